@@ -4,8 +4,11 @@ import (
 	"context"
 	"log"
 
+	"chess-backend/internal/book"
 	"chess-backend/internal/config"
+	"chess-backend/internal/engine"
 	"chess-backend/internal/store"
+	"chess-backend/internal/uci"
 )
 
 type app struct {
@@ -13,6 +16,8 @@ type app struct {
 	jwt_secret string
 	port       int
 	store      store.GameStore
+	engine     engine.Searcher // nil for the built-in engine
+	book       *book.Book
 	// database_models
 
 }
@@ -37,6 +42,25 @@ func main() {
 		store:      dbStore,
 	}
 
+	if cfg.Engine.Path != "" {
+		pool, err := uci.NewPool(ctx, uci.Config{Path: cfg.Engine.Path, Options: cfg.Engine.Options}, cfg.Engine.PoolSize)
+		if err != nil {
+			log.Fatalf("Engine start error: %v", err)
+		}
+		defer pool.Close()
+		log.Printf("Using external engine %s", pool.Name())
+		app.engine = pool
+	}
+
+	if cfg.Book.Path != "" {
+		openings, err := book.Open(cfg.Book.Path)
+		if err != nil {
+			log.Fatalf("Opening book error: %v", err)
+		}
+		log.Printf("Loaded opening book with %d entries", openings.Len())
+		app.book = openings
+	}
+
 	if err := app.serve(); err != nil {
 		log.Fatalf("Serve error %v", err)
 	}
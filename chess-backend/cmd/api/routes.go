@@ -10,7 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func (app *app) routes() http.Handler {
+func (app *app) routes(handlers *api.Handlers) http.Handler {
 	g := gin.Default()
 	g.Use(api.CORSMiddleware())
 
@@ -21,10 +21,10 @@ func (app *app) routes() http.Handler {
 
 	v1 := g.Group("/api/v1")
 	v1.Use(api.NoopAuthMiddleware())
-	handlers := api.NewHandlers(app.store)
 	const generalTimeout = 7 * time.Second
 	{
 		v1.POST("/games", withTimeout(generalTimeout, handlers.CreateGame))
+		v1.POST("/games/import", withTimeout(generalTimeout, handlers.ImportGame))
 		v1.GET("/games/:id", withTimeout(generalTimeout, handlers.GetGame))
 		v1.POST("/games/:id/join", withTimeout(generalTimeout, handlers.JoinGame))
 		v1.GET("/games/:id/stream", handlers.StreamGame)
@@ -32,9 +32,17 @@ func (app *app) routes() http.Handler {
 		v1.POST("/games/:id/moves", withTimeout(generalTimeout, handlers.MakeMove))
 		v1.GET("/games/:id/status", withTimeout(generalTimeout, handlers.Status))
 		v1.GET("/games/:id/history", withTimeout(generalTimeout, handlers.History))
+		v1.GET("/games/:id/pgn", withTimeout(generalTimeout, handlers.ExportPGN))
+		v1.GET("/games/:id/analysis", withTimeout(generalTimeout, handlers.AnalyzeGame))
+		v1.GET("/games/:id/book", withTimeout(generalTimeout, handlers.Book))
 		v1.POST("/games/:id/resign", withTimeout(generalTimeout, handlers.Resign))
 		v1.POST("/games/:id/offer-draw", withTimeout(generalTimeout, handlers.OfferDraw))
 		v1.POST("/games/:id/accept-draw", withTimeout(generalTimeout, handlers.AcceptDraw))
+		v1.POST("/games/:id/claim-draw", withTimeout(generalTimeout, handlers.ClaimDraw))
+		v1.POST("/games/:id/takeback", withTimeout(generalTimeout, handlers.OfferTakeback))
+		v1.POST("/games/:id/takeback/accept", withTimeout(generalTimeout, handlers.AcceptTakeback))
+		v1.POST("/games/:id/takeback/decline", withTimeout(generalTimeout, handlers.DeclineTakeback))
+		v1.POST("/analysis", withTimeout(generalTimeout, handlers.AnalyzePosition))
 	}
 
 	return g
@@ -1,16 +1,44 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
+
+	"chess-backend/internal/api"
 )
 
+// shutdownTimeout bounds how long requests in flight get to finish.
+const shutdownTimeout = 30 * time.Second
+
+// serve runs the server until it fails or the process is told to stop. On a
+// stop it lets requests, the flag checker and bot moves finish, since they
+// all write to the store that main closes once serve returns.
 func (app *app) serve() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	handlers := api.NewHandlers(app.store)
+	if app.engine != nil {
+		handlers.UseSearcher(app.engine)
+	}
+	handlers.UseBook(app.book)
+
+	flagChecker := make(chan struct{})
+	go func() {
+		defer close(flagChecker)
+		handlers.RunFlagChecker(ctx, time.Second)
+	}()
+
 	server := http.Server{
 		Addr:         fmt.Sprintf("0.0.0.0:%d", app.port),
-		Handler:      app.routes(),
+		Handler:      app.routes(handlers),
 		IdleTimeout:  time.Minute,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 30 * time.Second,
@@ -18,5 +46,24 @@ func (app *app) serve() error {
 
 	log.Printf("Starting server on port %d", app.port)
 
-	return server.ListenAndServe()
+	served := make(chan error, 1)
+	go func() { served <- server.ListenAndServe() }()
+
+	var err error
+	select {
+	case err = <-served:
+		stop()
+	case <-ctx.Done():
+		log.Printf("Shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		err = server.Shutdown(shutdownCtx)
+	}
+
+	<-flagChecker
+	handlers.WaitForBots()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
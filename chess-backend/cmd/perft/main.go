@@ -0,0 +1,53 @@
+// Command perft counts move generation leaf nodes for a position, optionally
+// split by root move, for comparing against other engines.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"chess-backend/internal/chess"
+)
+
+func main() {
+	fen := flag.String("fen", chess.StartingFEN, "position to search")
+	depth := flag.Int("depth", 5, "search depth in plies")
+	divide := flag.Bool("divide", false, "print the node count below each root move")
+	flag.Parse()
+
+	board, err := chess.LoadFEN(*fen)
+	if err != nil {
+		log.Fatalf("Invalid FEN: %v", err)
+	}
+	if *depth < 1 {
+		log.Fatalf("Depth must be at least 1")
+	}
+
+	start := time.Now()
+	var nodes uint64
+	if *divide {
+		counts := board.Divide(*depth)
+		moves := make([]chess.Move, 0, len(counts))
+		for m := range counts {
+			moves = append(moves, m)
+		}
+		sort.Slice(moves, func(i, j int) bool { return moves[i].UCI() < moves[j].UCI() })
+		for _, m := range moves {
+			fmt.Printf("%s: %d\n", m.UCI(), counts[m])
+			nodes += counts[m]
+		}
+		fmt.Println()
+	} else {
+		nodes = board.Perft(*depth)
+	}
+	elapsed := time.Since(start)
+
+	fmt.Printf("Nodes: %d\n", nodes)
+	fmt.Printf("Time:  %s\n", elapsed.Round(time.Millisecond))
+	if elapsed > 0 {
+		fmt.Printf("NPS:   %.0f\n", float64(nodes)/elapsed.Seconds())
+	}
+}
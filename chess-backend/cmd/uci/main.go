@@ -0,0 +1,15 @@
+// Command uci runs the built-in engine as a Universal Chess Interface engine
+// over stdin and stdout, so it can be loaded into chess GUIs such as Arena or
+// cutechess for testing and engine-vs-engine matches.
+package main
+
+import (
+	"log"
+	"os"
+)
+
+func main() {
+	if err := newSession(os.Stdout).run(os.Stdin); err != nil {
+		log.Fatalf("UCI session error: %v", err)
+	}
+}
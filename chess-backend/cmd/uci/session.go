@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"chess-backend/internal/chess"
+	"chess-backend/internal/engine"
+)
+
+const (
+	engineName   = "go-chess"
+	engineAuthor = "go-chess contributors"
+
+	maxHashMB  = 1024
+	maxMultiPV = 5
+
+	// moveOverhead is kept back from every timed move for GUI and pipe latency
+	moveOverhead = 50 * time.Millisecond
+	// defaultMovesToGo is assumed when the GUI does not say how many moves
+	// remain until the next time control
+	defaultMovesToGo = 30
+)
+
+// session is one UCI conversation. Commands are read on one goroutine while
+// a search runs on another, so all output goes through send.
+type session struct {
+	out   io.Writer
+	outMu sync.Mutex
+
+	engine   *engine.Engine
+	multiPV  int
+	chess960 bool // castling is sent and received as king takes rook
+	board    *chess.Board
+
+	// set while a search is running
+	cancel   context.CancelFunc
+	done     chan struct{}
+	stopped  chan struct{} // closed by stop; infinite searches wait for it
+	infinite bool
+}
+
+func newSession(out io.Writer) *session {
+	return &session{
+		out:     out,
+		engine:  engine.New(engine.DefaultHashMB),
+		multiPV: 1,
+		board:   chess.NewBoard(),
+	}
+}
+
+func (s *session) send(format string, args ...any) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	fmt.Fprintf(s.out, format+"\n", args...)
+}
+
+func (s *session) run(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch cmd, args := fields[0], fields[1:]; cmd {
+		case "uci":
+			s.send("id name %s", engineName)
+			s.send("id author %s", engineAuthor)
+			s.send("option name Hash type spin default %d min 1 max %d", engine.DefaultHashMB, maxHashMB)
+			s.send("option name MultiPV type spin default 1 min 1 max %d", maxMultiPV)
+			s.send("option name Clear Hash type button")
+			s.send("option name UCI_Chess960 type check default false")
+			s.send("uciok")
+		case "isready":
+			s.send("readyok")
+		case "ucinewgame":
+			s.stop()
+			s.engine.Clear()
+			s.board = s.startPosition()
+		case "setoption":
+			s.stop()
+			s.setOption(args)
+		case "position":
+			s.stop()
+			if err := s.setPosition(args); err != nil {
+				s.send("info string %v", err)
+			}
+		case "go":
+			s.stop()
+			s.goSearch(args)
+		case "stop":
+			s.stop()
+		case "quit":
+			s.stop()
+			return nil
+		default:
+			s.send("info string unknown command %s", cmd)
+		}
+	}
+
+	// with piped input such as "go depth 8" and then end of input, let a
+	// bounded search finish rather than cutting it short
+	if s.done != nil && !s.infinite {
+		<-s.done
+	}
+	s.stop()
+	return scanner.Err()
+}
+
+// setOption handles "setoption name <id> [value <x>]"; names may contain
+// spaces.
+func (s *session) setOption(args []string) {
+	var name, value []string
+	target := &name
+	for _, a := range args {
+		switch a {
+		case "name":
+			target = &name
+		case "value":
+			target = &value
+		default:
+			*target = append(*target, a)
+		}
+	}
+
+	switch strings.ToLower(strings.Join(name, " ")) {
+	case "hash":
+		mb, err := strconv.Atoi(strings.Join(value, ""))
+		if err != nil || mb < 1 || mb > maxHashMB {
+			s.send("info string invalid Hash value")
+			return
+		}
+		s.engine = engine.New(mb)
+	case "multipv":
+		n, err := strconv.Atoi(strings.Join(value, ""))
+		if err != nil || n < 1 || n > maxMultiPV {
+			s.send("info string invalid MultiPV value")
+			return
+		}
+		s.multiPV = n
+	case "clear hash":
+		s.engine.Clear()
+	case "uci_chess960":
+		s.chess960 = strings.Join(value, "") == "true"
+		s.board = s.startPosition()
+	default:
+		s.send("info string unknown option %s", strings.Join(name, " "))
+	}
+}
+
+// setPosition handles "position startpos|fen <fen> [moves <m1> ...]". The
+// current position is kept when the command is invalid.
+func (s *session) setPosition(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("position needs startpos or fen")
+	}
+
+	var board *chess.Board
+	rest := args[1:]
+	switch args[0] {
+	case "startpos":
+		board = s.startPosition()
+	case "fen":
+		end := len(rest)
+		for i, a := range rest {
+			if a == "moves" {
+				end = i
+				break
+			}
+		}
+		load := chess.LoadFEN
+		if s.chess960 {
+			load = chess.LoadChess960FEN
+		}
+		var err error
+		board, err = load(strings.Join(rest[:end], " "))
+		if err != nil {
+			return fmt.Errorf("invalid fen: %w", err)
+		}
+		rest = rest[end:]
+	default:
+		return fmt.Errorf("position needs startpos or fen")
+	}
+
+	if len(rest) > 0 && rest[0] == "moves" {
+		for _, uci := range rest[1:] {
+			move, err := chess.ParseUCI(uci)
+			if err != nil {
+				return fmt.Errorf("invalid move %s: %w", uci, err)
+			}
+			if err := board.MakeMove(move); err != nil {
+				return fmt.Errorf("illegal move %s: %w", uci, err)
+			}
+		}
+	}
+
+	s.board = board
+	return nil
+}
+
+func (s *session) startPosition() *chess.Board {
+	if s.chess960 {
+		board, _ := chess.NewChess960Board(chess.StandardChess960Position)
+		return board
+	}
+	return chess.NewBoard()
+}
+
+type goParams struct {
+	limits   engine.Limits
+	infinite bool
+}
+
+func (s *session) parseGo(args []string) goParams {
+	var p goParams
+	var wtime, btime, winc, binc time.Duration
+	movesToGo := 0
+
+	next := func(i *int) int {
+		*i++
+		if *i >= len(args) {
+			return 0
+		}
+		n, _ := strconv.Atoi(args[*i])
+		return n
+	}
+	ms := func(n int) time.Duration { return time.Duration(n) * time.Millisecond }
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "depth":
+			p.limits.Depth = next(&i)
+		case "nodes":
+			p.limits.Nodes = uint64(max(next(&i), 0))
+		case "movetime":
+			p.limits.MoveTime = max(ms(next(&i))-moveOverhead, time.Millisecond)
+		case "wtime":
+			wtime = ms(next(&i))
+		case "btime":
+			btime = ms(next(&i))
+		case "winc":
+			winc = ms(next(&i))
+		case "binc":
+			binc = ms(next(&i))
+		case "movestogo":
+			movesToGo = next(&i)
+		case "infinite":
+			p.infinite = true
+		}
+	}
+
+	remaining, inc := wtime, winc
+	if s.board.Turn() == chess.Black {
+		remaining, inc = btime, binc
+	}
+	if remaining > 0 && p.limits.MoveTime == 0 && !p.infinite {
+		p.limits.MoveTime = moveBudget(remaining, inc, movesToGo)
+	}
+	return p
+}
+
+// moveBudget spreads the remaining time over the moves left until the next
+// time control, never planning to use more than is actually left.
+func moveBudget(remaining, inc time.Duration, movesToGo int) time.Duration {
+	if movesToGo <= 0 {
+		movesToGo = defaultMovesToGo
+	}
+	budget := remaining/time.Duration(movesToGo) + inc*3/4
+	budget = min(budget, remaining-moveOverhead)
+	return max(budget, 10*time.Millisecond)
+}
+
+func (s *session) goSearch(args []string) {
+	p := s.parseGo(args)
+	p.limits.MultiPV = s.multiPV
+	p.limits.Info = s.sendInfo
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+	s.stopped = make(chan struct{})
+	s.infinite = p.infinite
+
+	board, eng, done, stopped := s.board.Clone(), s.engine, s.done, s.stopped
+	go func() {
+		defer close(done)
+		result, err := eng.Search(ctx, board, p.limits)
+		// in infinite mode bestmove must wait for stop, even after a mate
+		if p.infinite {
+			<-stopped
+		}
+		if err != nil {
+			s.send("bestmove 0000")
+			return
+		}
+		if len(result.PV) > 1 {
+			s.send("bestmove %s ponder %s", result.Move.UCI(), result.PV[1].UCI())
+		} else {
+			s.send("bestmove %s", result.Move.UCI())
+		}
+	}()
+}
+
+// stop ends the running search, if any, and waits for its bestmove.
+func (s *session) stop() {
+	if s.cancel == nil {
+		return
+	}
+	close(s.stopped)
+	s.cancel()
+	<-s.done
+	s.cancel, s.done, s.stopped = nil, nil, nil
+}
+
+func (s *session) sendInfo(r engine.Result) {
+	nps := uint64(0)
+	if r.Elapsed > 0 {
+		nps = uint64(float64(r.Nodes) / r.Elapsed.Seconds())
+	}
+	for i, line := range r.Lines {
+		pv := make([]string, len(line.PV))
+		for j, m := range line.PV {
+			pv[j] = m.UCI()
+		}
+		s.send("info depth %d multipv %d score %s nodes %d nps %d time %d pv %s",
+			r.Depth, i+1, uciScore(line.Score), r.Nodes, nps, r.Elapsed.Milliseconds(), strings.Join(pv, " "))
+	}
+}
+
+func uciScore(score engine.Score) string {
+	if mate, ok := score.Mate(); ok {
+		return "mate " + strconv.Itoa(mate)
+	}
+	return "cp " + strconv.Itoa(int(score))
+}
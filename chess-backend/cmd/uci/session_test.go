@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func runSession(t *testing.T, input string) []string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := newSession(&out).run(strings.NewReader(input)); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(out.String()), "\n")
+}
+
+func lastLine(lines []string) string {
+	return lines[len(lines)-1]
+}
+
+func TestHandshake(t *testing.T) {
+	lines := runSession(t, "uci\nisready\n")
+	if lines[0] != "id name "+engineName {
+		t.Fatalf("expected the engine name first, got %q", lines[0])
+	}
+	if lines[len(lines)-2] != "uciok" || lastLine(lines) != "readyok" {
+		t.Fatalf("expected uciok then readyok, got %q", lines)
+	}
+}
+
+func TestGoFindsMate(t *testing.T) {
+	lines := runSession(t, "position fen 6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1\ngo depth 3\n")
+	if lastLine(lines) != "bestmove a1a8" {
+		t.Fatalf("expected bestmove a1a8, got %q", lastLine(lines))
+	}
+	if !strings.Contains(lines[0], "score mate 1") || !strings.HasSuffix(lines[0], "pv a1a8") {
+		t.Fatalf("expected an info line with the mate, got %q", lines[0])
+	}
+}
+
+func TestPositionWithMoves(t *testing.T) {
+	// after 1.f3 e5 2.g4 black mates with Qh4
+	lines := runSession(t, "position startpos moves f2f3 e7e5 g2g4\ngo depth 2\n")
+	if lastLine(lines) != "bestmove d8h4" {
+		t.Fatalf("expected bestmove d8h4, got %q", lastLine(lines))
+	}
+
+	lines = runSession(t, "position startpos moves e2e5\n")
+	if !strings.HasPrefix(lines[0], "info string illegal move e2e5") {
+		t.Fatalf("expected an illegal move to be reported, got %q", lines)
+	}
+}
+
+func TestChess960Castling(t *testing.T) {
+	moves := "position startpos moves g1f3 g8f6 g2g3 g7g6 f1g2 f8g7 e1h1\n"
+	lines := runSession(t, "setoption name UCI_Chess960 value true\n"+moves+"go depth 1\n")
+	if !strings.HasPrefix(lastLine(lines), "bestmove ") || strings.HasPrefix(lines[0], "info string") {
+		t.Fatalf("expected king-takes-rook castling to be accepted, got %q", lines)
+	}
+
+	lines = runSession(t, moves)
+	if !strings.HasPrefix(lines[0], "info string illegal move e1h1") {
+		t.Fatalf("expected e1h1 to be illegal without UCI_Chess960, got %q", lines)
+	}
+}
+
+func TestMultiPVAndPonder(t *testing.T) {
+	lines := runSession(t, "setoption name MultiPV value 3\nposition startpos\ngo depth 3\n")
+	var depth3 int
+	for _, l := range lines {
+		if strings.HasPrefix(l, "info depth 3 ") {
+			depth3++
+		}
+	}
+	if depth3 != 3 {
+		t.Fatalf("expected 3 lines at depth 3, got %d in %q", depth3, lines)
+	}
+	if !strings.Contains(lastLine(lines), " ponder ") {
+		t.Fatalf("expected a ponder move, got %q", lastLine(lines))
+	}
+}
+
+func TestStopInfiniteSearch(t *testing.T) {
+	start := time.Now()
+	lines := runSession(t, "position startpos\ngo infinite\nstop\n")
+	if !strings.HasPrefix(lastLine(lines), "bestmove ") {
+		t.Fatalf("expected bestmove after stop, got %q", lines)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatalf("stop did not end the search promptly")
+	}
+}
+
+func TestMoveBudget(t *testing.T) {
+	if got := moveBudget(60*time.Second, 0, 0); got != 2*time.Second {
+		t.Fatalf("expected a thirtieth of the clock, got %v", got)
+	}
+	if got := moveBudget(10*time.Second, 2*time.Second, 5); got != 3500*time.Millisecond {
+		t.Fatalf("expected the increment to be used, got %v", got)
+	}
+	if got := moveBudget(100*time.Millisecond, time.Second, 1); got != 50*time.Millisecond {
+		t.Fatalf("expected the budget to stay within the clock, got %v", got)
+	}
+}
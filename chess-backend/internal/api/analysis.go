@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"chess-backend/internal/chess"
+	"chess-backend/internal/engine"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxAnalysisDepth    = 30
+	maxAnalysisMultiPV  = 5
+	defaultAnalysisTime = time.Second
+	maxAnalysisTime     = 5 * time.Second
+	minAnalysisTime     = 100 * time.Millisecond
+
+	// analysisMargin is left of the request deadline to build the response
+	analysisMargin = 250 * time.Millisecond
+)
+
+func (h *Handlers) AnalyzeGame(c *gin.Context) {
+	id := c.Param("id")
+
+	var req AnalysisRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid query parameters")
+		return
+	}
+
+	game, err := h.store.GetGame(c.Request.Context(), id)
+	if err != nil {
+		handleStoreError(c, err)
+		return
+	}
+
+	h.writeAnalysis(c, game.Board, req)
+}
+
+func (h *Handlers) AnalyzePosition(c *gin.Context) {
+	var req AnalysisRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	board, err := chess.LoadFEN(strings.TrimSpace(req.FEN))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeAnalysis(c, board, req)
+}
+
+func (h *Handlers) writeAnalysis(c *gin.Context, board *chess.Board, req AnalysisRequest) {
+	limits, err := analysisLimits(c.Request.Context(), req)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := h.searcher.Search(c.Request.Context(), board, limits)
+	if errors.Is(err, engine.ErrNoLegalMoves) {
+		writeError(c, http.StatusUnprocessableEntity, "no legal moves in this position")
+		return
+	}
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "analysis failed")
+		return
+	}
+	if result.Depth == 0 {
+		writeError(c, http.StatusServiceUnavailable, "analysis did not finish within the time budget")
+		return
+	}
+
+	c.JSON(http.StatusOK, buildAnalysisResponse(board, result))
+}
+
+// analysisLimits turns the request into search limits that always finish
+// before the request's own deadline.
+func analysisLimits(ctx context.Context, req AnalysisRequest) (engine.Limits, error) {
+	if req.Depth < 0 || req.Depth > maxAnalysisDepth {
+		return engine.Limits{}, fmt.Errorf("depth must be between 1 and %d", maxAnalysisDepth)
+	}
+	if req.MultiPV < 0 || req.MultiPV > maxAnalysisMultiPV {
+		return engine.Limits{}, fmt.Errorf("multiPv must be between 1 and %d", maxAnalysisMultiPV)
+	}
+	if req.MoveTimeMs < 0 {
+		return engine.Limits{}, errors.New("moveTimeMs must not be negative")
+	}
+
+	moveTime := defaultAnalysisTime
+	if req.MoveTimeMs > 0 {
+		moveTime = min(time.Duration(req.MoveTimeMs)*time.Millisecond, maxAnalysisTime)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		moveTime = min(moveTime, time.Until(deadline)-analysisMargin)
+	}
+
+	return engine.Limits{
+		Depth:    req.Depth,
+		MoveTime: max(moveTime, minAnalysisTime),
+		MultiPV:  req.MultiPV,
+	}, nil
+}
+
+func buildAnalysisResponse(board *chess.Board, result engine.Result) AnalysisResponse {
+	turn := board.Turn()
+	response := AnalysisResponse{
+		FEN:      board.ToFEN(),
+		Turn:     turn.String(),
+		Depth:    result.Depth,
+		Nodes:    result.Nodes,
+		TimeMs:   result.Elapsed.Milliseconds(),
+		BestMove: uciFromMove(result.Move),
+		Lines:    make([]AnalysisLine, 0, len(result.Lines)),
+	}
+	for _, line := range result.Lines {
+		uci, san := pvNotation(board, line.PV)
+		response.Lines = append(response.Lines, AnalysisLine{
+			Score: scoreForWhite(line.Score, turn),
+			PV:    uci,
+			PVSAN: san,
+		})
+	}
+
+	best := response.Lines[0]
+	response.Score = best.Score
+	response.PV = best.PV
+	response.PVSAN = best.PVSAN
+	if len(best.PVSAN) > 0 {
+		response.BestMoveSAN = best.PVSAN[0]
+	}
+	return response
+}
+
+// scoreForWhite reports the engine's score, which is from the side to move's
+// point of view, from white's point of view like an evaluation bar.
+func scoreForWhite(score engine.Score, turn chess.Color) ScoreResponse {
+	sign := 1
+	if turn == chess.Black {
+		sign = -1
+	}
+	if mate, ok := score.Mate(); ok {
+		mate *= sign
+		return ScoreResponse{Mate: &mate}
+	}
+	cp := int(score) * sign
+	return ScoreResponse{Cp: &cp}
+}
+
+func pvNotation(board *chess.Board, pv []chess.Move) (uci, san []string) {
+	b := board.Clone()
+	uci = make([]string, 0, len(pv))
+	san = make([]string, 0, len(pv))
+	for _, m := range pv {
+		s, err := b.SAN(m)
+		if err != nil {
+			break
+		}
+		b.DoMove(m)
+		uci = append(uci, uciFromMove(m))
+		san = append(san, s)
+	}
+	return uci, san
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+
+	"chess-backend/internal/chess"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Book lists the opening book's moves for the game's current position. Without
+// a book, or out of book, the list is empty.
+func (h *Handlers) Book(c *gin.Context) {
+	id := c.Param("id")
+
+	game, err := h.store.GetGame(c.Request.Context(), id)
+	if err != nil {
+		handleStoreError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.buildBookResponse(game.Board))
+}
+
+func (h *Handlers) buildBookResponse(board *chess.Board) BookResponse {
+	moves := h.book.Moves(board)
+	total := 0
+	for _, m := range moves {
+		total += m.Weight
+	}
+
+	response := BookResponse{
+		FEN:   board.ToFEN(),
+		Moves: make([]BookMoveResponse, 0, len(moves)),
+	}
+	for _, m := range moves {
+		san, err := board.SAN(m.Move)
+		if err != nil {
+			continue
+		}
+		response.Moves = append(response.Moves, BookMoveResponse{
+			Move:        uciFromMove(m.Move),
+			SAN:         san,
+			Weight:      m.Weight,
+			Probability: float64(m.Weight) / float64(total),
+		})
+	}
+	return response
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"chess-backend/internal/bot"
+	"chess-backend/internal/chess"
+	"chess-backend/internal/store"
+)
+
+const (
+	opponentHuman = "human"
+	opponentBot   = "bot"
+
+	// botMoveTimeout bounds a single bot move, whatever its level
+	botMoveTimeout = 30 * time.Second
+)
+
+func parseOpponent(opponent string, level int, color chess.Color) (*store.Bot, error) {
+	switch strings.ToLower(strings.TrimSpace(opponent)) {
+	case "", opponentHuman:
+		return nil, nil
+	case opponentBot:
+	default:
+		return nil, fmt.Errorf("invalid opponent %q", opponent)
+	}
+
+	if level == 0 {
+		level = bot.DefaultLevel
+	}
+	if err := bot.ValidateLevel(level); err != nil {
+		return nil, fmt.Errorf("botLevel must be between %d and %d", bot.MinLevel, bot.MaxLevel)
+	}
+	return &store.Bot{Color: color, Level: level}, nil
+}
+
+// seatBot gives the bot's seat a token nobody receives, so the game counts as
+// full and only the server moves for that side.
+func seatBot(game *store.Game, b *store.Bot) {
+	game.Bot = b
+	now := game.CreatedAt
+	if b.Color == chess.White {
+		game.PlayerWhiteToken = newPlayerToken()
+		game.PlayerWhiteJoinedAt = &now
+	} else {
+		game.PlayerBlackToken = newPlayerToken()
+		game.PlayerBlackJoinedAt = &now
+	}
+}
+
+// startBotMove computes the bot's reply in the background when it is the
+// bot's turn in an ongoing game.
+func (h *Handlers) startBotMove(game *store.Game) {
+	if game.Bot == nil || game.Board.Turn() != game.Bot.Color || game.Result != resultOngoing {
+		return
+	}
+
+	h.bots.Add(1)
+	go func() {
+		defer h.bots.Done()
+		if err := h.playBotMove(game.ID); err != nil {
+			log.Printf("bot move for game %s: %v", game.ID, err)
+		}
+	}()
+}
+
+// WaitForBots blocks until every bot move being computed in the background
+// has been saved or dropped. Call it before closing the store.
+func (h *Handlers) WaitForBots() {
+	h.bots.Wait()
+}
+
+// playBotMove reloads the game, searches and saves the bot's move through the
+// same path as a player's move. If the game changed while the bot was
+// thinking, e.g. its opponent resigned, the move is dropped.
+func (h *Handlers) playBotMove(gameID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), botMoveTimeout)
+	defer cancel()
+
+	game, err := h.store.GetGame(ctx, gameID)
+	if err != nil {
+		return err
+	}
+	if game.Bot == nil || game.Board.Turn() != game.Bot.Color || computeStatus(game).Result != resultOngoing {
+		return nil
+	}
+	color := game.Bot.Color
+
+	// the book varies the openings, which the search alone would play the
+	// same way every game
+	move, inBook := h.book.Pick(game.Board)
+	if !inBook {
+		searchCtx, cancelSearch := context.WithTimeout(ctx, botThinkTime(game, time.Now().UTC()))
+		move, err = bot.ChooseMove(searchCtx, h.searcher, game.Board, game.Bot.Level)
+		cancelSearch()
+		if err != nil {
+			return err
+		}
+	}
+
+	now := time.Now().UTC()
+	if err := h.expireClock(ctx, game, now); err != nil {
+		return ignoreConflict(err)
+	}
+	if computeStatus(game).Result != resultOngoing {
+		return nil
+	}
+
+	record, err := playMove(game, move, color, now)
+	if err != nil {
+		return err
+	}
+	if err := h.store.UpdateGameWithMove(ctx, game, record); err != nil {
+		return ignoreConflict(err)
+	}
+	h.broadcastGame(game)
+	return nil
+}
+
+// botThinkTime keeps a bot in a timed game from losing on time: it spends at
+// most a thirtieth of what is left on its clock.
+func botThinkTime(game *store.Game, now time.Time) time.Duration {
+	budget := botMoveTimeout
+	if c := game.Clock; c != nil && c.Running() {
+		budget = min(budget, c.RemainingAt(game.Bot.Color, game.Board.Turn(), now)/30)
+	}
+	return budget
+}
+
+func ignoreConflict(err error) error {
+	if errors.Is(err, store.ErrConflict) {
+		return nil
+	}
+	return err
+}
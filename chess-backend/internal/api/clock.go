@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"chess-backend/internal/chess"
+	"chess-backend/internal/clock"
+	"chess-backend/internal/store"
+)
+
+func parseTimeControl(req *TimeControlRequest) (clock.TimeControl, error) {
+	stages := req.Stages
+	if len(stages) == 0 {
+		stages = []TimeControlStage{{
+			BaseSeconds:      req.BaseSeconds,
+			IncrementSeconds: req.IncrementSeconds,
+			DelaySeconds:     req.DelaySeconds,
+			DelayMode:        req.DelayMode,
+		}}
+	}
+
+	tc := clock.TimeControl{Stages: make([]clock.Stage, 0, len(stages))}
+	for _, s := range stages {
+		tc.Stages = append(tc.Stages, clock.Stage{
+			Moves:     s.Moves,
+			Time:      time.Duration(s.BaseSeconds) * time.Second,
+			Increment: time.Duration(s.IncrementSeconds) * time.Second,
+			Delay:     time.Duration(s.DelaySeconds) * time.Second,
+			DelayMode: clock.DelayMode(s.DelayMode),
+		})
+	}
+	if err := tc.Validate(); err != nil {
+		return clock.TimeControl{}, err
+	}
+	return tc, nil
+}
+
+func buildClockResponse(game *store.Game, status Status, now time.Time) *ClockResponse {
+	c := game.Clock
+	if c == nil {
+		return nil
+	}
+
+	turn := game.Board.Turn()
+	response := &ClockResponse{
+		TimeControl: c.Control.String(),
+		WhiteMs:     c.Remaining[chess.White].Milliseconds(),
+		BlackMs:     c.Remaining[chess.Black].Milliseconds(),
+		ServerTime:  now,
+	}
+	if status.Result == resultOngoing && c.Running() {
+		response.WhiteMs = c.RemainingAt(chess.White, turn, now).Milliseconds()
+		response.BlackMs = c.RemainingAt(chess.Black, turn, now).Milliseconds()
+		response.Running = turn.String()
+	}
+	return response
+}
+
+// applyTimeout ends the game if the side to move has run out of time. The
+// game is drawn instead when the opponent has too little material to mate, a
+// lone king or a king and one minor piece; variants with other ways to win
+// always score the timeout.
+func applyTimeout(game *store.Game, now time.Time) bool {
+	if game.Clock == nil || !game.Clock.Flagged(game.Board.Turn(), now) {
+		return false
+	}
+	if status := computeStatus(game); status.Result != resultOngoing {
+		return false
+	}
+
+	loser := game.Board.Turn()
+	game.Clock.Stop(loser, now)
+	if game.Board.Orthodox() && game.Board.HasInsufficientMaterial(loser.Opposite()) {
+		game.Result = resultDraw
+		game.Winner = "none"
+	} else {
+		game.Result = resultTimeout
+		game.Winner = loser.Opposite().String()
+	}
+	game.EndedBy = endedByTimeout
+	game.PendingDrawOfferBy = nil
+	game.UpdatedAt = now
+	return true
+}
+
+func stopClock(game *store.Game, now time.Time) {
+	if game.Clock != nil {
+		game.Clock.Stop(game.Board.Turn(), now)
+	}
+}
+
+// restartClock charges the side that was thinking up to now, takes back the
+// credit the clock gave for the undone plies and starts the clock of whoever
+// is to move after a takeback.
+func restartClock(game *store.Game, thinking chess.Color, plies int, now time.Time) {
+	if game.Clock == nil || !game.Clock.Running() {
+		return
+	}
+	game.Clock.Stop(thinking, now)
+	mover := thinking
+	for range plies {
+		mover = mover.Opposite()
+		game.Clock.Unpunch(mover)
+	}
+	game.Clock.LastPunch = &now
+}
+
+// expireClock persists and broadcasts a time forfeit if one is due.
+func (h *Handlers) expireClock(ctx context.Context, game *store.Game, now time.Time) error {
+	if !applyTimeout(game, now) {
+		return nil
+	}
+	if err := h.store.UpdateGame(ctx, game); err != nil {
+		return err
+	}
+	h.broadcastGame(game)
+	return nil
+}
+
+// RunFlagChecker ends timed games whose side to move has flagged, so a player
+// who simply stops moving still loses on time. It blocks until ctx is done.
+func (h *Handlers) RunFlagChecker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.checkFlags(ctx, time.Now().UTC())
+		}
+	}
+}
+
+func (h *Handlers) checkFlags(ctx context.Context, now time.Time) {
+	ids, err := h.store.ListActiveTimedGames(ctx)
+	if err != nil {
+		log.Printf("flag checker: list games: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		game, err := h.store.GetGame(ctx, id)
+		if err != nil {
+			log.Printf("flag checker: get game %s: %v", id, err)
+			continue
+		}
+		// a conflict means the game moved on since we read it; the next tick rechecks
+		if err := h.expireClock(ctx, game, now); err != nil && !errors.Is(err, store.ErrConflict) {
+			log.Printf("flag checker: expire game %s: %v", id, err)
+		}
+	}
+}
@@ -3,12 +3,56 @@ package api
 import "time"
 
 type CreateGameRequest struct {
-	Fen            string `json:"fen"`
+	Fen            string              `json:"fen"`
+	PreferredColor string              `json:"preferredColor"`
+	TimeControl    *TimeControlRequest `json:"timeControl,omitempty"`
+	// Opponent is "human" (the default) or "bot"; a bot takes the other
+	// colour straight away and plays at BotLevel.
+	Opponent string `json:"opponent,omitempty"`
+	BotLevel int    `json:"botLevel,omitempty"`
+	// Variant is "standard" (the default) or "chess960". Chess960 games
+	// start from Chess960Position, 0-959, or a random one; Fen may give a
+	// Chess960 position instead.
+	Variant          string `json:"variant,omitempty"`
+	Chess960Position *int   `json:"chess960Position,omitempty"`
+	// Odds starts a standard game from a handicap position: "pawnAndMove",
+	// "knight", "rook" or "queen".
+	Odds string `json:"odds,omitempty"`
+}
+
+// TimeControlRequest describes a single-stage control via the top-level
+// fields, or a multi-stage one ("40 moves in 90 minutes, then ...") via Stages.
+type TimeControlRequest struct {
+	BaseSeconds      int                `json:"baseSeconds"`
+	IncrementSeconds int                `json:"incrementSeconds"`
+	DelaySeconds     int                `json:"delaySeconds"`
+	DelayMode        string             `json:"delayMode"`
+	Stages           []TimeControlStage `json:"stages,omitempty"`
+}
+
+type TimeControlStage struct {
+	Moves            int    `json:"moves"`
+	BaseSeconds      int    `json:"baseSeconds"`
+	IncrementSeconds int    `json:"incrementSeconds"`
+	DelaySeconds     int    `json:"delaySeconds"`
+	DelayMode        string `json:"delayMode"`
+}
+
+type ImportGameRequest struct {
+	PGN            string `json:"pgn"`
+	Index          int    `json:"index"`
 	PreferredColor string `json:"preferredColor"`
 }
 
 type MoveRequest struct {
 	UCI string `json:"uci"`
+	// ClientMoveID makes retries safe: resubmitting the same ID returns the
+	// original response instead of playing the move again.
+	ClientMoveID string `json:"clientMoveId,omitempty"`
+	// ExpectedPly and ExpectedFEN reject the move if the game has moved on
+	// since the client last saw it.
+	ExpectedPly *int   `json:"expectedPly,omitempty"`
+	ExpectedFEN string `json:"expectedFen,omitempty"`
 }
 
 type ResignRequest struct {
@@ -23,6 +67,10 @@ type AcceptDrawRequest struct {
 	Color string `json:"color"`
 }
 
+type ClaimDrawRequest struct {
+	UCI string `json:"uci,omitempty"`
+}
+
 type Flags struct {
 	InCheck       bool   `json:"inCheck"`
 	Checkmate     bool   `json:"checkmate"`
@@ -30,6 +78,15 @@ type Flags struct {
 	Draw          bool   `json:"draw"`
 	DrawReason    string `json:"drawReason,omitempty"`
 	DrawClaimable bool   `json:"drawClaimable,omitempty"`
+	Unwinnable    bool   `json:"unwinnable,omitempty"`
+}
+
+type ClockResponse struct {
+	TimeControl string    `json:"timeControl"`
+	WhiteMs     int64     `json:"whiteMs"`
+	BlackMs     int64     `json:"blackMs"`
+	Running     string    `json:"running,omitempty"`
+	ServerTime  time.Time `json:"serverTime"`
 }
 
 type Meta struct {
@@ -39,31 +96,65 @@ type Meta struct {
 }
 
 type GameResponse struct {
-	ID               string `json:"id"`
-	FEN              string `json:"fen"`
-	Turn             string `json:"turn"`
-	Result           string `json:"result"`
-	Winner           string `json:"winner,omitempty"`
-	EndedBy          string `json:"endedBy,omitempty"`
-	PlayerColor      string `json:"playerColor,omitempty"`
-	BoardOrientation string `json:"boardOrientation,omitempty"`
-	Flags            Flags  `json:"flags"`
-	Halfmove         int    `json:"halfmove"`
-	Fullmove         int    `json:"fullmove"`
-	Meta             Meta   `json:"meta"`
+	ID                  string           `json:"id"`
+	FEN                 string           `json:"fen"`
+	Turn                string           `json:"turn"`
+	Result              string           `json:"result"`
+	Winner              string           `json:"winner,omitempty"`
+	EndedBy             string           `json:"endedBy,omitempty"`
+	PlayerColor         string           `json:"playerColor,omitempty"`
+	BoardOrientation    string           `json:"boardOrientation,omitempty"`
+	Flags               Flags            `json:"flags"`
+	Halfmove            int              `json:"halfmove"`
+	Fullmove            int              `json:"fullmove"`
+	Ply                 int              `json:"ply"`
+	Variant             string           `json:"variant"`
+	Clock               *ClockResponse   `json:"clock,omitempty"`
+	TakebackRequestedBy string           `json:"takebackRequestedBy,omitempty"`
+	Bot                 *BotResponse     `json:"bot,omitempty"`
+	Opening             *OpeningResponse `json:"opening,omitempty"`
+	Pockets             *PocketsResponse `json:"pockets,omitempty"`
+	Meta                Meta             `json:"meta"`
+}
+
+type BotResponse struct {
+	Color string `json:"color"`
+	Level int    `json:"level"`
+}
+
+type OpeningResponse struct {
+	ECO       string `json:"eco"`
+	Name      string `json:"name"`
+	Variation string `json:"variation,omitempty"`
+}
+
+// PocketsResponse lists the pieces each side holds in Crazyhouse.
+type PocketsResponse struct {
+	White PocketResponse `json:"white"`
+	Black PocketResponse `json:"black"`
+}
+
+type PocketResponse struct {
+	Pawn   int `json:"pawn"`
+	Knight int `json:"knight"`
+	Bishop int `json:"bishop"`
+	Rook   int `json:"rook"`
+	Queen  int `json:"queen"`
 }
 
 type MoveResponse struct {
-	FEN              string `json:"fen"`
-	Turn             string `json:"turn"`
-	Result           string `json:"result"`
-	Winner           string `json:"winner,omitempty"`
-	EndedBy          string `json:"endedBy,omitempty"`
-	PlayerColor      string `json:"playerColor,omitempty"`
-	BoardOrientation string `json:"boardOrientation,omitempty"`
-	Flags            Flags  `json:"flags"`
-	Halfmove         int    `json:"halfmove"`
-	Fullmove         int    `json:"fullmove"`
+	FEN              string         `json:"fen"`
+	Turn             string         `json:"turn"`
+	Result           string         `json:"result"`
+	Winner           string         `json:"winner,omitempty"`
+	EndedBy          string         `json:"endedBy,omitempty"`
+	PlayerColor      string         `json:"playerColor,omitempty"`
+	BoardOrientation string         `json:"boardOrientation,omitempty"`
+	Flags            Flags          `json:"flags"`
+	Halfmove         int            `json:"halfmove"`
+	Fullmove         int            `json:"fullmove"`
+	Ply              int            `json:"ply"`
+	Clock            *ClockResponse `json:"clock,omitempty"`
 }
 
 type StatusResponse struct {
@@ -93,6 +184,10 @@ type OfferDrawResponse struct {
 	Offer string `json:"offer"`
 }
 
+type TakebackResponse struct {
+	Offer string `json:"offer"`
+}
+
 type AcceptDrawResponse struct {
 	Result  string `json:"result"`
 	Winner  string `json:"winner"`
@@ -100,8 +195,66 @@ type AcceptDrawResponse struct {
 	Flags   Flags  `json:"flags"`
 }
 
+type ClaimDrawResponse struct {
+	Result  string `json:"result"`
+	Winner  string `json:"winner"`
+	EndedBy string `json:"endedBy"`
+	Reason  string `json:"reason"`
+	Flags   Flags  `json:"flags"`
+}
+
+// AnalysisRequest is the body of POST /analysis and the query of
+// GET /games/:id/analysis, which ignores FEN.
+type AnalysisRequest struct {
+	FEN        string `json:"fen" form:"-"`
+	Depth      int    `json:"depth" form:"depth"`
+	MultiPV    int    `json:"multiPv" form:"multiPv"`
+	MoveTimeMs int    `json:"moveTimeMs" form:"moveTimeMs"`
+}
+
+// ScoreResponse holds either centipawns or moves to mate, from white's point
+// of view; a negative mate means black mates.
+type ScoreResponse struct {
+	Cp   *int `json:"cp,omitempty"`
+	Mate *int `json:"mate,omitempty"`
+}
+
+type AnalysisLine struct {
+	Score ScoreResponse `json:"score"`
+	PV    []string      `json:"pv"`
+	PVSAN []string      `json:"pvSan"`
+}
+
+type AnalysisResponse struct {
+	FEN         string         `json:"fen"`
+	Turn        string         `json:"turn"`
+	Depth       int            `json:"depth"`
+	Nodes       uint64         `json:"nodes"`
+	TimeMs      int64          `json:"timeMs"`
+	BestMove    string         `json:"bestMove"`
+	BestMoveSAN string         `json:"bestMoveSan"`
+	Score       ScoreResponse  `json:"score"`
+	PV          []string       `json:"pv"`
+	PVSAN       []string       `json:"pvSan"`
+	Lines       []AnalysisLine `json:"lines"`
+}
+
+type BookMoveResponse struct {
+	Move   string `json:"move"`
+	SAN    string `json:"san"`
+	Weight int    `json:"weight"`
+	// Probability is how likely a bot following the book plays the move
+	Probability float64 `json:"probability"`
+}
+
+type BookResponse struct {
+	FEN   string             `json:"fen"`
+	Moves []BookMoveResponse `json:"moves"`
+}
+
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error     string `json:"error"`
+	Retryable bool   `json:"retryable,omitempty"`
 }
 
 type PlayerGameResponse struct {
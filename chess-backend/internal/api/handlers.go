@@ -1,13 +1,19 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	"chess-backend/internal/book"
 	"chess-backend/internal/chess"
+	"chess-backend/internal/clock"
+	"chess-backend/internal/eco"
+	"chess-backend/internal/engine"
 	"chess-backend/internal/store"
 
 	"github.com/gin-gonic/gin"
@@ -15,17 +21,33 @@ import (
 )
 
 type Handlers struct {
-	store store.GameStore
-	hub   *StreamHub
+	store    store.GameStore
+	hub      *StreamHub
+	searcher engine.Searcher // used for analysis and bot moves
+	book     *book.Book      // opening book for bots and GET /book; nil is empty
+	bots     sync.WaitGroup  // bot moves being computed in the background
 }
 
 func NewHandlers(store store.GameStore) *Handlers {
 	return &Handlers{
-		store: store,
-		hub:   NewStreamHub(),
+		store:    store,
+		hub:      NewStreamHub(),
+		searcher: engine.NewPool(engine.DefaultHashMB),
 	}
 }
 
+// UseSearcher replaces the built-in engine for analysis and bots, e.g. with
+// an external UCI engine.
+func (h *Handlers) UseSearcher(s engine.Searcher) {
+	h.searcher = s
+}
+
+// UseBook gives bots an opening book to play from and serves it on
+// GET /games/:id/book.
+func (h *Handlers) UseBook(b *book.Book) {
+	h.book = b
+}
+
 func (h *Handlers) CreateGame(c *gin.Context) {
 	var req CreateGameRequest
 	if err := c.ShouldBindJSON(&req); err != nil && !isEmptyBody(err) {
@@ -33,63 +55,52 @@ func (h *Handlers) CreateGame(c *gin.Context) {
 		return
 	}
 
-	var board *chess.Board
-	var err error
-	if strings.TrimSpace(req.Fen) == "" {
-		board = chess.NewBoard()
-	} else {
-		board, err = chess.LoadFEN(req.Fen)
-		if err != nil {
-			writeError(c, http.StatusBadRequest, err.Error())
-			return
-		}
+	board, err := startBoard(req)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	var creatorColor chess.Color
-	if strings.TrimSpace(req.PreferredColor) == "" {
-		creatorColor = chess.White
-	} else {
-		creatorColor, err = parseColor(req.PreferredColor)
+	creatorColor, err := parsePreferredColor(req.PreferredColor)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var timeControl *clock.TimeControl
+	if req.TimeControl != nil {
+		tc, err := parseTimeControl(req.TimeControl)
 		if err != nil {
 			writeError(c, http.StatusBadRequest, err.Error())
 			return
 		}
+		timeControl = &tc
 	}
 
-	id, err := store.NewGameID()
+	botConfig, err := parseOpponent(req.Opponent, req.BotLevel, creatorColor.Opposite())
 	if err != nil {
-		writeError(c, http.StatusInternalServerError, "failed to create game id")
+		writeError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	now := time.Now().UTC()
-	playerToken := newPlayerToken()
-	game := &store.Game{
-		ID:        id,
-		Board:     board,
-		StartFEN:  board.ToFEN(),
-		Moves:     []string{},
-		CreatedAt: now,
-		UpdatedAt: now,
+	game, playerToken, err := newGame(board, creatorColor)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "failed to create game id")
+		return
 	}
-	if creatorColor == chess.White {
-		game.PlayerWhiteToken = playerToken
-		game.PlayerWhiteJoinedAt = &now
-	} else {
-		game.PlayerBlackToken = playerToken
-		game.PlayerBlackJoinedAt = &now
+	if timeControl != nil {
+		game.Clock = clock.New(*timeControl)
+	}
+	if botConfig != nil {
+		seatBot(game, botConfig)
 	}
-
-	status := computeStatus(game)
-	game.Result = status.Result
-	game.Winner = status.Winner
-	game.EndedBy = status.EndedBy
 
 	if err := h.store.CreateGame(c.Request.Context(), game); err != nil {
 		writeError(c, http.StatusInternalServerError, "failed to store game: "+err.Error())
 		return
 	}
 	response := buildGameResponseForToken(game, playerToken)
+	h.startBotMove(game)
 
 	c.JSON(http.StatusOK, PlayerGameResponse{
 		GameResponse:  response,
@@ -212,39 +223,123 @@ func (h *Handlers) MakeMove(c *gin.Context) {
 	if !ok {
 		return
 	}
+
+	clientMoveID := strings.TrimSpace(req.ClientMoveID)
+	if clientMoveID != "" && h.replayClientMove(c, id, clientMoveID, color) {
+		return
+	}
+	if req.ExpectedPly != nil && *req.ExpectedPly != game.Ply {
+		writeError(c, http.StatusConflict, fmt.Sprintf("stale position: expected ply %d but game is at ply %d", *req.ExpectedPly, game.Ply))
+		return
+	}
+	if fen := strings.TrimSpace(req.ExpectedFEN); fen != "" && fen != game.Board.ToFEN() {
+		writeError(c, http.StatusConflict, "stale position: expectedFen does not match the current position")
+		return
+	}
+
 	if game.Board.Turn() != color {
 		writeError(c, http.StatusConflict, "not your turn")
 		return
 	}
 
+	now := time.Now().UTC()
+	if err := h.expireClock(c.Request.Context(), game, now); err != nil {
+		handleStoreError(c, err)
+		return
+	}
+
 	status := computeStatus(game)
 	if status.Result != resultOngoing {
 		writeError(c, http.StatusConflict, "game already finished")
 		return
 	}
 
-	if err := game.Board.MakeMove(move); err != nil {
+	record, err := playMove(game, move, color, now)
+	if err != nil {
 		writeError(c, http.StatusUnprocessableEntity, err.Error())
 		return
 	}
 
-	moveUCI := uciFromMove(move)
+	// built before saving so it can be stored for replays; the store bumps Ply on success
+	response := buildMoveResponseForToken(game, token)
+	response.Ply = game.Ply + 1
+	if clientMoveID != "" {
+		encoded, err := json.Marshal(response)
+		if err != nil {
+			writeError(c, http.StatusInternalServerError, "failed to encode response")
+			return
+		}
+		record.ClientMove = &store.ClientMove{ID: clientMoveID, Color: color, Response: encoded}
+	}
+
+	if err := h.store.UpdateGameWithMove(c.Request.Context(), game, record); err != nil {
+		// a concurrent retry of the same submission may have won the race
+		if errors.Is(err, store.ErrConflict) && clientMoveID != "" && h.replayClientMove(c, id, clientMoveID, color) {
+			return
+		}
+		handleStoreError(c, err)
+		return
+	}
+	h.broadcastGame(game)
+	h.startBotMove(game)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// playMove plays move for color and updates everything a move affects:
+// pending offers, the clock and the result.
+func playMove(game *store.Game, move chess.Move, color chess.Color, now time.Time) (store.MoveRecord, error) {
+	san, err := game.Board.SAN(move)
+	if err != nil {
+		return store.MoveRecord{}, err
+	}
+	if err := game.Board.MakeMove(move); err != nil {
+		return store.MoveRecord{}, err
+	}
+	updateOpening(game)
+
 	game.PendingDrawOfferBy = nil
-	game.UpdatedAt = time.Now().UTC()
+	game.PendingTakebackBy = nil
+	game.UpdatedAt = now
+	if game.Clock != nil {
+		game.Clock.Punch(color, now)
+	}
 
-	status = computeStatus(game)
+	status := computeStatus(game)
 	game.Result = status.Result
 	game.Winner = status.Winner
 	game.EndedBy = status.EndedBy
+	if status.Result != resultOngoing {
+		stopClock(game, now)
+	}
+	return store.MoveRecord{UCI: uciFromMove(move), SAN: san}, nil
+}
 
-	if err := h.store.UpdateGameWithMove(c.Request.Context(), game, moveUCI); err != nil {
-		handleStoreError(c, err)
-		return
+// updateOpening records the position just reached as the game's opening when
+// it is a known one that eco.Replaces the opening already recorded.
+func updateOpening(game *store.Game) {
+	if o, ok := eco.Lookup(game.Board); ok && eco.Replaces(o, game.Opening) {
+		game.Opening = &o
 	}
-	response := buildMoveResponseForToken(game, token)
-	h.broadcastGame(game)
+}
 
-	c.JSON(http.StatusOK, response)
+// replayClientMove answers a resubmitted move with the response recorded for
+// the original submission. It reports whether a response was written.
+func (h *Handlers) replayClientMove(c *gin.Context, gameID, clientMoveID string, color chess.Color) bool {
+	cm, err := h.store.GetClientMove(c.Request.Context(), gameID, clientMoveID)
+	if err != nil {
+		handleStoreError(c, err)
+		return true
+	}
+	if cm == nil {
+		return false
+	}
+	if cm.Color != color {
+		writeError(c, http.StatusConflict, "clientMoveId already used by the opponent")
+		return true
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", cm.Response)
+	return true
 }
 
 func (h *Handlers) Status(c *gin.Context) {
@@ -300,6 +395,12 @@ func (h *Handlers) Resign(c *gin.Context) {
 		return
 	}
 
+	now := time.Now().UTC()
+	if err := h.expireClock(c.Request.Context(), game, now); err != nil {
+		handleStoreError(c, err)
+		return
+	}
+
 	status := computeStatus(game)
 	if status.Result != resultOngoing {
 		writeError(c, http.StatusConflict, "game already finished")
@@ -310,7 +411,8 @@ func (h *Handlers) Resign(c *gin.Context) {
 	game.EndedBy = endedByResignation
 	game.Winner = color.Opposite().String()
 	game.PendingDrawOfferBy = nil
-	game.UpdatedAt = time.Now().UTC()
+	game.UpdatedAt = now
+	stopClock(game, now)
 
 	status = computeStatus(game)
 	if err := h.store.UpdateGame(c.Request.Context(), game); err != nil {
@@ -351,6 +453,12 @@ func (h *Handlers) OfferDraw(c *gin.Context) {
 		return
 	}
 
+	now := time.Now().UTC()
+	if err := h.expireClock(c.Request.Context(), game, now); err != nil {
+		handleStoreError(c, err)
+		return
+	}
+
 	status := computeStatus(game)
 	if status.Result != resultOngoing {
 		writeError(c, http.StatusConflict, "game already finished")
@@ -361,7 +469,7 @@ func (h *Handlers) OfferDraw(c *gin.Context) {
 	game.Winner = status.Winner
 	game.EndedBy = status.EndedBy
 	game.PendingDrawOfferBy = &color
-	game.UpdatedAt = time.Now().UTC()
+	game.UpdatedAt = now
 	if err := h.store.UpdateGame(c.Request.Context(), game); err != nil {
 		handleStoreError(c, err)
 		return
@@ -395,6 +503,12 @@ func (h *Handlers) AcceptDraw(c *gin.Context) {
 		return
 	}
 
+	now := time.Now().UTC()
+	if err := h.expireClock(c.Request.Context(), game, now); err != nil {
+		handleStoreError(c, err)
+		return
+	}
+
 	status := computeStatus(game)
 	if status.Result != resultOngoing {
 		writeError(c, http.StatusConflict, "game already finished")
@@ -414,7 +528,8 @@ func (h *Handlers) AcceptDraw(c *gin.Context) {
 	game.EndedBy = endedByDrawAgreement
 	game.Winner = "none"
 	game.PendingDrawOfferBy = nil
-	game.UpdatedAt = time.Now().UTC()
+	game.UpdatedAt = now
+	stopClock(game, now)
 
 	status = computeStatus(game)
 	if err := h.store.UpdateGame(c.Request.Context(), game); err != nil {
@@ -431,6 +546,146 @@ func (h *Handlers) AcceptDraw(c *gin.Context) {
 	})
 }
 
+func (h *Handlers) ClaimDraw(c *gin.Context) {
+	id := c.Param("id")
+	token := playerTokenFromRequest(c)
+
+	var req ClaimDrawRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !isEmptyBody(err) {
+		writeError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	game, err := h.store.GetGame(c.Request.Context(), id)
+	if err != nil {
+		handleStoreError(c, err)
+		return
+	}
+	color, ok := requirePlayerToken(c, game, token)
+	if !ok {
+		return
+	}
+	if game.Board.Turn() != color {
+		writeError(c, http.StatusConflict, "not your turn")
+		return
+	}
+
+	now := time.Now().UTC()
+	if err := h.expireClock(c.Request.Context(), game, now); err != nil {
+		handleStoreError(c, err)
+		return
+	}
+
+	status := computeStatus(game)
+	if status.Result != resultOngoing {
+		writeError(c, http.StatusConflict, "game already finished")
+		return
+	}
+
+	// without a move the claim is against the current position; with one,
+	// the claimant announces the move and the claim is checked after it. The
+	// game is our own copy, so a rejected claim leaves nothing to undo.
+	var record *store.MoveRecord
+	if uci := strings.TrimSpace(req.UCI); uci != "" {
+		move, err := parseUCI(uci)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		played, err := playMove(game, move, color, now)
+		if err != nil {
+			writeError(c, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		record = &played
+	}
+
+	reason, ok := drawClaimReason(game.Board)
+	switch {
+	case !ok && record != nil:
+		writeError(c, http.StatusConflict, "move does not lead to a claimable draw")
+		return
+	case !ok:
+		writeError(c, http.StatusConflict, "no draw claim available")
+		return
+	}
+
+	// an announced move that itself ends the game (e.g. mate) takes precedence over the claim
+	status = computeStatus(game)
+	if status.Result == resultOngoing {
+		game.Result = resultDraw
+		game.EndedBy = endedByDrawClaim
+		game.Winner = "none"
+	} else {
+		game.Result = status.Result
+		game.Winner = status.Winner
+		game.EndedBy = status.EndedBy
+	}
+	game.PendingDrawOfferBy = nil
+	game.UpdatedAt = now
+	stopClock(game, now)
+
+	if record != nil {
+		err = h.store.UpdateGameWithMove(c.Request.Context(), game, *record)
+	} else {
+		err = h.store.UpdateGame(c.Request.Context(), game)
+	}
+	if err != nil {
+		handleStoreError(c, err)
+		return
+	}
+	h.broadcastGame(game)
+
+	status = computeStatus(game)
+	c.JSON(http.StatusOK, ClaimDrawResponse{
+		Result:  status.Result,
+		Winner:  status.Winner,
+		EndedBy: status.EndedBy,
+		Reason:  reason,
+		Flags:   status.Flags,
+	})
+}
+
+func newGame(board *chess.Board, creatorColor chess.Color) (*store.Game, string, error) {
+	id, err := store.NewGameID()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now().UTC()
+	playerToken := newPlayerToken()
+	game := &store.Game{
+		ID:        id,
+		Board:     board,
+		StartFEN:  board.ToFEN(),
+		Variant:   gameVariant(board),
+		Moves:     []string{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if creatorColor == chess.White {
+		game.PlayerWhiteToken = playerToken
+		game.PlayerWhiteJoinedAt = &now
+	} else {
+		game.PlayerBlackToken = playerToken
+		game.PlayerBlackJoinedAt = &now
+	}
+
+	status := computeStatus(game)
+	game.Result = status.Result
+	game.Winner = status.Winner
+	game.EndedBy = status.EndedBy
+
+	return game, playerToken, nil
+}
+
+func parsePreferredColor(value string) (chess.Color, error) {
+	if strings.TrimSpace(value) == "" {
+		return chess.White, nil
+	}
+	return parseColor(value)
+}
+
 func parseColor(value string) (chess.Color, error) {
 	switch strings.ToLower(strings.TrimSpace(value)) {
 	case "white":
@@ -444,7 +699,7 @@ func parseColor(value string) (chess.Color, error) {
 
 func buildGameResponse(game *store.Game) GameResponse {
 	status := computeStatus(game)
-	return GameResponse{
+	response := GameResponse{
 		ID:       game.ID,
 		FEN:      game.Board.ToFEN(),
 		Turn:     game.Board.Turn().String(),
@@ -454,12 +709,41 @@ func buildGameResponse(game *store.Game) GameResponse {
 		Flags:    status.Flags,
 		Halfmove: game.Board.HalfMove(),
 		Fullmove: game.Board.FullMove(),
+		Ply:      game.Ply,
+		Variant:  game.Variant,
+		Clock:    buildClockResponse(game, status, time.Now().UTC()),
 		Meta: Meta{
 			CreatedAt: game.CreatedAt,
 			UpdatedAt: game.UpdatedAt,
 			StartFEN:  game.StartFEN,
 		},
 	}
+	if game.PendingTakebackBy != nil {
+		response.TakebackRequestedBy = game.PendingTakebackBy.String()
+	}
+	if game.Bot != nil {
+		response.Bot = &BotResponse{Color: game.Bot.Color.String(), Level: game.Bot.Level}
+	}
+	if o := game.Opening; o != nil {
+		response.Opening = &OpeningResponse{ECO: o.ECO, Name: o.Name, Variation: o.Variation}
+	}
+	if game.Board.HasPockets() {
+		response.Pockets = &PocketsResponse{
+			White: buildPocketResponse(game.Board.Pocket(chess.White)),
+			Black: buildPocketResponse(game.Board.Pocket(chess.Black)),
+		}
+	}
+	return response
+}
+
+func buildPocketResponse(p chess.Pocket) PocketResponse {
+	return PocketResponse{
+		Pawn:   p[chess.Pawn],
+		Knight: p[chess.Knight],
+		Bishop: p[chess.Bishop],
+		Rook:   p[chess.Rook],
+		Queen:  p[chess.Queen],
+	}
 }
 
 func buildMoveResponse(game *store.Game) MoveResponse {
@@ -473,6 +757,8 @@ func buildMoveResponse(game *store.Game) MoveResponse {
 		Flags:    status.Flags,
 		Halfmove: game.Board.HalfMove(),
 		Fullmove: game.Board.FullMove(),
+		Ply:      game.Ply,
+		Clock:    buildClockResponse(game, status, time.Now().UTC()),
 	}
 }
 
@@ -541,6 +827,13 @@ func handleStoreError(c *gin.Context, err error) {
 		writeError(c, http.StatusNotFound, "game not found")
 		return
 	}
+	if errors.Is(err, store.ErrConflict) {
+		c.JSON(http.StatusConflict, ErrorResponse{
+			Error:     "game was updated by another request; reload it and retry",
+			Retryable: true,
+		})
+		return
+	}
 	writeError(c, http.StatusInternalServerError, "storage error")
 }
 
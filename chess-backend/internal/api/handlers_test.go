@@ -2,12 +2,22 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"chess-backend/internal/book"
+	"chess-backend/internal/chess"
+	"chess-backend/internal/clock"
 	"chess-backend/internal/store"
+	"chess-backend/internal/uci"
 
 	"github.com/gin-gonic/gin"
 )
@@ -161,3 +171,1204 @@ func TestMoveAuthorization(t *testing.T) {
 		t.Fatalf("expected 409 for wrong turn, got %d", rec.Code)
 	}
 }
+
+func TestExportPGN(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games", handlers.CreateGame)
+	v1.POST("/games/:id/moves", handlers.MakeMove)
+	v1.GET("/games/:id/pgn", handlers.ExportPGN)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/games", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var created PlayerGameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/games/"+created.ID+"/moves", bytes.NewBufferString(`{"uci":"g1f3"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Player-Token", created.PlayerToken)
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for move, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/games/"+created.ID+"/pgn", nil)
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != pgnContentType {
+		t.Fatalf("expected %s content type, got %q", pgnContentType, ct)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `[Result "*"]`) || !strings.Contains(body, "1. Nf3 *") {
+		t.Fatalf("unexpected pgn body:\n%s", body)
+	}
+}
+
+func TestImportGameFromPGN(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games/import", handlers.ImportGame)
+	v1.GET("/games/:id/history", handlers.History)
+
+	pgn := `[Event "Adjourned"]
+
+1. e4 {main} e5 (1... c5 2. Nf3) 2. Nf3 Nc6 $2 *`
+	body, _ := json.Marshal(ImportGameRequest{PGN: pgn, PreferredColor: "black"})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/games/import", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var imported PlayerGameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &imported); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if imported.FEN != "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3" {
+		t.Fatalf("unexpected FEN after import: %s", imported.FEN)
+	}
+	if imported.PlayerColor != "black" || imported.PlayerToken == "" {
+		t.Fatalf("expected black player token, got color %q", imported.PlayerColor)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/games/"+imported.ID+"/history", nil)
+	router.ServeHTTP(rec, req)
+
+	var history HistoryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil {
+		t.Fatalf("failed to parse history: %v", err)
+	}
+	if strings.Join(history.Moves, " ") != "e2e4 e7e5 g1f3 b8c6" {
+		t.Fatalf("unexpected history %v", history.Moves)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/games/import", bytes.NewBufferString("1. e4 e5 2. Ke3 *"))
+	req.Header.Set("Content-Type", pgnContentType)
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for illegal pgn move, got %d", rec.Code)
+	}
+}
+
+func TestRepetitionFlagsAndFivefoldEndsGame(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games", handlers.CreateGame)
+	v1.POST("/games/:id/join", handlers.JoinGame)
+	v1.POST("/games/:id/moves", handlers.MakeMove)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/games", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(rec, req)
+
+	var created PlayerGameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/games/"+created.ID+"/join", bytes.NewBufferString(`{}`))
+	router.ServeHTTP(rec, req)
+
+	var joined PlayerGameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &joined); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	tokens := []string{created.PlayerToken, joined.PlayerToken}
+	shuffle := []string{"g1f3", "g8f6", "f3g1", "f6g8"}
+
+	var last MoveResponse
+	for i := 0; i < 16; i++ {
+		body := []byte(`{"uci":"` + shuffle[i%4] + `"}`)
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodPost, "/api/v1/games/"+created.ID+"/moves", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Player-Token", tokens[i%2])
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("move %d: expected 200, got %d", i+1, rec.Code)
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &last); err != nil {
+			t.Fatalf("failed to parse move response: %v", err)
+		}
+
+		if i == 7 {
+			if !last.Flags.DrawClaimable || last.Flags.DrawReason != endedByThreefoldRepetition {
+				t.Fatalf("expected threefold repetition to be claimable, got %+v", last.Flags)
+			}
+			if last.Result != resultOngoing {
+				t.Fatalf("threefold must not end the game automatically, got %q", last.Result)
+			}
+		}
+	}
+
+	if last.Result != resultDraw || last.EndedBy != endedByFivefoldRepetition {
+		t.Fatalf("expected fivefold repetition draw, got result=%q endedBy=%q", last.Result, last.EndedBy)
+	}
+}
+
+func TestClaimDraw(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games", handlers.CreateGame)
+	v1.POST("/games/:id/join", handlers.JoinGame)
+	v1.POST("/games/:id/moves", handlers.MakeMove)
+	v1.POST("/games/:id/claim-draw", handlers.ClaimDraw)
+	v1.GET("/games/:id/history", handlers.History)
+	v1.GET("/games/:id", handlers.GetGame)
+	v1.POST("/games/:id/takeback", handlers.OfferTakeback)
+
+	// fifty-move rule against the current position
+	fifty := createTestGame(t, router, `{"fen":"4k3/8/8/8/8/8/8/R3K3 w - - 100 80"}`)
+	rec := performJSON(router, http.MethodPost, "/api/v1/games/"+fifty.ID+"/claim-draw", ``, fifty.PlayerToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for fifty-move claim, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var claimed ClaimDrawResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &claimed); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if claimed.Result != resultDraw || claimed.EndedBy != endedByDrawClaim || claimed.Reason != endedByFiftyMove {
+		t.Fatalf("unexpected claim response %+v", claimed)
+	}
+
+	// nothing to claim in the starting position
+	game := createTestGame(t, router, `{}`)
+	rec = performJSON(router, http.MethodPost, "/api/v1/games/"+game.ID+"/join", `{}`, "")
+	var joined PlayerGameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &joined); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	rec = performJSON(router, http.MethodPost, "/api/v1/games/"+game.ID+"/claim-draw", `{}`, game.PlayerToken)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 without a claimable draw, got %d", rec.Code)
+	}
+
+	// threefold repetition claimed together with the move that produces it
+	tokens := []string{game.PlayerToken, joined.PlayerToken}
+	shuffle := []string{"g1f3", "g8f6", "f3g1", "f6g8"}
+	for i := 0; i < 7; i++ {
+		rec = performJSON(router, http.MethodPost, "/api/v1/games/"+game.ID+"/moves", `{"uci":"`+shuffle[i%4]+`"}`, tokens[i%2])
+		if rec.Code != http.StatusOK {
+			t.Fatalf("move %d: expected 200, got %d", i+1, rec.Code)
+		}
+	}
+
+	rec = performJSON(router, http.MethodPost, "/api/v1/games/"+game.ID+"/claim-draw", `{"uci":"f6g8"}`, game.PlayerToken)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 when claiming out of turn, got %d", rec.Code)
+	}
+	rec = performJSON(router, http.MethodPost, "/api/v1/games/"+game.ID+"/claim-draw", `{"uci":"b8c6"}`, joined.PlayerToken)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a move that does not repeat, got %d", rec.Code)
+	}
+	// the claimed move answers a pending takeback request like any other move
+	rec = performJSON(router, http.MethodPost, "/api/v1/games/"+game.ID+"/takeback", ``, game.PlayerToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the takeback request to be accepted, got %d", rec.Code)
+	}
+	rec = performJSON(router, http.MethodPost, "/api/v1/games/"+game.ID+"/claim-draw", `{"uci":"f6g8"}`, joined.PlayerToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for threefold claim, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := getTestGame(t, router, game.ID, game.PlayerToken); got.TakebackRequestedBy != "" {
+		t.Fatalf("expected the claimed move to clear the takeback request, got %q", got.TakebackRequestedBy)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &claimed); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if claimed.EndedBy != endedByDrawClaim || claimed.Reason != endedByThreefoldRepetition {
+		t.Fatalf("unexpected claim response %+v", claimed)
+	}
+
+	rec = performJSON(router, http.MethodGet, "/api/v1/games/"+game.ID+"/history", ``, "")
+	var history HistoryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil {
+		t.Fatalf("failed to parse history: %v", err)
+	}
+	if len(history.Moves) != 8 || history.Moves[7] != "f6g8" {
+		t.Fatalf("expected claimed move to be recorded, got %v", history.Moves)
+	}
+}
+
+func createTestGame(t *testing.T, router *gin.Engine, body string) PlayerGameResponse {
+	t.Helper()
+	rec := performJSON(router, http.MethodPost, "/api/v1/games", body, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 creating game, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created PlayerGameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	return created
+}
+
+func performJSON(router *gin.Engine, method, path, body, token string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("X-Player-Token", token)
+	}
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAutomaticDrawsReportEndedBy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games", handlers.CreateGame)
+
+	cases := []struct {
+		fen     string
+		endedBy string
+	}{
+		{"4k3/8/8/8/8/8/8/R3K3 w - - 150 100", endedBySeventyFiveMove},
+		{"8/8/3k4/1p1p1p1p/1P1P1P1P/3K4/8/8 w - - 0 1", endedByDeadPosition},
+	}
+	for _, tc := range cases {
+		game := createTestGame(t, router, `{"fen":"`+tc.fen+`"}`)
+		if game.Result != resultDraw || game.EndedBy != tc.endedBy || game.Flags.DrawReason != tc.endedBy {
+			t.Fatalf("fen %q: expected draw by %s, got result=%q endedBy=%q", tc.fen, tc.endedBy, game.Result, game.EndedBy)
+		}
+	}
+
+	game := createTestGame(t, router, `{"fen":"4k3/8/8/8/8/8/8/1N2K1N1 w - - 0 1"}`)
+	if game.Result != resultOngoing || !game.Flags.Unwinnable {
+		t.Fatalf("expected ongoing game flagged unwinnable, got result=%q flags=%+v", game.Result, game.Flags)
+	}
+}
+
+func TestTimedGameClockAndTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games", handlers.CreateGame)
+	v1.POST("/games/:id/join", handlers.JoinGame)
+	v1.POST("/games/:id/moves", handlers.MakeMove)
+
+	rec := performJSON(router, http.MethodPost, "/api/v1/games", `{"timeControl":{"baseSeconds":0}}`, "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid time control, got %d", rec.Code)
+	}
+
+	game := createTestGame(t, router, `{"timeControl":{"baseSeconds":60,"incrementSeconds":2}}`)
+	if game.Clock == nil || game.Clock.WhiteMs != 60000 || game.Clock.Running != "" || game.Clock.TimeControl != "60+2" {
+		t.Fatalf("unexpected initial clock %+v", game.Clock)
+	}
+	rec = performJSON(router, http.MethodPost, "/api/v1/games/"+game.ID+"/join", `{}`, "")
+	var joined PlayerGameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &joined); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	rec = performJSON(router, http.MethodPost, "/api/v1/games/"+game.ID+"/moves", `{"uci":"e2e4"}`, game.PlayerToken)
+	var moved MoveResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &moved); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if moved.Clock == nil || moved.Clock.WhiteMs != 62000 || moved.Clock.Running != "black" {
+		t.Fatalf("expected increment and black's clock running, got %+v", moved.Clock)
+	}
+
+	// black lets the clock run out
+	stored, err := memStore.GetGame(context.Background(), game.ID)
+	if err != nil {
+		t.Fatalf("get game: %v", err)
+	}
+	past := time.Now().UTC().Add(-2 * time.Minute)
+	stored.Clock.LastPunch = &past
+	if err := memStore.UpdateGame(context.Background(), stored); err != nil {
+		t.Fatalf("update game: %v", err)
+	}
+
+	handlers.checkFlags(context.Background(), time.Now().UTC())
+	stored, err = memStore.GetGame(context.Background(), game.ID)
+	if err != nil {
+		t.Fatalf("get game: %v", err)
+	}
+	if stored.Result != resultTimeout || stored.Winner != "white" || stored.EndedBy != endedByTimeout {
+		t.Fatalf("expected white to win on time, got result=%q winner=%q", stored.Result, stored.Winner)
+	}
+	if stored.Clock.Running() || stored.Clock.Remaining[1] != 0 {
+		t.Fatalf("expected stopped clock with black at zero, got %+v", stored.Clock)
+	}
+
+	rec = performJSON(router, http.MethodPost, "/api/v1/games/"+game.ID+"/moves", `{"uci":"e7e5"}`, joined.PlayerToken)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 after flag fall, got %d", rec.Code)
+	}
+}
+
+func TestTimeoutAgainstLoneKingIsDraw(t *testing.T) {
+	tc, err := parseTimeControl(&TimeControlRequest{BaseSeconds: 10})
+	if err != nil {
+		t.Fatalf("parse time control: %v", err)
+	}
+	for fen, want := range map[string]string{
+		"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1":   resultDraw,    // lone king
+		"4k3/8/8/8/8/2n5/4P3/4K3 w - - 0 1": resultDraw,    // king and knight
+		"4k3/8/8/8/8/2r5/4P3/4K3 w - - 0 1": resultTimeout, // a rook can mate
+	} {
+		game := &store.Game{Board: mustLoadFEN(t, fen)}
+		game.Clock = clock.New(tc)
+		start := time.Now().UTC()
+		game.Clock.Punch(chess.Black, start)
+
+		if !applyTimeout(game, start.Add(11*time.Second)) {
+			t.Fatalf("%s: expected white to flag", fen)
+		}
+		if game.Result != want || game.EndedBy != endedByTimeout {
+			t.Fatalf("%s: expected %s on time, got result=%q endedBy=%q", fen, want, game.Result, game.EndedBy)
+		}
+	}
+}
+
+func mustLoadFEN(t *testing.T, fen string) *chess.Board {
+	t.Helper()
+	board, err := chess.LoadFEN(fen)
+	if err != nil {
+		t.Fatalf("load fen: %v", err)
+	}
+	return board
+}
+
+func TestTakebackFlow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games", handlers.CreateGame)
+	v1.POST("/games/:id/join", handlers.JoinGame)
+	v1.POST("/games/:id/moves", handlers.MakeMove)
+	v1.GET("/games/:id", handlers.GetGame)
+	v1.GET("/games/:id/history", handlers.History)
+	v1.POST("/games/:id/takeback", handlers.OfferTakeback)
+	v1.POST("/games/:id/takeback/accept", handlers.AcceptTakeback)
+	v1.POST("/games/:id/takeback/decline", handlers.DeclineTakeback)
+
+	game := createTestGame(t, router, `{}`)
+	rec := performJSON(router, http.MethodPost, "/api/v1/games/"+game.ID+"/join", `{}`, "")
+	var joined PlayerGameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &joined); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	white, black := game.PlayerToken, joined.PlayerToken
+	base := "/api/v1/games/" + game.ID
+
+	rec = performJSON(router, http.MethodPost, base+"/takeback", ``, white)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 before any move, got %d", rec.Code)
+	}
+
+	for i, uci := range []string{"e2e4", "e7e5", "g1f3"} {
+		token := white
+		if i%2 == 1 {
+			token = black
+		}
+		rec = performJSON(router, http.MethodPost, base+"/moves", `{"uci":"`+uci+`"}`, token)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("move %s: expected 200, got %d", uci, rec.Code)
+		}
+	}
+
+	// white asks to take back g1f3 and black declines
+	rec = performJSON(router, http.MethodPost, base+"/takeback", ``, white)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for takeback request, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = performJSON(router, http.MethodGet, base, ``, black)
+	var state GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if state.TakebackRequestedBy != "white" {
+		t.Fatalf("expected pending takeback by white, got %q", state.TakebackRequestedBy)
+	}
+	rec = performJSON(router, http.MethodPost, base+"/takeback/accept", ``, white)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 when accepting own request, got %d", rec.Code)
+	}
+	rec = performJSON(router, http.MethodPost, base+"/takeback/decline", ``, black)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for decline, got %d", rec.Code)
+	}
+	rec = performJSON(router, http.MethodPost, base+"/takeback/accept", ``, black)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 without a pending request, got %d", rec.Code)
+	}
+
+	// black, to move, asks to take back e7e5, which also undoes white's reply
+	rec = performJSON(router, http.MethodPost, base+"/takeback", ``, black)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for takeback request, got %d", rec.Code)
+	}
+	rec = performJSON(router, http.MethodPost, base+"/takeback/accept", ``, white)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for accept, got %d: %s", rec.Code, rec.Body.String())
+	}
+	state = GameResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &state); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if state.FEN != "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1" || state.TakebackRequestedBy != "" {
+		t.Fatalf("unexpected position after takeback: %+v", state)
+	}
+
+	rec = performJSON(router, http.MethodGet, base+"/history", ``, "")
+	var history HistoryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil {
+		t.Fatalf("failed to parse history: %v", err)
+	}
+	if len(history.Moves) != 1 || history.Moves[0] != "e2e4" {
+		t.Fatalf("expected history to be truncated, got %v", history.Moves)
+	}
+
+	rec = performJSON(router, http.MethodPost, base+"/moves", `{"uci":"c7c5"}`, black)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected black to move again after takeback, got %d", rec.Code)
+	}
+}
+
+func TestConcurrentJoinsClaimOneSeat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games", handlers.CreateGame)
+	v1.POST("/games/:id/join", handlers.JoinGame)
+
+	game := createTestGame(t, router, `{}`)
+
+	const joiners = 16
+	codes := make(chan int, joiners)
+	var wg sync.WaitGroup
+	for range joiners {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- performJSON(router, http.MethodPost, "/api/v1/games/"+game.ID+"/join", `{}`, "").Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	joined := 0
+	for code := range codes {
+		switch code {
+		case http.StatusOK:
+			joined++
+		case http.StatusConflict:
+		default:
+			t.Fatalf("unexpected status %d", code)
+		}
+	}
+	if joined != 1 {
+		t.Fatalf("expected exactly one join to succeed, got %d", joined)
+	}
+}
+
+func TestStaleUpdateReturnsRetryableConflict(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games", handlers.CreateGame)
+
+	game := createTestGame(t, router, `{}`)
+	ctx := context.Background()
+	first, err := memStore.GetGame(ctx, game.ID)
+	if err != nil {
+		t.Fatalf("get game: %v", err)
+	}
+	second, err := memStore.GetGame(ctx, game.ID)
+	if err != nil {
+		t.Fatalf("get game: %v", err)
+	}
+
+	if err := memStore.UpdateGame(ctx, first); err != nil {
+		t.Fatalf("first update: %v", err)
+	}
+	if err := memStore.UpdateGameWithMove(ctx, second, store.MoveRecord{UCI: "e2e4"}); !errors.Is(err, store.ErrConflict) {
+		t.Fatalf("expected ErrConflict for stale update, got %v", err)
+	}
+	if err := memStore.UpdateGame(ctx, first); err != nil {
+		t.Fatalf("expected version to advance with the update, got %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	handleStoreError(c, store.ErrConflict)
+	var body ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if rec.Code != http.StatusConflict || !body.Retryable {
+		t.Fatalf("expected retryable 409, got %d %+v", rec.Code, body)
+	}
+}
+
+func TestIdempotentMoveSubmission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games", handlers.CreateGame)
+	v1.POST("/games/:id/join", handlers.JoinGame)
+	v1.POST("/games/:id/moves", handlers.MakeMove)
+	v1.GET("/games/:id/history", handlers.History)
+	v1.POST("/games/:id/takeback", handlers.OfferTakeback)
+	v1.POST("/games/:id/takeback/accept", handlers.AcceptTakeback)
+
+	game := createTestGame(t, router, `{}`)
+	rec := performJSON(router, http.MethodPost, "/api/v1/games/"+game.ID+"/join", `{}`, "")
+	var joined PlayerGameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &joined); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	movesPath := "/api/v1/games/" + game.ID + "/moves"
+
+	body := `{"uci":"e2e4","clientMoveId":"m-1","expectedPly":0}`
+	first := performJSON(router, http.MethodPost, movesPath, body, game.PlayerToken)
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", first.Code, first.Body.String())
+	}
+	var moved MoveResponse
+	if err := json.Unmarshal(first.Body.Bytes(), &moved); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if moved.Ply != 1 {
+		t.Fatalf("expected ply 1 after the first move, got %d", moved.Ply)
+	}
+
+	retry := performJSON(router, http.MethodPost, movesPath, body, game.PlayerToken)
+	if retry.Code != http.StatusOK || retry.Body.String() != first.Body.String() {
+		t.Fatalf("expected the original response on retry, got %d: %s", retry.Code, retry.Body.String())
+	}
+
+	rec = performJSON(router, http.MethodPost, movesPath, `{"uci":"e7e5","clientMoveId":"m-1"}`, joined.PlayerToken)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a client move id reused by the opponent, got %d", rec.Code)
+	}
+
+	rec = performJSON(router, http.MethodPost, movesPath, `{"uci":"e7e5","expectedPly":0}`, joined.PlayerToken)
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "stale position") {
+		t.Fatalf("expected 409 for a stale ply, got %d: %s", rec.Code, rec.Body.String())
+	}
+	startFEN := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	rec = performJSON(router, http.MethodPost, movesPath, `{"uci":"e7e5","expectedFen":"`+startFEN+`"}`, joined.PlayerToken)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for a stale fen, got %d", rec.Code)
+	}
+	rec = performJSON(router, http.MethodPost, movesPath, `{"uci":"e7e5","expectedPly":1,"expectedFen":"`+moved.FEN+`"}`, joined.PlayerToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 against the current position, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = performJSON(router, http.MethodGet, "/api/v1/games/"+game.ID+"/history", ``, "")
+	var history HistoryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil {
+		t.Fatalf("failed to parse history: %v", err)
+	}
+	if len(history.Moves) != 2 {
+		t.Fatalf("expected the retried move to be recorded once, got %v", history.Moves)
+	}
+
+	// once taken back, the client move id plays the move afresh
+	base := "/api/v1/games/" + game.ID
+	if rec := performJSON(router, http.MethodPost, base+"/takeback", ``, game.PlayerToken); rec.Code != http.StatusOK {
+		t.Fatalf("expected the takeback offer to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := performJSON(router, http.MethodPost, base+"/takeback/accept", ``, joined.PlayerToken); rec.Code != http.StatusOK {
+		t.Fatalf("expected the takeback to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := performJSON(router, http.MethodPost, movesPath, body, game.PlayerToken); rec.Code != http.StatusOK {
+		t.Fatalf("expected the move to be replayed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = performJSON(router, http.MethodGet, base+"/history", ``, "")
+	history = HistoryResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &history); err != nil {
+		t.Fatalf("failed to parse history: %v", err)
+	}
+	if len(history.Moves) != 1 {
+		t.Fatalf("expected the move played again after the takeback, got %v", history.Moves)
+	}
+}
+
+func TestPlayAgainstBot(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games", handlers.CreateGame)
+	v1.POST("/games/:id/join", handlers.JoinGame)
+	v1.POST("/games/:id/moves", handlers.MakeMove)
+	v1.GET("/games/:id", handlers.GetGame)
+
+	for _, body := range []string{`{"opponent":"robot"}`, `{"opponent":"bot","botLevel":99}`} {
+		if rec := performJSON(router, http.MethodPost, "/api/v1/games", body, ""); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", body, rec.Code)
+		}
+	}
+
+	// the bot moves first when the human takes black
+	game := createTestGame(t, router, `{"preferredColor":"black","opponent":"bot","botLevel":1}`)
+	if game.Bot == nil || game.Bot.Color != "white" || game.Bot.Level != 1 {
+		t.Fatalf("expected a level 1 white bot, got %+v", game.Bot)
+	}
+	if rec := performJSON(router, http.MethodPost, "/api/v1/games/"+game.ID+"/join", `{}`, ""); rec.Code != http.StatusConflict {
+		t.Fatalf("expected the bot's seat to be taken, got %d", rec.Code)
+	}
+	handlers.bots.Wait()
+
+	current := getTestGame(t, router, game.ID, game.PlayerToken)
+	if current.Ply != 1 || current.Turn != "black" {
+		t.Fatalf("expected the bot to have opened, got ply %d with %s to move", current.Ply, current.Turn)
+	}
+
+	board := mustLoadFEN(t, current.FEN)
+	reply := board.LegalMoves()[0].UCI()
+	rec := performJSON(router, http.MethodPost, "/api/v1/games/"+game.ID+"/moves", `{"uci":"`+reply+`"}`, game.PlayerToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for the human move, got %d: %s", rec.Code, rec.Body.String())
+	}
+	handlers.bots.Wait()
+
+	current = getTestGame(t, router, game.ID, game.PlayerToken)
+	if current.Ply != 3 || current.Turn != "black" {
+		t.Fatalf("expected the bot to reply, got ply %d with %s to move", current.Ply, current.Turn)
+	}
+}
+
+func getTestGame(t *testing.T, router *gin.Engine, id, token string) GameResponse {
+	t.Helper()
+	rec := performJSON(router, http.MethodGet, "/api/v1/games/"+id, ``, token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 getting game, got %d", rec.Code)
+	}
+	var game GameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &game); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	return game
+}
+
+func TestAnalysis(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games", handlers.CreateGame)
+	v1.GET("/games/:id/analysis", handlers.AnalyzeGame)
+	v1.POST("/analysis", handlers.AnalyzePosition)
+
+	// black mates: scores are reported from white's point of view
+	rec := performJSON(router, http.MethodPost, "/api/v1/analysis", `{"fen":"r5k1/8/8/8/8/8/5PPP/6K1 b - - 0 1","depth":4}`, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var analysis AnalysisResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &analysis); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if analysis.BestMove != "a8a1" || analysis.Score.Mate == nil || *analysis.Score.Mate != -1 {
+		t.Fatalf("expected black to mate with Ra1, got %s with %+v", analysis.BestMove, analysis.Score)
+	}
+
+	rec = performJSON(router, http.MethodPost, "/api/v1/analysis", `{"fen":"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1","depth":4}`, "")
+	analysis = AnalysisResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &analysis); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if analysis.BestMove != "a1a8" || analysis.BestMoveSAN != "Ra8#" {
+		t.Fatalf("expected Ra8#, got %s (%s)", analysis.BestMove, analysis.BestMoveSAN)
+	}
+	if analysis.Score.Mate == nil || *analysis.Score.Mate != 1 || analysis.Depth == 0 {
+		t.Fatalf("expected mate in 1, got %+v at depth %d", analysis.Score, analysis.Depth)
+	}
+
+	game := createTestGame(t, router, `{}`)
+	rec = performJSON(router, http.MethodGet, "/api/v1/games/"+game.ID+"/analysis?depth=2&multiPv=3", ``, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	analysis = AnalysisResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &analysis); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if analysis.Depth != 2 || len(analysis.Lines) != 3 || len(analysis.PV) != len(analysis.PVSAN) {
+		t.Fatalf("expected 3 lines at depth 2, got %d lines at depth %d", len(analysis.Lines), analysis.Depth)
+	}
+	if analysis.PV[0] != analysis.BestMove || analysis.Lines[1].PV[0] == analysis.BestMove {
+		t.Fatalf("expected distinct lines led by the best move, got %+v", analysis.Lines)
+	}
+
+	for _, body := range []string{`{"fen":"not a fen"}`, `{"fen":"` + chess.StartingFEN + `","multiPv":9}`, `{"fen":"` + chess.StartingFEN + `","depth":-1}`} {
+		if rec := performJSON(router, http.MethodPost, "/api/v1/analysis", body, ""); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", body, rec.Code)
+		}
+	}
+	if rec := performJSON(router, http.MethodPost, "/api/v1/analysis", `{"fen":"R5k1/5ppp/8/8/8/8/8/6K1 b - - 1 1"}`, ""); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for a mated position, got %d", rec.Code)
+	}
+}
+
+func TestAnalysisWithExternalEngine(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	pool, err := uci.NewPool(context.Background(), uci.Config{Path: "/bin/sh", Args: []string{"../uci/testdata/fake_engine.sh"}}, 1)
+	if err != nil {
+		t.Fatalf("start fake engine: %v", err)
+	}
+	defer pool.Close()
+
+	handlers := NewHandlers(store.NewMemoryStore())
+	handlers.UseSearcher(pool)
+	router := gin.New()
+	router.POST("/api/v1/analysis", handlers.AnalyzePosition)
+
+	rec := performJSON(router, http.MethodPost, "/api/v1/analysis", `{"fen":"`+chess.StartingFEN+`","multiPv":2}`, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var analysis AnalysisResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &analysis); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	// the fake engine's canned answer
+	if analysis.BestMove != "e2e4" || analysis.Score.Cp == nil || *analysis.Score.Cp != 25 || len(analysis.Lines) != 2 {
+		t.Fatalf("expected the external engine's analysis, got %+v", analysis)
+	}
+	if strings.Join(analysis.PVSAN, " ") != "e4 e5" {
+		t.Fatalf("expected pv e4 e5, got %v", analysis.PVSAN)
+	}
+}
+
+// testBook holds 1. e4 (weight 3) and 1. d4 (weight 1) for the starting
+// position. Polyglot moves pack the origin in bits 6-11 and the destination
+// in bits 0-5, each as rank*8+file.
+func testBook(t *testing.T) *book.Book {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, e := range []struct{ move, weight uint16 }{{12<<6 | 28, 3}, {11<<6 | 27, 1}} {
+		var raw [16]byte
+		binary.BigEndian.PutUint64(raw[0:8], chess.NewBoard().PolyglotKey())
+		binary.BigEndian.PutUint16(raw[8:10], e.move)
+		binary.BigEndian.PutUint16(raw[10:12], e.weight)
+		buf.Write(raw[:])
+	}
+	openings, err := book.Read(&buf)
+	if err != nil {
+		t.Fatalf("read book: %v", err)
+	}
+	return openings
+}
+
+func TestOpeningBook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games", handlers.CreateGame)
+	v1.GET("/games/:id", handlers.GetGame)
+	v1.GET("/games/:id/book", handlers.Book)
+
+	// without a book there are no theory moves
+	game := createTestGame(t, router, `{}`)
+	rec := performJSON(router, http.MethodGet, "/api/v1/games/"+game.ID+"/book", ``, "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"moves":[]`) {
+		t.Fatalf("expected an empty book, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	handlers.UseBook(testBook(t))
+	rec = performJSON(router, http.MethodGet, "/api/v1/games/"+game.ID+"/book", ``, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var response BookResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if len(response.Moves) != 2 || response.Moves[0].SAN != "e4" || response.Moves[0].Probability != 0.75 || response.Moves[1].Move != "d2d4" {
+		t.Fatalf("unexpected book moves %+v", response.Moves)
+	}
+
+	// a bot opens from the book whatever its level
+	game = createTestGame(t, router, `{"preferredColor":"black","opponent":"bot","botLevel":1}`)
+	handlers.bots.Wait()
+	current := getTestGame(t, router, game.ID, game.PlayerToken)
+	if !strings.HasPrefix(current.FEN, "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b") &&
+		!strings.HasPrefix(current.FEN, "rnbqkbnr/pppppppp/8/8/3P4/8/PPP1PPPP/RNBQKBNR b") {
+		t.Fatalf("expected the bot to play a book move, got %s", current.FEN)
+	}
+}
+
+func TestOpeningClassification(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games/import", handlers.ImportGame)
+	v1.GET("/games/:id", handlers.GetGame)
+	v1.GET("/games/:id/pgn", handlers.ExportPGN)
+	v1.POST("/games/:id/join", handlers.JoinGame)
+	v1.POST("/games/:id/moves", handlers.MakeMove)
+	v1.POST("/games/:id/takeback", handlers.OfferTakeback)
+	v1.POST("/games/:id/takeback/accept", handlers.AcceptTakeback)
+
+	// the last move leaves the table, so the Najdorf is the deepest match
+	pgn := `1. e4 c5 2. Nf3 d6 3. d4 cxd4 4. Nxd4 Nf6 5. Nc3 a6 6. h3 *`
+	body, _ := json.Marshal(ImportGameRequest{PGN: pgn})
+	rec := performJSON(router, http.MethodPost, "/api/v1/games/import", string(body), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var imported PlayerGameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &imported); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+
+	game := getTestGame(t, router, imported.ID, imported.PlayerToken)
+	if o := game.Opening; o == nil || o.ECO != "B90" || o.Name != "Sicilian Defense" || o.Variation != "Najdorf Variation" {
+		t.Fatalf("expected B90 Sicilian Defense: Najdorf Variation, got %+v", o)
+	}
+
+	rec = performJSON(router, http.MethodGet, "/api/v1/games/"+imported.ID+"/pgn", ``, "")
+	for _, tag := range []string{`[ECO "B90"]`, `[Opening "Sicilian Defense"]`, `[Variation "Najdorf Variation"]`} {
+		if !strings.Contains(rec.Body.String(), tag) {
+			t.Fatalf("expected %s in pgn:\n%s", tag, rec.Body.String())
+		}
+	}
+
+	// returning to a shorter line keeps the deeper classification
+	body, _ = json.Marshal(ImportGameRequest{PGN: `1. e4 e5 2. Nf3 Nc6 3. Ng1 Nb8 *`})
+	rec = performJSON(router, http.MethodPost, "/api/v1/games/import", string(body), "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &imported); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if o := imported.Opening; o == nil || o.ECO != "C44" {
+		t.Fatalf("expected C44 to survive the transposition back to C20, got %+v", o)
+	}
+
+	// and so does a takeback after it
+	rec = performJSON(router, http.MethodPost, "/api/v1/games/"+imported.ID+"/join", `{}`, "")
+	var joined PlayerGameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &joined); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	white, black := imported.PlayerToken, joined.PlayerToken
+	base := "/api/v1/games/" + imported.ID
+	if rec := performJSON(router, http.MethodPost, base+"/moves", `{"uci":"d2d3"}`, white); rec.Code != http.StatusOK {
+		t.Fatalf("expected d2d3 to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := performJSON(router, http.MethodPost, base+"/takeback", ``, white); rec.Code != http.StatusOK {
+		t.Fatalf("expected the takeback offer to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := performJSON(router, http.MethodPost, base+"/takeback/accept", ``, black); rec.Code != http.StatusOK {
+		t.Fatalf("expected the takeback to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+	game = getTestGame(t, router, imported.ID, white)
+	if game.Ply != 6 {
+		t.Fatalf("expected the game back at ply 6, got %d", game.Ply)
+	}
+	if o := game.Opening; o == nil || o.ECO != "C44" {
+		t.Fatalf("expected C44 to survive the takeback, got %+v", o)
+	}
+}
+
+func TestChess960Game(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games", handlers.CreateGame)
+	v1.GET("/games/:id", handlers.GetGame)
+	v1.GET("/games/:id/legal-moves", handlers.LegalMoves)
+	v1.POST("/games/:id/moves", handlers.MakeMove)
+	v1.GET("/games/:id/pgn", handlers.ExportPGN)
+
+	numbered := createTestGame(t, router, `{"variant":"chess960","chess960Position":0}`)
+	if numbered.Variant != "chess960" || numbered.FEN != "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w HFhf - 0 1" {
+		t.Fatalf("expected Chess960 position 0, got %s %s", numbered.Variant, numbered.FEN)
+	}
+
+	// X-FEN: K and Q are the outermost rooks, here g1 and b1
+	created := createTestGame(t, router, `{"variant":"chess960","fen":"1r2k2r/8/8/8/8/8/8/1R3KR1 w KQkq - 0 1"}`)
+	if created.FEN != "1r2k2r/8/8/8/8/8/8/1R3KR1 w GBhb - 0 1" {
+		t.Fatalf("expected the FEN in Shredder-FEN, got %s", created.FEN)
+	}
+
+	rec := performJSON(router, http.MethodGet, "/api/v1/games/"+created.ID+"/legal-moves?from=f1", ``, created.PlayerToken)
+	for _, uci := range []string{`"f1g1"`, `"f1b1"`} {
+		if !strings.Contains(rec.Body.String(), uci) {
+			t.Fatalf("expected castling move %s in legal moves, got %s", uci, rec.Body.String())
+		}
+	}
+
+	rec = performJSON(router, http.MethodPost, "/api/v1/games/"+created.ID+"/moves", `{"uci":"f1g1"}`, created.PlayerToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected castling to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+	game := getTestGame(t, router, created.ID, created.PlayerToken)
+	if game.FEN != "1r2k2r/8/8/8/8/8/8/1R3RK1 b hb - 1 1" {
+		t.Fatalf("unexpected position after castling: %s", game.FEN)
+	}
+
+	rec = performJSON(router, http.MethodGet, "/api/v1/games/"+created.ID+"/pgn", ``, "")
+	for _, want := range []string{`[Variant "Chess960"]`, `[FEN "1r2k2r/8/8/8/8/8/8/1R3KR1 w GBhb - 0 1"]`, "1. O-O"} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("expected %s in pgn:\n%s", want, rec.Body.String())
+		}
+	}
+
+	for _, body := range []string{
+		`{"variant":"chess960","chess960Position":960}`,
+		`{"chess960Position":5}`,
+		`{"fen":"1r2k2r/8/8/8/8/8/8/1R3KR1 w GBhb - 0 1"}`,
+		`{"variant":"shogi"}`,
+	} {
+		if rec := performJSON(router, http.MethodPost, "/api/v1/games", body, ""); rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestVariantGames(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games", handlers.CreateGame)
+	v1.GET("/games/:id", handlers.GetGame)
+	v1.POST("/games/:id/moves", handlers.MakeMove)
+	v1.GET("/games/:id/pgn", handlers.ExportPGN)
+
+	hill := createTestGame(t, router, `{"variant":"kingOfTheHill","fen":"4k3/8/8/8/8/4K3/8/8 w - - 0 1"}`)
+	if hill.Variant != "kingOfTheHill" {
+		t.Fatalf("expected a King of the Hill game, got %q", hill.Variant)
+	}
+	rec := performJSON(router, http.MethodPost, "/api/v1/games/"+hill.ID+"/moves", `{"uci":"e3d4"}`, hill.PlayerToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the move to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+	game := getTestGame(t, router, hill.ID, hill.PlayerToken)
+	if game.Result != resultVariantEnd || game.Winner != "white" || game.EndedBy != "king_of_the_hill" {
+		t.Fatalf("expected White to win on the hill, got %s %s %s", game.Result, game.Winner, game.EndedBy)
+	}
+	rec = performJSON(router, http.MethodGet, "/api/v1/games/"+hill.ID+"/pgn", ``, "")
+	for _, want := range []string{`[Variant "King of the Hill"]`, `[Result "1-0"]`} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("expected %s in pgn:\n%s", want, rec.Body.String())
+		}
+	}
+
+	checks := createTestGame(t, router, `{"variant":"threeCheck"}`)
+	if checks.FEN != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 3+3 0 1" {
+		t.Fatalf("expected the checks field in the FEN, got %s", checks.FEN)
+	}
+
+	racing := createTestGame(t, router, `{"variant":"racingKings"}`)
+	if racing.FEN != "8/8/8/8/8/8/krbnNBRK/qrbnNBRQ w - - 0 1" {
+		t.Fatalf("expected the Racing Kings start, got %s", racing.FEN)
+	}
+
+	if rec := performJSON(router, http.MethodPost, "/api/v1/games", `{"variant":"kingOfTheHill","chess960Position":3}`, ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a Chess960 position outside Chess960, got %d", rec.Code)
+	}
+}
+
+func TestCrazyhouseGame(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games/import", handlers.ImportGame)
+	v1.GET("/games/:id", handlers.GetGame)
+	v1.GET("/games/:id/legal-moves", handlers.LegalMoves)
+	v1.POST("/games/:id/moves", handlers.MakeMove)
+	v1.GET("/games/:id/pgn", handlers.ExportPGN)
+
+	pgn := "[Variant \"Crazyhouse\"]\n\n1. e4 d5 2. exd5 Qxd5 *"
+	body, _ := json.Marshal(ImportGameRequest{PGN: pgn})
+	rec := performJSON(router, http.MethodPost, "/api/v1/games/import", string(body), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var imported PlayerGameResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &imported); err != nil {
+		t.Fatalf("failed to parse response: %v", err)
+	}
+	if imported.Variant != "crazyhouse" || imported.Pockets == nil || imported.Pockets.White.Pawn != 1 || imported.Pockets.Black.Pawn != 1 {
+		t.Fatalf("expected a pawn in each pocket, got %s %+v", imported.Variant, imported.Pockets)
+	}
+
+	rec = performJSON(router, http.MethodGet, "/api/v1/games/"+imported.ID+"/legal-moves", ``, imported.PlayerToken)
+	if !strings.Contains(rec.Body.String(), `"P@e6"`) || strings.Contains(rec.Body.String(), `"P@e8"`) {
+		t.Fatalf("expected pawn drops off the back ranks in legal moves, got %s", rec.Body.String())
+	}
+
+	rec = performJSON(router, http.MethodPost, "/api/v1/games/"+imported.ID+"/moves", `{"uci":"P@e6"}`, imported.PlayerToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the drop to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+	game := getTestGame(t, router, imported.ID, imported.PlayerToken)
+	if game.FEN != "rnb1kbnr/ppp1pppp/4P3/3q4/8/8/PPPP1PPP/RNBQKBNR[p] b KQkq - 1 3" || game.Pockets.White.Pawn != 0 {
+		t.Fatalf("unexpected game after the drop: %s %+v", game.FEN, game.Pockets)
+	}
+
+	rec = performJSON(router, http.MethodGet, "/api/v1/games/"+imported.ID+"/pgn", ``, "")
+	for _, want := range []string{`[Variant "Crazyhouse"]`, "3. @e6"} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("expected %s in pgn:\n%s", want, rec.Body.String())
+		}
+	}
+}
+
+func TestAtomicAndAntichessGames(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games", handlers.CreateGame)
+	v1.GET("/games/:id", handlers.GetGame)
+	v1.POST("/games/:id/moves", handlers.MakeMove)
+
+	atomic := createTestGame(t, router, `{"variant":"atomic","fen":"3qk3/4n3/8/8/8/8/8/3QK2r w - - 0 1"}`)
+	rec := performJSON(router, http.MethodPost, "/api/v1/games/"+atomic.ID+"/moves", `{"uci":"d1d8"}`, atomic.PlayerToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the explosion to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+	game := getTestGame(t, router, atomic.ID, atomic.PlayerToken)
+	if game.Result != resultVariantEnd || game.Winner != "white" || game.EndedBy != "king_exploded" {
+		t.Fatalf("expected White to win by exploding the king, got %s %s %s", game.Result, game.Winner, game.EndedBy)
+	}
+
+	anti := createTestGame(t, router, `{"variant":"antichess","fen":"8/8/8/8/p7/8/P7/8 w - - 0 1"}`)
+	rec = performJSON(router, http.MethodPost, "/api/v1/games/"+anti.ID+"/moves", `{"uci":"a2a3"}`, anti.PlayerToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected a2a3 to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+	game = getTestGame(t, router, anti.ID, anti.PlayerToken)
+	if game.Result != resultVariantEnd || game.Winner != "black" || game.EndedBy != "stalemate" || game.Flags.Draw {
+		t.Fatalf("expected the stalemated side to win, got %s %s %s", game.Result, game.Winner, game.EndedBy)
+	}
+}
+
+func TestHordeAndOddsGames(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	memStore := store.NewMemoryStore()
+	handlers := NewHandlers(memStore)
+	router := gin.New()
+	v1 := router.Group("/api/v1")
+	v1.POST("/games", handlers.CreateGame)
+	v1.GET("/games/:id", handlers.GetGame)
+	v1.POST("/games/:id/moves", handlers.MakeMove)
+
+	horde := createTestGame(t, router, `{"variant":"horde"}`)
+	if horde.FEN != chess.HordeFEN || horde.Flags.InCheck {
+		t.Fatalf("expected the Horde start without check, got %s", horde.FEN)
+	}
+
+	horde = createTestGame(t, router, `{"variant":"horde","fen":"4k3/8/8/8/8/8/1q6/P7 b - - 0 1","preferredColor":"black"}`)
+	rec := performJSON(router, http.MethodPost, "/api/v1/games/"+horde.ID+"/moves", `{"uci":"b2a1"}`, horde.PlayerToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the capture to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+	game := getTestGame(t, router, horde.ID, horde.PlayerToken)
+	if game.Result != resultVariantEnd || game.Winner != "black" || game.EndedBy != "all_pieces_lost" {
+		t.Fatalf("expected Black to win by taking the horde, got %s %s %s", game.Result, game.Winner, game.EndedBy)
+	}
+
+	odds := createTestGame(t, router, `{"odds":"knight"}`)
+	if odds.Variant != "standard" || odds.FEN != chess.KnightOdds.FEN {
+		t.Fatalf("expected a standard game at knight odds, got %s %s", odds.Variant, odds.FEN)
+	}
+	rec = performJSON(router, http.MethodPost, "/api/v1/games/"+odds.ID+"/moves", `{"uci":"e2e4"}`, odds.PlayerToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected e2e4 to be accepted, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	for _, body := range []string{
+		`{"odds":"bishop"}`,
+		`{"odds":"rook","variant":"chess960"}`,
+		`{"odds":"queen","fen":"4k3/8/8/8/8/8/8/4K3 w - - 0 1"}`,
+	} {
+		if rec := performJSON(router, http.MethodPost, "/api/v1/games", body, ""); rec.Code != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s, got %d", body, rec.Code)
+		}
+	}
+}
@@ -30,3 +30,19 @@ func TestUCIFromMove(t *testing.T) {
 		t.Fatalf("expected promotion uci, got %q", got)
 	}
 }
+
+func TestParseUCIDrop(t *testing.T) {
+	move, err := parseUCI("N@f3")
+	if err != nil {
+		t.Fatalf("parseUCI drop error: %v", err)
+	}
+	if !move.IsDrop() || move.Drop != chess.Knight || move.To != chess.F3 {
+		t.Fatalf("unexpected drop: %+v", move)
+	}
+	if got := uciFromMove(move); got != "N@f3" {
+		t.Fatalf("expected drop uci, got %q", got)
+	}
+	if _, err := parseUCI("K@e4"); err == nil {
+		t.Fatalf("expected a king drop to be rejected")
+	}
+}
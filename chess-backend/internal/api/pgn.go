@@ -0,0 +1,180 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"chess-backend/internal/chess"
+	"chess-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+const pgnContentType = "application/x-chess-pgn"
+
+func (h *Handlers) ExportPGN(c *gin.Context) {
+	id := c.Param("id")
+
+	game, err := h.store.GetGame(c.Request.Context(), id)
+	if err != nil {
+		handleStoreError(c, err)
+		return
+	}
+	moves, err := h.store.ListMoves(c.Request.Context(), id)
+	if err != nil {
+		handleStoreError(c, err)
+		return
+	}
+
+	pgn, err := buildPGN(game, moves)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "failed to build pgn: "+err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+game.ID+`.pgn"`)
+	c.Data(http.StatusOK, pgnContentType, []byte(pgn.String()))
+}
+
+func buildPGN(game *store.Game, moves []string) (*chess.PGNGame, error) {
+	start, err := loadStartBoard(game)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := make([]chess.Move, 0, len(moves))
+	for _, uci := range moves {
+		move, err := parseUCI(uci)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, move)
+	}
+
+	pgn, err := chess.NewPGNGameFromMoves(start, parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	pgn.Tags["Date"] = game.CreatedAt.Format("2006.01.02")
+	pgn.Tags["Result"] = pgnResult(computeStatus(game))
+	if game.Clock != nil {
+		pgn.Tags["TimeControl"] = game.Clock.Control.String()
+	}
+	if o := game.Opening; o != nil {
+		pgn.Tags["ECO"] = o.ECO
+		pgn.Tags["Opening"] = o.Name
+		if o.Variation != "" {
+			pgn.Tags["Variation"] = o.Variation
+		}
+	}
+	return pgn, nil
+}
+
+func pgnResult(status Status) string {
+	if status.Result == resultOngoing {
+		return chess.ResultUnknown
+	}
+	switch status.Winner {
+	case chess.White.String():
+		return chess.ResultWhiteWins
+	case chess.Black.String():
+		return chess.ResultBlackWins
+	default:
+		return chess.ResultDraw
+	}
+}
+
+func (h *Handlers) ImportGame(c *gin.Context) {
+	var req ImportGameRequest
+	if strings.HasPrefix(c.ContentType(), pgnContentType) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			writeError(c, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		req.PGN = string(body)
+		req.PreferredColor = c.Query("preferredColor")
+		if idx := c.Query("index"); idx != "" {
+			req.Index, err = strconv.Atoi(idx)
+			if err != nil {
+				writeError(c, http.StatusBadRequest, "invalid index")
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	games, err := chess.ParsePGN(strings.NewReader(req.PGN))
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(games) == 0 {
+		writeError(c, http.StatusBadRequest, "no games found in pgn")
+		return
+	}
+	if req.Index < 0 || req.Index >= len(games) {
+		writeError(c, http.StatusBadRequest, fmt.Sprintf("index out of range: pgn contains %d game(s)", len(games)))
+		return
+	}
+
+	start, moves, err := games[req.Index].Replay()
+	if err != nil {
+		writeError(c, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	creatorColor, err := parsePreferredColor(req.PreferredColor)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	game, playerToken, err := newGame(start, creatorColor)
+	if err != nil {
+		writeError(c, http.StatusInternalServerError, "failed to create game id")
+		return
+	}
+
+	records := make([]store.MoveRecord, 0, len(moves))
+	for _, move := range moves {
+		san, err := game.Board.SAN(move)
+		if err != nil {
+			writeError(c, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		if err := game.Board.MakeMove(move); err != nil {
+			writeError(c, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		updateOpening(game)
+		records = append(records, store.MoveRecord{UCI: uciFromMove(move), SAN: san})
+	}
+
+	status := computeStatus(game)
+	game.Result = status.Result
+	game.Winner = status.Winner
+	game.EndedBy = status.EndedBy
+	game.UpdatedAt = time.Now().UTC()
+
+	// the game and its moves are saved together, so a failure leaves no
+	// half-imported game behind
+	if err := h.store.CreateGameWithMoves(c.Request.Context(), game, records); err != nil {
+		writeError(c, http.StatusInternalServerError, "failed to store game: "+err.Error())
+		return
+	}
+
+	response := buildGameResponseForToken(game, playerToken)
+	c.JSON(http.StatusOK, PlayerGameResponse{
+		GameResponse:  response,
+		PlayerToken:   playerToken,
+		OpponentColor: creatorColor.Opposite().String(),
+	})
+}
@@ -1,6 +1,9 @@
 package api
 
-import "chess-backend/internal/store"
+import (
+	"chess-backend/internal/chess"
+	"chess-backend/internal/store"
+)
 
 const (
 	resultOngoing   = "ongoing"
@@ -8,16 +11,27 @@ const (
 	resultStalemate = "stalemate"
 	resultDraw      = "draw"
 	resultResigned  = "resigned"
+	resultTimeout   = "timeout"
+	// resultVariantEnd is a win by a variant's own rule, such as reaching
+	// the hill in King of the Hill, being stalemated in Antichess or taking
+	// the whole horde in Horde; EndedBy says which
+	resultVariantEnd = "variant_end"
 )
 
 const (
-	endedByCheckmate            = "checkmate"
-	endedByStalemate            = "stalemate"
+	endedByCheckmate            = chess.EndCheckmate
+	endedByStalemate            = chess.EndStalemate
 	endedByResignation          = "resignation"
 	endedByDrawAgreement        = "draw_agreement"
 	endedByDrawClaim            = "draw_claim"
-	endedByInsufficientMaterial = "insufficient_material"
+	endedByInsufficientMaterial = chess.EndInsufficientMaterial
 	endedByFiftyMove            = "fifty_move"
+	endedByThreefoldRepetition  = "threefold_repetition"
+	endedByFivefoldRepetition   = chess.EndFivefoldRepetition
+	endedBySeventyFiveMove      = chess.EndSeventyFiveMove
+	endedByDeadPosition         = chess.EndDeadPosition
+	endedByRacingKings          = chess.EndRacingKings
+	endedByTimeout              = "timeout"
 )
 
 type Status struct {
@@ -43,42 +57,18 @@ func computeStatus(game *store.Game) Status {
 		}
 	}
 
-	if board.IsCheckmate(board.Turn()) {
-		flags.Checkmate = true
-		flags.InCheck = true
-		return Status{
-			Result:  resultCheckmate,
-			Winner:  board.Turn().Opposite().String(),
-			EndedBy: endedByCheckmate,
-			Flags:   flags,
-		}
-	}
-
-	if board.IsStalemate(board.Turn()) {
-		flags.Stalemate = true
-		flags.Draw = true
-		return Status{
-			Result:  resultStalemate,
-			Winner:  "none",
-			EndedBy: endedByStalemate,
-			Flags:   flags,
-		}
+	if outcome, over := board.Outcome(); over {
+		return outcomeStatus(outcome, flags)
 	}
 
-	if board.IsInsufficientMaterial() {
-		flags.Draw = true
-		flags.DrawReason = endedByInsufficientMaterial
-		return Status{
-			Result:  resultDraw,
-			Winner:  "none",
-			EndedBy: endedByInsufficientMaterial,
-			Flags:   flags,
-		}
+	// the unwinnability test knows only the orthodox ways to win
+	if board.Orthodox() {
+		flags.Unwinnable = board.CannotForceMate()
 	}
 
-	if board.CanClaimFiftyMoveDraw() {
+	if reason, ok := drawClaimReason(board); ok {
 		flags.DrawClaimable = true
-		flags.DrawReason = endedByFiftyMove
+		flags.DrawReason = reason
 	}
 
 	return Status{
@@ -87,13 +77,46 @@ func computeStatus(game *store.Game) Status {
 	}
 }
 
+// outcomeStatus reports a game the rules have ended.
+func outcomeStatus(o chess.Outcome, flags Flags) Status {
+	switch {
+	case o.Reason == chess.EndCheckmate:
+		flags.Checkmate = true
+		flags.InCheck = true
+		return Status{Result: resultCheckmate, Winner: o.Winner.String(), EndedBy: o.Reason, Flags: flags}
+	case o.Reason == chess.EndStalemate && o.Draw:
+		flags.Stalemate = true
+		flags.Draw = true
+		return Status{Result: resultStalemate, Winner: "none", EndedBy: o.Reason, Flags: flags}
+	case o.Draw:
+		flags.Draw = true
+		flags.DrawReason = o.Reason
+		return Status{Result: resultDraw, Winner: "none", EndedBy: o.Reason, Flags: flags}
+	default:
+		return Status{Result: resultVariantEnd, Winner: o.Winner.String(), EndedBy: o.Reason, Flags: flags}
+	}
+}
+
+func drawClaimReason(board *chess.Board) (string, bool) {
+	switch {
+	case board.CanClaimFiftyMoveDraw():
+		return endedByFiftyMove, true
+	case board.CanClaimThreefoldRepetition():
+		return endedByThreefoldRepetition, true
+	default:
+		return "", false
+	}
+}
+
 func applyStoredStatus(flags *Flags, result string, endedBy string) {
 	switch result {
 	case resultResigned:
 		flags.Draw = false
 	case resultDraw:
 		flags.Draw = true
-		if endedBy == endedByInsufficientMaterial || endedBy == endedByFiftyMove {
+		switch endedBy {
+		case endedByInsufficientMaterial, endedByFiftyMove, endedByFivefoldRepetition, endedByDrawClaim,
+			endedBySeventyFiveMove, endedByDeadPosition, endedByTimeout, endedByRacingKings:
 			flags.DrawReason = endedBy
 		}
 	case resultStalemate:
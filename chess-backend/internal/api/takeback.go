@@ -0,0 +1,184 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"chess-backend/internal/chess"
+	"chess-backend/internal/eco"
+	"chess-backend/internal/store"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *Handlers) OfferTakeback(c *gin.Context) {
+	id := c.Param("id")
+	token := playerTokenFromRequest(c)
+
+	game, err := h.store.GetGame(c.Request.Context(), id)
+	if err != nil {
+		handleStoreError(c, err)
+		return
+	}
+	color, ok := requirePlayerToken(c, game, token)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	if err := h.expireClock(c.Request.Context(), game, now); err != nil {
+		handleStoreError(c, err)
+		return
+	}
+
+	status := computeStatus(game)
+	if status.Result != resultOngoing {
+		writeError(c, http.StatusConflict, "game already finished")
+		return
+	}
+	if game.PendingTakebackBy != nil {
+		writeError(c, http.StatusConflict, "takeback already requested")
+		return
+	}
+
+	sim := game.Board.Clone()
+	if err := unmakeMoves(sim, takebackPlies(game.Board.Turn(), color)); err != nil {
+		writeError(c, http.StatusConflict, "no move to take back")
+		return
+	}
+
+	game.PendingTakebackBy = &color
+	game.UpdatedAt = now
+	if err := h.store.UpdateGame(c.Request.Context(), game); err != nil {
+		handleStoreError(c, err)
+		return
+	}
+	h.broadcastGame(game)
+
+	c.JSON(http.StatusOK, TakebackResponse{Offer: "pending"})
+}
+
+func (h *Handlers) AcceptTakeback(c *gin.Context) {
+	id := c.Param("id")
+	token := playerTokenFromRequest(c)
+
+	game, err := h.store.GetGame(c.Request.Context(), id)
+	if err != nil {
+		handleStoreError(c, err)
+		return
+	}
+	color, ok := requirePlayerToken(c, game, token)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	if err := h.expireClock(c.Request.Context(), game, now); err != nil {
+		handleStoreError(c, err)
+		return
+	}
+
+	status := computeStatus(game)
+	if status.Result != resultOngoing {
+		writeError(c, http.StatusConflict, "game already finished")
+		return
+	}
+	if !requireTakebackFromOpponent(c, game, color) {
+		return
+	}
+
+	turn := game.Board.Turn()
+	plies := takebackPlies(turn, *game.PendingTakebackBy)
+	if err := unmakeMoves(game.Board, plies); err != nil {
+		writeError(c, http.StatusConflict, "no move to take back")
+		return
+	}
+	// reclassify over the moves that remain, keeping the deepest match as
+	// updateOpening does move by move
+	game.Opening = nil
+	if o, ok := eco.Classify(game.Board); ok {
+		game.Opening = &o
+	}
+
+	status = computeStatus(game)
+	game.Result = status.Result
+	game.Winner = status.Winner
+	game.EndedBy = status.EndedBy
+	game.PendingTakebackBy = nil
+	game.PendingDrawOfferBy = nil
+	game.UpdatedAt = now
+	restartClock(game, turn, plies, now)
+
+	if err := h.store.UpdateGameWithTakeback(c.Request.Context(), game, plies); err != nil {
+		if errors.Is(err, store.ErrNoMovesToTakeBack) {
+			writeError(c, http.StatusConflict, "no move to take back")
+			return
+		}
+		handleStoreError(c, err)
+		return
+	}
+	response := buildGameResponseForToken(game, token)
+	h.broadcastGame(game)
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handlers) DeclineTakeback(c *gin.Context) {
+	id := c.Param("id")
+	token := playerTokenFromRequest(c)
+
+	game, err := h.store.GetGame(c.Request.Context(), id)
+	if err != nil {
+		handleStoreError(c, err)
+		return
+	}
+	color, ok := requirePlayerToken(c, game, token)
+	if !ok {
+		return
+	}
+	if !requireTakebackFromOpponent(c, game, color) {
+		return
+	}
+
+	game.PendingTakebackBy = nil
+	game.UpdatedAt = time.Now().UTC()
+	if err := h.store.UpdateGame(c.Request.Context(), game); err != nil {
+		handleStoreError(c, err)
+		return
+	}
+	h.broadcastGame(game)
+
+	c.JSON(http.StatusOK, TakebackResponse{Offer: "declined"})
+}
+
+// takebackPlies is how many plies to undo so that requester's last move is
+// taken back: just that move if the opponent has not replied yet, otherwise
+// the reply as well.
+func takebackPlies(turn, requester chess.Color) int {
+	if turn == requester {
+		return 2
+	}
+	return 1
+}
+
+func unmakeMoves(board *chess.Board, plies int) error {
+	for range plies {
+		if err := board.UnmakeMove(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func requireTakebackFromOpponent(c *gin.Context, game *store.Game, color chess.Color) bool {
+	if game.PendingTakebackBy == nil {
+		writeError(c, http.StatusConflict, "no pending takeback request")
+		return false
+	}
+	if *game.PendingTakebackBy == color {
+		writeError(c, http.StatusConflict, "takeback request must be answered by opponent")
+		return false
+	}
+	return true
+}
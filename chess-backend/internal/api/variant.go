@@ -0,0 +1,79 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"chess-backend/internal/chess"
+	"chess-backend/internal/store"
+)
+
+// startBoard sets up the position a new game starts from: req.Fen, the
+// req.Odds handicap position, or the variant's starting position. Chess960
+// games start from req.Chess960Position, a random position when it is not
+// given, or a Chess960 FEN in Shredder-FEN or X-FEN.
+func startBoard(req CreateGameRequest) (*chess.Board, error) {
+	fen := strings.TrimSpace(req.Fen)
+
+	variant, ok := chess.VariantByName(req.Variant)
+	if !ok {
+		return nil, fmt.Errorf("unknown variant %q", req.Variant)
+	}
+
+	if name := strings.TrimSpace(req.Odds); name != "" {
+		odds, ok := chess.OddsByName(name)
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("unknown odds %q", req.Odds)
+		case variant != chess.Standard || req.Chess960Position != nil:
+			return nil, errors.New("odds games are played under standard rules")
+		case fen != "":
+			return nil, errors.New("give either fen or odds, not both")
+		}
+		return odds.NewBoard(), nil
+	}
+
+	switch {
+	case variant == chess.Chess960:
+		if fen != "" {
+			if req.Chess960Position != nil {
+				return nil, errors.New("give either fen or chess960Position, not both")
+			}
+			return chess.LoadChess960FEN(fen)
+		}
+		n := rand.IntN(960)
+		if req.Chess960Position != nil {
+			n = *req.Chess960Position
+		}
+		return chess.NewChess960Board(n)
+
+	case req.Chess960Position != nil:
+		return nil, errors.New("chess960Position needs the chess960 variant")
+
+	case fen == "":
+		return variant.NewBoard(), nil
+
+	default:
+		return chess.LoadVariantFEN(variant, fen)
+	}
+}
+
+// loadStartBoard loads a stored game's start position under its variant's
+// rules.
+func loadStartBoard(game *store.Game) (*chess.Board, error) {
+	variant, ok := chess.VariantByName(game.Variant)
+	if !ok {
+		return nil, fmt.Errorf("unknown variant %q", game.Variant)
+	}
+	if fen := strings.TrimSpace(game.StartFEN); fen != "" {
+		return chess.LoadVariantFEN(variant, fen)
+	}
+	return variant.NewBoard(), nil
+}
+
+// gameVariant is the variant a game on board is played in.
+func gameVariant(board *chess.Board) string {
+	return board.Variant().Name()
+}
@@ -0,0 +1,186 @@
+// Package book reads opening books in the Polyglot .bin format and suggests
+// moves from them.
+package book
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"slices"
+
+	"chess-backend/internal/chess"
+)
+
+// entrySize is the size of one Polyglot entry: key, move, weight and learn
+// data, all big-endian.
+const entrySize = 16
+
+var ErrInvalidBook = errors.New("invalid polyglot book")
+
+type entry struct {
+	key    uint64
+	move   uint16
+	weight uint16
+}
+
+// Book is an opening book held in memory. A nil *Book is an empty book.
+type Book struct {
+	entries []entry // sorted by key
+}
+
+// Move is a book move with its weight; how often it should be played is its
+// weight relative to the other moves in the position.
+type Move struct {
+	Move   chess.Move
+	Weight int
+}
+
+func Open(path string) (*Book, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Read loads a whole book. Polyglot books are sorted by key; unsorted input
+// is sorted so lookups still work.
+func Read(r io.Reader) (*Book, error) {
+	var entries []entry
+	br := bufio.NewReader(r)
+	var buf [entrySize]byte
+	for {
+		_, err := io.ReadFull(br, buf[:])
+		if err == io.EOF {
+			break
+		}
+		if err == io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("%w: truncated entry", ErrInvalidBook)
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry{
+			key:    binary.BigEndian.Uint64(buf[0:8]),
+			move:   binary.BigEndian.Uint16(buf[8:10]),
+			weight: binary.BigEndian.Uint16(buf[10:12]),
+		})
+	}
+
+	byKey := func(a, b entry) int { return cmp.Compare(a.key, b.key) }
+	if !slices.IsSortedFunc(entries, byKey) {
+		slices.SortStableFunc(entries, byKey)
+	}
+	return &Book{entries: entries}, nil
+}
+
+// Len is the number of entries in the book.
+func (bk *Book) Len() int {
+	if bk == nil {
+		return 0
+	}
+	return len(bk.entries)
+}
+
+// Moves lists the book moves for the position, heaviest first. Entries that
+// are not legal in the position, e.g. from a key collision, are skipped, as
+// are moves with weight 0, which books use to mark moves not to play.
+// Books hold orthodox chess, so variants with other rules get no moves.
+func (bk *Book) Moves(board *chess.Board) []Move {
+	if bk == nil || !board.Orthodox() {
+		return nil
+	}
+
+	key := board.PolyglotKey()
+	i, _ := slices.BinarySearchFunc(bk.entries, key, func(e entry, k uint64) int { return cmp.Compare(e.key, k) })
+
+	var moves []Move
+	for ; i < len(bk.entries) && bk.entries[i].key == key; i++ {
+		e := bk.entries[i]
+		if e.weight == 0 {
+			continue
+		}
+		m := decodeMove(board, e.move)
+		if chess.ValidateMove(board, m) != nil {
+			continue
+		}
+		if j := slices.IndexFunc(moves, func(bm Move) bool { return bm.Move == m }); j >= 0 {
+			moves[j].Weight += int(e.weight)
+			continue
+		}
+		moves = append(moves, Move{Move: m, Weight: int(e.weight)})
+	}
+
+	slices.SortStableFunc(moves, func(a, b Move) int { return cmp.Compare(b.Weight, a.Weight) })
+	return moves
+}
+
+// Best returns the heaviest book move.
+func (bk *Book) Best(board *chess.Board) (chess.Move, bool) {
+	moves := bk.Moves(board)
+	if len(moves) == 0 {
+		return chess.Move{}, false
+	}
+	return moves[0].Move, true
+}
+
+// Pick chooses a book move at random in proportion to the weights.
+func (bk *Book) Pick(board *chess.Board) (chess.Move, bool) {
+	moves := bk.Moves(board)
+	total := 0
+	for _, m := range moves {
+		total += m.Weight
+	}
+	if total == 0 {
+		return chess.Move{}, false
+	}
+
+	n := rand.IntN(total)
+	for _, m := range moves {
+		if n < m.Weight {
+			return m.Move, true
+		}
+		n -= m.Weight
+	}
+	return moves[len(moves)-1].Move, true
+}
+
+// polyglotPromotions maps the promotion field of a Polyglot move.
+var polyglotPromotions = [8]chess.PieceType{1: chess.Knight, 2: chess.Bishop, 3: chess.Rook, 4: chess.Queen}
+
+// decodeMove unpacks a Polyglot move: bits 0-5 are the destination, 6-11 the
+// origin (file in the low three bits, rank above) and 12-14 the promotion.
+// Castling is written as the king taking its own rook and becomes the usual
+// two-square king move, except on Chess960 boards, which write it the same way.
+func decodeMove(board *chess.Board, raw uint16) chess.Move {
+	square := func(bits uint16) chess.Square {
+		file, rank := int(bits&7), int(bits>>3&7)
+		return chess.Square(file*8 + rank)
+	}
+	m := chess.Move{
+		From:      square(raw >> 6),
+		To:        square(raw),
+		Promotion: polyglotPromotions[raw>>12&7],
+	}
+
+	if board.Chess960() {
+		return m
+	}
+	if p := board.PieceAt(m.From); p != nil && p.Type == chess.King && m.From.File() == 4 {
+		if rook := board.PieceAt(m.To); rook != nil && rook.Type == chess.Rook && rook.Color == p.Color {
+			switch m.To.File() {
+			case 7:
+				m.To = chess.Square(6*8 + m.From.Rank())
+			case 0:
+				m.To = chess.Square(2*8 + m.From.Rank())
+			}
+		}
+	}
+	return m
+}
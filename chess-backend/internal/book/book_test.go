@@ -0,0 +1,142 @@
+package book
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"chess-backend/internal/chess"
+)
+
+type testEntry struct {
+	fen    string
+	move   string // from, to and an optional promotion as in the Polyglot encoding
+	weight uint16
+}
+
+// encodeMove packs a move the way Polyglot does, e.g. "e1h1" for white
+// castling kingside.
+func encodeMove(t *testing.T, uci string) uint16 {
+	t.Helper()
+	m, err := chess.ParseUCI(uci)
+	if err != nil {
+		t.Fatalf("parse %s: %v", uci, err)
+	}
+	square := func(sq chess.Square) uint16 { return uint16(sq.Rank()<<3 | sq.File()) }
+	raw := square(m.From)<<6 | square(m.To)
+	for code, pt := range polyglotPromotions {
+		if code > 0 && m.Promotion != 0 && pt == m.Promotion {
+			raw |= uint16(code) << 12
+		}
+	}
+	return raw
+}
+
+func buildBook(t *testing.T, entries []testEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, e := range entries {
+		b, err := chess.LoadFEN(e.fen)
+		if err != nil {
+			t.Fatalf("load fen: %v", err)
+		}
+		var raw [entrySize]byte
+		binary.BigEndian.PutUint64(raw[0:8], b.PolyglotKey())
+		binary.BigEndian.PutUint16(raw[8:10], encodeMove(t, e.move))
+		binary.BigEndian.PutUint16(raw[10:12], e.weight)
+		buf.Write(raw[:])
+	}
+	return buf.Bytes()
+}
+
+const castlingFEN = "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1"
+
+func testBook(t *testing.T) *Book {
+	t.Helper()
+	// deliberately out of key order
+	data := buildBook(t, []testEntry{
+		{chess.StartingFEN, "d2d4", 10},
+		{castlingFEN, "e1h1", 5},
+		{chess.StartingFEN, "e2e4", 30},
+		{chess.StartingFEN, "e2e5", 50}, // illegal
+		{chess.StartingFEN, "a2a3", 0},  // never to be played
+		{castlingFEN, "e1a1", 2},
+		{"8/P6k/8/8/8/8/8/K7 w - - 0 1", "a7a8q", 1},
+	})
+	bk, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("read book: %v", err)
+	}
+	return bk
+}
+
+func moveList(moves []Move) []string {
+	list := make([]string, len(moves))
+	for i, m := range moves {
+		list[i] = m.Move.UCI()
+	}
+	return list
+}
+
+func TestMoves(t *testing.T) {
+	bk := testBook(t)
+	if bk.Len() != 7 {
+		t.Fatalf("expected 7 entries, got %d", bk.Len())
+	}
+
+	moves := bk.Moves(chess.NewBoard())
+	if got := moveList(moves); len(got) != 2 || got[0] != "e2e4" || got[1] != "d2d4" {
+		t.Fatalf("expected e2e4 then d2d4, got %v", got)
+	}
+	if moves[0].Weight != 30 || moves[1].Weight != 10 {
+		t.Fatalf("unexpected weights %+v", moves)
+	}
+
+	b, _ := chess.LoadFEN(castlingFEN)
+	if got := moveList(bk.Moves(b)); len(got) != 2 || got[0] != "e1g1" || got[1] != "e1c1" {
+		t.Fatalf("expected castling as king moves, got %v", got)
+	}
+
+	b, _ = chess.LoadFEN("8/P6k/8/8/8/8/8/K7 w - - 0 1")
+	if best, ok := bk.Best(b); !ok || best.UCI() != "a7a8q" {
+		t.Fatalf("expected a7a8q, got %s", best.UCI())
+	}
+
+	b, _ = chess.LoadFEN("8/8/8/8/8/8/8/K6k w - - 0 1")
+	if _, ok := bk.Best(b); ok {
+		t.Fatal("expected no book move outside the book")
+	}
+}
+
+func TestPickFollowsWeights(t *testing.T) {
+	bk := testBook(t)
+	counts := map[string]int{}
+	for range 2000 {
+		m, ok := bk.Pick(chess.NewBoard())
+		if !ok {
+			t.Fatal("expected a book move")
+		}
+		counts[m.UCI()]++
+	}
+	if len(counts) != 2 || counts["e2e4"] < 1300 || counts["d2d4"] < 300 {
+		t.Fatalf("expected roughly 3:1 e2e4 to d2d4, got %v", counts)
+	}
+}
+
+func TestNilBook(t *testing.T) {
+	var bk *Book
+	if bk.Len() != 0 || bk.Moves(chess.NewBoard()) != nil {
+		t.Fatal("expected a nil book to be empty")
+	}
+	if _, ok := bk.Pick(chess.NewBoard()); ok {
+		t.Fatal("expected no move from a nil book")
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+	data := buildBook(t, []testEntry{{chess.StartingFEN, "e2e4", 1}})
+	if _, err := Read(bytes.NewReader(data[:entrySize-3])); !errors.Is(err, ErrInvalidBook) {
+		t.Fatalf("expected ErrInvalidBook, got %v", err)
+	}
+}
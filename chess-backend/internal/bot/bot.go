@@ -0,0 +1,82 @@
+// Package bot plays moves for computer opponents of selectable strength on top
+// of the engine package.
+package bot
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"chess-backend/internal/chess"
+	"chess-backend/internal/engine"
+)
+
+const (
+	MinLevel     = 1
+	MaxLevel     = 8
+	DefaultLevel = 4
+)
+
+var ErrInvalidLevel = errors.New("invalid bot level")
+
+// Level limits how hard a bot thinks. With Noise set every root move gets a
+// line of its own in a multi-PV search, and a random error with that standard
+// deviation, in centipawns, is added to each line's score before the best one
+// is picked. Small errors are common and large ones rare, so weak bots mostly
+// drop a pawn or miss a tactic rather than playing at random.
+type Level struct {
+	Depth    int
+	Nodes    uint64
+	MoveTime time.Duration
+	Noise    float64
+}
+
+var levels = [MaxLevel + 1]Level{
+	1: {Depth: 2, Noise: 400},
+	2: {Depth: 2, Noise: 200},
+	3: {Depth: 3, Noise: 120},
+	4: {Depth: 3, Noise: 60},
+	5: {Depth: 4, Noise: 30},
+	6: {Depth: 5, Noise: 15},
+	7: {Depth: 6, Nodes: 300_000},
+	8: {Nodes: 3_000_000, MoveTime: 2 * time.Second},
+}
+
+func ValidateLevel(level int) error {
+	if level < MinLevel || level > MaxLevel {
+		return ErrInvalidLevel
+	}
+	return nil
+}
+
+// ChooseMove picks a move for the side to move at the given level using
+// searcher, which may be the built-in engine or an external one. The board is
+// not modified. Cancelling ctx makes the bot answer with what it has.
+func ChooseMove(ctx context.Context, searcher engine.Searcher, board *chess.Board, level int) (chess.Move, error) {
+	if err := ValidateLevel(level); err != nil {
+		return chess.Move{}, err
+	}
+	l := levels[level]
+
+	limits := engine.Limits{Depth: l.Depth, Nodes: l.Nodes, MoveTime: l.MoveTime}
+	if l.Noise > 0 {
+		limits.MultiPV = len(board.LegalMoves())
+	}
+	result, err := searcher.Search(ctx, board, limits)
+	if err != nil || l.Noise == 0 {
+		return result.Move, err
+	}
+
+	best, bestScore, found := result.Move, 0.0, false
+	for _, line := range result.Lines {
+		if len(line.PV) == 0 {
+			continue
+		}
+		score := float64(line.Score) + rand.NormFloat64()*l.Noise
+		if !found || score > bestScore {
+			best, bestScore, found = line.PV[0], score, true
+		}
+	}
+	return best, nil
+}
@@ -0,0 +1,76 @@
+package bot
+
+import (
+	"context"
+	"testing"
+
+	"chess-backend/internal/chess"
+	"chess-backend/internal/engine"
+)
+
+var testSearcher = engine.NewPool(4)
+
+func TestChooseMoveAtEveryLevelIsLegal(t *testing.T) {
+	b := chess.NewBoard()
+	for level := MinLevel; level <= MaxLevel; level++ {
+		move, err := ChooseMove(context.Background(), testSearcher, b, level)
+		if err != nil {
+			t.Fatalf("level %d: %v", level, err)
+		}
+		if err := chess.ValidateMove(b, move); err != nil {
+			t.Fatalf("level %d chose illegal move %s: %v", level, move, err)
+		}
+	}
+	if b.ToFEN() != chess.StartingFEN {
+		t.Fatalf("ChooseMove modified the board: %q", b.ToFEN())
+	}
+}
+
+func TestStrongLevelsFindMate(t *testing.T) {
+	b, err := chess.LoadFEN("6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1")
+	if err != nil {
+		t.Fatalf("load fen: %v", err)
+	}
+	for level := 6; level <= MaxLevel; level++ {
+		move, err := ChooseMove(context.Background(), testSearcher, b, level)
+		if err != nil {
+			t.Fatalf("level %d: %v", level, err)
+		}
+		if move.UCI() != "a1a8" {
+			t.Fatalf("level %d missed mate in one, played %s", level, move.UCI())
+		}
+	}
+}
+
+func TestInvalidLevel(t *testing.T) {
+	for _, level := range []int{0, MaxLevel + 1} {
+		if _, err := ChooseMove(context.Background(), testSearcher, chess.NewBoard(), level); err != ErrInvalidLevel {
+			t.Fatalf("level %d: expected ErrInvalidLevel, got %v", level, err)
+		}
+	}
+}
+
+// fixedSearcher returns the same result for every search.
+type fixedSearcher engine.Result
+
+func (s fixedSearcher) Search(context.Context, *chess.Board, engine.Limits) (engine.Result, error) {
+	return engine.Result(s), nil
+}
+
+func TestChooseMoveSkipsEmptyLines(t *testing.T) {
+	want := chess.NewMove(chess.G1, chess.F3)
+	searcher := fixedSearcher{
+		Move: chess.NewMove(chess.E2, chess.E4),
+		Lines: []engine.Line{
+			{Score: 50},
+			{Score: -5000, PV: []chess.Move{want}},
+		},
+	}
+	move, err := ChooseMove(context.Background(), searcher, chess.NewBoard(), 1)
+	if err != nil {
+		t.Fatalf("ChooseMove error: %v", err)
+	}
+	if move != want {
+		t.Fatalf("expected the only line with a move, got %s", move.UCI())
+	}
+}
@@ -0,0 +1,62 @@
+package chess
+
+// Antichess, also known as Giveaway, is won by losing every piece or by
+// having no move. Captures are compulsory, there is no check and no castling,
+// the king is an ordinary piece, and pawns may also promote to kings.
+var Antichess Variant = antichess{}
+
+type antichess struct{}
+
+func (antichess) Name() string     { return "antichess" }
+func (antichess) Title() string    { return "Antichess" }
+func (antichess) NewBoard() *Board { return newVariantBoard(Antichess, StartingFEN) }
+
+// setup drops any castling rights the FEN gives.
+func (antichess) setup(b *Board) {
+	b.commonKings = true
+	b.castling = CastlingRights{}
+}
+
+func (antichess) FilterMoves(b *Board, pseudo []Move) []Move {
+	captures := make([]Move, 0, len(pseudo))
+	for _, m := range pseudo {
+		if b.isCapture(m) {
+			captures = append(captures, m)
+		}
+	}
+	legal := pseudo
+	if len(captures) > 0 {
+		legal = captures
+	}
+
+	moves := make([]Move, 0, len(legal)+4)
+	for _, m := range legal {
+		moves = append(moves, m)
+		if m.Promotion == Queen {
+			moves = append(moves, NewMoveWithPromotion(m.From, m.To, King))
+		}
+	}
+	return moves
+}
+
+func (antichess) Outcome(b *Board) (Outcome, bool) {
+	if b.bb.colors[b.turn] == 0 {
+		return win(b.turn, EndAllPiecesLost)
+	}
+	if len(b.LegalMoves()) == 0 {
+		return win(b.turn, EndStalemate)
+	}
+	return b.drawRuleOutcome()
+}
+
+// isCapture reports whether m takes a piece, en passant included.
+func (b *Board) isCapture(m Move) bool {
+	if m.IsDrop() {
+		return false
+	}
+	if p := b.PieceAt(m.To); p != nil {
+		return p.Color != b.turn
+	}
+	p := b.PieceAt(m.From)
+	return p.Type == Pawn && m.To == b.enPassent
+}
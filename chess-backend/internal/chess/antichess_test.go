@@ -0,0 +1,64 @@
+package chess
+
+import "testing"
+
+func TestAntichess_CompulsoryCaptures(t *testing.T) {
+	b := Antichess.NewBoard()
+	if got := b.ToFEN(); got != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w - - 0 1" {
+		t.Fatalf("expected no castling rights, got %s", got)
+	}
+
+	playUCI(t, b, "e2e4", "d7d5")
+	moves := b.LegalMoves()
+	if len(moves) != 1 || moves[0] != NewMove(E4, D5) {
+		t.Fatalf("expected exd5 to be forced, got %v", moves)
+	}
+	if err := ValidateMove(b, NewMove(G1, F3)); err == nil {
+		t.Fatalf("expected a quiet move to be illegal when a capture is available")
+	}
+
+	// the king can be captured and can walk into attack
+	b, _ = LoadVariantFEN(Antichess, "8/8/8/8/8/8/1r6/K7 w - - 0 1")
+	if err := ValidateMove(b, NewMove(A1, B2)); err != nil {
+		t.Fatalf("expected Kxb2, got %v", err)
+	}
+	if b.InCheck(White) {
+		t.Fatalf("expected no checks in Antichess")
+	}
+}
+
+func TestAntichess_Outcome(t *testing.T) {
+	// losing the last piece wins
+	b, _ := LoadVariantFEN(Antichess, "8/8/8/8/8/8/1r6/K7 w - - 0 1")
+	playUCI(t, b, "a1b2")
+	o, over := b.Outcome()
+	if !over || o.Winner != Black || o.Reason != EndAllPiecesLost {
+		t.Fatalf("expected Black to win with no pieces left, got %+v (%t)", o, over)
+	}
+
+	// so does having no move
+	b, _ = LoadVariantFEN(Antichess, "8/8/8/8/8/p7/P7/8 w - - 0 1")
+	o, over = b.Outcome()
+	if !over || o.Draw || o.Winner != White || o.Reason != EndStalemate {
+		t.Fatalf("expected the stalemated side to win, got %+v (%t)", o, over)
+	}
+
+	// pawns may promote to a king
+	b, _ = LoadVariantFEN(Antichess, "8/P7/8/8/8/8/8/7k w - - 0 1")
+	m, err := ParseUCI("a7a8k")
+	if err != nil {
+		t.Fatalf("ParseUCI error: %v", err)
+	}
+	if san, err := b.SAN(m); err != nil || san != "a8=K" {
+		t.Fatalf("expected a8=K, got %q (%v)", san, err)
+	}
+}
+
+func TestAntichess_Perft(t *testing.T) {
+	b := Antichess.NewBoard()
+	for depth, want := range []uint64{20, 400, 8067, 153299} {
+		if got := b.Perft(depth + 1); got != want {
+			t.Fatalf("perft(%d): expected %d, got %d", depth+1, want, got)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package chess
+
+// Atomic is chess in which every capture is an explosion: the capturing
+// piece, the captured piece and every piece other than a pawn next to the
+// capture square leave the board. Kings may not capture, and blowing up the
+// enemy king wins. Touching kings cannot check each other, since taking one
+// would blow up the other.
+var Atomic Variant = atomic{}
+
+type atomic struct{}
+
+func (atomic) Name() string     { return "atomic" }
+func (atomic) Title() string    { return "Atomic" }
+func (atomic) NewBoard() *Board { return newVariantBoard(Atomic, StartingFEN) }
+
+func (atomic) setup(b *Board) { b.explosions = true }
+
+func (atomic) FilterMoves(b *Board, pseudo []Move) []Move {
+	us := b.turn
+	legal := make([]Move, 0, len(pseudo))
+	for _, m := range pseudo {
+		p := b.PieceAt(m.From)
+		if p.Type == King && !b.isCastle(*p, m) && b.PieceAt(m.To) != nil {
+			continue
+		}
+		if b.isCastle(*p, m) {
+			v := KingValidator{}
+			if !v.isLegalCastle(b, m) {
+				continue
+			}
+		}
+
+		u := b.DoMove(m)
+		ok := b.bb.pieces[us][King] != 0 &&
+			(b.bb.pieces[us.Opposite()][King] == 0 || !b.InCheck(us))
+		b.UndoMove(u)
+		if ok {
+			legal = append(legal, m)
+		}
+	}
+	return legal
+}
+
+func (atomic) Outcome(b *Board) (Outcome, bool) {
+	for _, c := range [...]Color{White, Black} {
+		if b.bb.pieces[c][King] == 0 {
+			return win(c.Opposite(), EndKingExploded)
+		}
+	}
+	if o, ok := b.mateOutcome(); ok {
+		return o, ok
+	}
+	// with the kings alone nothing can explode
+	if b.bb.occupied() == b.bb.pieces[White][King]|b.bb.pieces[Black][King] {
+		return draw(EndInsufficientMaterial)
+	}
+	return b.drawRuleOutcome()
+}
+
+// placedPiece is a piece and the square it stood on.
+type placedPiece struct {
+	sq    Square
+	piece Piece
+}
+
+// explode clears the capturing piece on sq and the pieces other than pawns
+// around it, returning the neighbours it removed and clearing the castling
+// rights of any rook or king among them.
+func (b *Board) explode(sq Square) []placedPiece {
+	b.ClearSquare(sq)
+
+	var removed []placedPiece
+	for around := kingAttacks[sq] & b.bb.occupied(); around != 0; {
+		n := popLSB(&around)
+		p := *b.squares[n]
+		if p.Type == Pawn {
+			continue
+		}
+		removed = append(removed, placedPiece{n, p})
+		b.ClearSquare(n)
+		switch p.Type {
+		case King:
+			b.castling.clear(p.Color, castleKingside)
+			b.castling.clear(p.Color, castleQueenside)
+		case Rook:
+			b.clearCastlingRook(n)
+		}
+	}
+	return removed
+}
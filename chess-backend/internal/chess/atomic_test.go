@@ -0,0 +1,63 @@
+package chess
+
+import "testing"
+
+func TestAtomic_Explosion(t *testing.T) {
+	// Nxc5 blows up the knight, the queen and the bishop beside it, but
+	// not the pawns on b5 and c6
+	b, err := LoadVariantFEN(Atomic, "4k3/8/1bp5/1pq5/8/3N4/8/4K3 w - - 0 1")
+	if err != nil {
+		t.Fatalf("LoadVariantFEN error: %v", err)
+	}
+	hash, fen := b.Hash(), b.ToFEN()
+	playUCI(t, b, "d3c5")
+	if got := b.ToFEN(); got != "4k3/8/2p5/1p6/8/8/8/4K3 b - - 0 1" {
+		t.Fatalf("unexpected position after the explosion: %s", got)
+	}
+	if err := b.UnmakeMove(); err != nil {
+		t.Fatalf("UnmakeMove error: %v", err)
+	}
+	if b.ToFEN() != fen || b.Hash() != hash {
+		t.Fatalf("expected unmake to restore the exploded pieces, got %s", b.ToFEN())
+	}
+}
+
+func TestAtomic_KingRules(t *testing.T) {
+	// the king may not capture, and a capture next to its own king is illegal
+	b, err := LoadVariantFEN(Atomic, "4k3/8/8/8/8/8/3p4/3RK3 w - - 0 1")
+	if err != nil {
+		t.Fatalf("LoadVariantFEN error: %v", err)
+	}
+	for _, uci := range []string{"e1d2", "d1d2"} {
+		m, _ := ParseUCI(uci)
+		if err := ValidateMove(b, m); err == nil {
+			t.Fatalf("expected %s to be illegal", uci)
+		}
+	}
+
+	// touching kings are never in check
+	b, _ = LoadVariantFEN(Atomic, "8/8/8/8/8/3k4/3K4/3r4 w - - 0 1")
+	if b.InCheck(White) {
+		t.Fatalf("expected touching kings to cancel the rook's check")
+	}
+
+	// blowing up the king wins, even out of check
+	b, _ = LoadVariantFEN(Atomic, "3qk3/4n3/8/8/8/8/8/3QK2r w - - 0 1")
+	if err := ValidateMove(b, NewMove(D1, D8)); err != nil {
+		t.Fatalf("expected Qxd8 to be legal while in check, got %v", err)
+	}
+	playUCI(t, b, "d1d8")
+	o, over := b.Outcome()
+	if !over || o.Winner != White || o.Reason != EndKingExploded {
+		t.Fatalf("expected White to win by the explosion, got %+v (%t)", o, over)
+	}
+}
+
+func TestAtomic_Perft(t *testing.T) {
+	b := Atomic.NewBoard()
+	for depth, want := range []uint64{20, 400, 8902, 197326} {
+		if got := b.Perft(depth + 1); got != want {
+			t.Fatalf("perft(%d): expected %d, got %d", depth+1, want, got)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package chess
+
+import "math/bits"
+
+// Bitboards use the same square numbering as Square (file*8 + rank), so bit n
+// is set when square n is occupied. Moving one rank up is a shift by 1 and one
+// file right is a shift by 8.
+
+var (
+	knightAttacks [64]uint64
+	kingAttacks   [64]uint64
+	pawnAttacks   [2][64]uint64 // squares a pawn of the given colour attacks
+
+	// lines through each square, excluding the square itself, for sliders
+	fileMasks     [64]uint64
+	rankMasks     [64]uint64
+	diagMasks     [64]uint64
+	antiDiagMasks [64]uint64
+)
+
+func init() {
+	for sq := A1; sq <= H8; sq++ {
+		f, r := sq.File(), sq.Rank()
+
+		knightAttacks[sq] = offsetMask(f, r, [][2]int{
+			{2, 1}, {2, -1}, {-2, 1}, {-2, -1},
+			{1, 2}, {1, -2}, {-1, 2}, {-1, -2},
+		})
+		kingAttacks[sq] = offsetMask(f, r, [][2]int{
+			{-1, -1}, {-1, 0}, {-1, 1}, {0, -1},
+			{0, 1}, {1, -1}, {1, 0}, {1, 1},
+		})
+		pawnAttacks[White][sq] = offsetMask(f, r, [][2]int{{-1, 1}, {1, 1}})
+		pawnAttacks[Black][sq] = offsetMask(f, r, [][2]int{{-1, -1}, {1, -1}})
+
+		fileMasks[sq] = rayMask(f, r, 0, 1) | rayMask(f, r, 0, -1)
+		rankMasks[sq] = rayMask(f, r, 1, 0) | rayMask(f, r, -1, 0)
+		diagMasks[sq] = rayMask(f, r, 1, 1) | rayMask(f, r, -1, -1)
+		antiDiagMasks[sq] = rayMask(f, r, 1, -1) | rayMask(f, r, -1, 1)
+	}
+}
+
+func squareBB(sq Square) uint64 {
+	return 1 << uint(sq)
+}
+
+func offsetMask(f, r int, offsets [][2]int) uint64 {
+	var bb uint64
+	for _, o := range offsets {
+		nf, nr := f+o[0], r+o[1]
+		if nf >= 0 && nf <= 7 && nr >= 0 && nr <= 7 {
+			bb |= squareBB(Square(nf*8 + nr))
+		}
+	}
+	return bb
+}
+
+func rayMask(f, r, df, dr int) uint64 {
+	var bb uint64
+	for f, r = f+df, r+dr; f >= 0 && f <= 7 && r >= 0 && r <= 7; f, r = f+df, r+dr {
+		bb |= squareBB(Square(f*8 + r))
+	}
+	return bb
+}
+
+// lineAttacks finds the squares a slider on sq reaches along one line using
+// hyperbola quintessence: subtracting the slider from the blockers flips bits
+// up to the first blocker, and doing the same on the bit-reversed board
+// handles the other direction.
+func lineAttacks(sq Square, occupied, mask uint64) uint64 {
+	s := squareBB(sq)
+	o := occupied & mask
+	forward := o - 2*s
+	reverse := bits.Reverse64(bits.Reverse64(o) - 2*bits.Reverse64(s))
+	return (forward ^ reverse) & mask
+}
+
+func bishopAttacks(sq Square, occupied uint64) uint64 {
+	return lineAttacks(sq, occupied, diagMasks[sq]) | lineAttacks(sq, occupied, antiDiagMasks[sq])
+}
+
+func rookAttacks(sq Square, occupied uint64) uint64 {
+	return lineAttacks(sq, occupied, fileMasks[sq]) | lineAttacks(sq, occupied, rankMasks[sq])
+}
+
+func queenAttacks(sq Square, occupied uint64) uint64 {
+	return bishopAttacks(sq, occupied) | rookAttacks(sq, occupied)
+}
+
+// popLSB removes and returns the lowest set square of *bb.
+func popLSB(bb *uint64) Square {
+	sq := Square(bits.TrailingZeros64(*bb))
+	*bb &= *bb - 1
+	return sq
+}
+
+// bitboards is the piece placement on its own, cheap to copy when checking
+// whether a move would leave the king in check.
+type bitboards struct {
+	pieces [2][6]uint64 // indexed by Color and PieceType
+	colors [2]uint64
+}
+
+func (bb *bitboards) occupied() uint64 {
+	return bb.colors[White] | bb.colors[Black]
+}
+
+func (bb *bitboards) put(sq Square, p Piece) {
+	mask := squareBB(sq)
+	bb.pieces[p.Color][p.Type] |= mask
+	bb.colors[p.Color] |= mask
+}
+
+func (bb *bitboards) remove(sq Square, p Piece) {
+	mask := ^squareBB(sq)
+	bb.pieces[p.Color][p.Type] &= mask
+	bb.colors[p.Color] &= mask
+}
+
+// isAttacked reports whether any piece of byColor attacks target, given the
+// occupancy. Slider attacks are only computed when a slider shares a line.
+func (bb *bitboards) isAttacked(target Square, byColor Color, occupied uint64) bool {
+	p := &bb.pieces[byColor]
+	if pawnAttacks[byColor.Opposite()][target]&p[Pawn] != 0 ||
+		knightAttacks[target]&p[Knight] != 0 ||
+		kingAttacks[target]&p[King] != 0 {
+		return true
+	}
+	if diagonal := p[Bishop] | p[Queen]; diagonal&(diagMasks[target]|antiDiagMasks[target]) != 0 &&
+		bishopAttacks(target, occupied)&diagonal != 0 {
+		return true
+	}
+	straight := p[Rook] | p[Queen]
+	return straight&(fileMasks[target]|rankMasks[target]) != 0 && rookAttacks(target, occupied)&straight != 0
+}
+
+func (bb *bitboards) kingSquare(color Color) Square {
+	kings := bb.pieces[color][King]
+	if kings == 0 {
+		return NoSquare
+	}
+	return Square(bits.TrailingZeros64(kings))
+}
@@ -0,0 +1,73 @@
+package chess
+
+import "testing"
+
+func squaresOf(bb uint64) map[Square]bool {
+	out := map[Square]bool{}
+	for bb != 0 {
+		out[popLSB(&bb)] = true
+	}
+	return out
+}
+
+func TestSliderAttacksStopAtBlockers(t *testing.T) {
+	occupied := squareBB(D4) | squareBB(D6) | squareBB(B4) | squareBB(F6) | squareBB(C3)
+
+	rook := squaresOf(rookAttacks(D4, occupied))
+	for _, sq := range []Square{D5, D6, D3, D2, D1, C4, B4, E4, F4, G4, H4} {
+		if !rook[sq] {
+			t.Fatalf("expected rook on d4 to reach %s", sq)
+		}
+	}
+	if rook[D7] || rook[A4] || len(rook) != 11 {
+		t.Fatalf("rook attacks should stop at the first blocker, got %v", rook)
+	}
+
+	bishop := squaresOf(bishopAttacks(D4, occupied))
+	for _, sq := range []Square{E5, F6, C5, B6, A7, E3, F2, G1, C3} {
+		if !bishop[sq] {
+			t.Fatalf("expected bishop on d4 to reach %s", sq)
+		}
+	}
+	if bishop[G7] || bishop[B2] || len(bishop) != 9 {
+		t.Fatalf("bishop attacks should stop at the first blocker, got %v", bishop)
+	}
+}
+
+func TestLeaperTablesStayOnBoard(t *testing.T) {
+	if got := len(squaresOf(knightAttacks[A1])); got != 2 {
+		t.Fatalf("expected 2 knight moves from a1, got %d", got)
+	}
+	if got := len(squaresOf(kingAttacks[H8])); got != 3 {
+		t.Fatalf("expected 3 king moves from h8, got %d", got)
+	}
+	if got := squaresOf(pawnAttacks[White][A2]); len(got) != 1 || !got[B3] {
+		t.Fatalf("expected a2 pawn to attack only b3, got %v", got)
+	}
+	if got := squaresOf(pawnAttacks[Black][H7]); len(got) != 1 || !got[G6] {
+		t.Fatalf("expected h7 pawn to attack only g6, got %v", got)
+	}
+}
+
+func TestBitboardsTrackMoves(t *testing.T) {
+	b := NewBoard()
+	for _, uci := range []string{"e2e4", "d7d5", "e4d5", "g8f6", "f1b5", "c7c6", "d5c6", "d8d2", "b1d2"} {
+		move, err := ParseUCI(uci)
+		if err != nil {
+			t.Fatalf("parse %s: %v", uci, err)
+		}
+		if err := b.MakeMove(move); err != nil {
+			t.Fatalf("make %s: %v", uci, err)
+		}
+
+		var want bitboards
+		for sq := A1; sq <= H8; sq++ {
+			if p := b.PieceAt(sq); p != nil {
+				want.put(sq, *p)
+			}
+		}
+		if b.bb != want {
+			t.Fatalf("bitboards out of sync with the mailbox after %s", uci)
+		}
+	}
+}
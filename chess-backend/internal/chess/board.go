@@ -1,14 +1,62 @@
 package chess
 
 type Board struct {
-	squares   [64]*Piece
+	squares   [64]*Piece // mailbox view of bb for PieceAt; entries point into pieceRefs
+	bb        bitboards
 	turn      Color
 	castling  CastlingRights
 	enPassent Square
 	halfMove  int
 	fullMove  int
 
-	// positionCounts: make(map[string]int), #TODO: implement threefold repition draw
+	// chess960 boards castle with rooks on any file, written as the king
+	// taking its own rook; rookFiles[color][side] are those rooks' files.
+	// Standard boards keep the h and a files and move the king two squares.
+	chess960  bool
+	rookFiles [2][2]int
+
+	// variant is nil for orthodox rules, Standard or Chess960;
+	// variantKey keeps its positions apart from orthodox ones in the hash
+	variant    Variant
+	variantKey uint64
+	// checks counts the checks each side has given, for Three-check;
+	// countChecks turns the counting on
+	checks      [2]int
+	countChecks bool
+	// pockets hold the pieces each side has captured, for Crazyhouse, and
+	// promoted marks the squares of promoted pieces, which go back to the
+	// pocket as pawns; hasPockets turns both on
+	pockets    [2]Pocket
+	promoted   uint64
+	hasPockets bool
+	// explosions makes captures blow up the neighbouring pieces, for
+	// Atomic; commonKings makes kings ordinary pieces that are never in
+	// check, for Antichess; horde lets White play without a king
+	explosions  bool
+	commonKings bool
+	horde       bool
+
+	hash    uint64   // zobrist key of the current position
+	history []uint64 // zobrist keys of every earlier position, oldest first
+	undo    []Undo   // moves made with MakeMove, for UnmakeMove
+}
+
+// Undo is what DoMove cannot recompute from the resulting position. It is only
+// meaningful to UndoMove on the board that produced it.
+type Undo struct {
+	move       Move
+	piece      Piece // the moving piece before any promotion
+	captured   Piece
+	capturedSq Square // NoSquare when nothing was captured
+	castle     bool
+	castling   CastlingRights
+	checks     [2]int
+	promoted   uint64
+	exploded   []placedPiece // pieces an Atomic capture blew up, besides the mover
+	enPassent  Square
+	halfMove   int
+	fullMove   int
+	hash       uint64
 }
 
 type CastlingRights struct {
@@ -23,6 +71,7 @@ func newEmptyBoard(turn Color) *Board {
 		squares:   [64]*Piece{},
 		turn:      turn,
 		enPassent: NoSquare,
+		rookFiles: standardRookFiles,
 	}
 	return b
 }
@@ -39,8 +88,10 @@ func NewBoard() *Board {
 		enPassent: NoSquare,
 		halfMove:  0,
 		fullMove:  1,
+		rookFiles: standardRookFiles,
 	}
 	b.setupStartingPosition()
+	b.hash = b.computeHash()
 	return b
 }
 
@@ -75,16 +126,46 @@ func (b *Board) setupStartingPosition() {
 	}
 }
 
+// pieceRefs holds one shared, never-modified Piece per colour and type so the
+// mailbox does not allocate on every move.
+var pieceRefs = func() (refs [2][6]Piece) {
+	for c := range refs {
+		for t := range refs[c] {
+			refs[c][t] = NewPiece(PieceType(t), Color(c))
+		}
+	}
+	return refs
+}()
+
 func (b *Board) setPiece(sq Square, p Piece) {
-	b.squares[sq] = &p
+	if old := b.squares[sq]; old != nil {
+		b.hash ^= zobristPiece(*old, sq)
+		b.bb.remove(sq, *old)
+	}
+	b.squares[sq] = &pieceRefs[p.Color][p.Type]
+	b.bb.put(sq, p)
+	b.hash ^= zobristPiece(p, sq)
 }
 
 func (b *Board) ClearSquare(sq Square) {
 	if sq.isValid() {
+		if old := b.squares[sq]; old != nil {
+			b.hash ^= zobristPiece(*old, sq)
+			b.bb.remove(sq, *old)
+		}
 		b.squares[sq] = nil
 	}
 }
 
+func (b *Board) movePiece(from, to Square) {
+	p := b.squares[from]
+	if p == nil {
+		return
+	}
+	b.ClearSquare(from)
+	b.setPiece(to, *p)
+}
+
 func (b *Board) PieceAt(sq Square) *Piece {
 	if !sq.isValid() {
 		return nil
@@ -105,70 +186,125 @@ func (b *Board) MakeMove(move Move) error {
 	if err := ValidateMove(b, move); err != nil {
 		return err
 	}
+	b.undo = append(b.undo, b.DoMove(move))
+	return nil
+}
 
-	piece := b.PieceAt(move.From)
-	if piece == nil {
-		return ErrNoMoveablePiece
+// ReplayMove plays a move that was validated when it was first made, such as
+// one read back from storage, without validating it again. Unlike DoMove it
+// is recorded for UnmakeMove. It only checks that the side to move has the
+// piece to play, so a corrupt move is an error rather than a panic.
+func (b *Board) ReplayMove(move Move) error {
+	if move.IsDrop() {
+		if err := validateDrop(b, move); err != nil {
+			return err
+		}
+	} else {
+		if !move.From.isValid() || !move.To.isValid() {
+			return ErrInvalidSquare
+		}
+		p := b.squares[move.From]
+		if p == nil {
+			return ErrNoMoveablePiece
+		}
+		if p.Color != b.turn {
+			return ErrWrongTurn
+		}
 	}
+	b.undo = append(b.undo, b.DoMove(move))
+	return nil
+}
 
-	capturedPiece := b.PieceAt(move.To)
-	prevEnPassent := b.enPassent
+// UnmakeMove takes back the last move made with MakeMove, restoring any
+// captured piece, castling rights, the en passant square and both counters.
+func (b *Board) UnmakeMove() error {
+	if len(b.undo) == 0 {
+		return ErrNoMoveToUnmake
+	}
+	u := b.undo[len(b.undo)-1]
+	b.undo = b.undo[:len(b.undo)-1]
+	b.UndoMove(u)
+	return nil
+}
 
-	if piece.Type == Pawn && move.To == prevEnPassent && capturedPiece == nil {
-		capturedSq := Square(move.To.File()*8 + move.From.Rank())
+// DoMove plays move without validating it and returns what UndoMove needs to
+// restore the position exactly. The move must be at least pseudo-legal, e.g.
+// one taken from LegalMoves or PseudoLegalMoves; search code pairs every
+// DoMove with an UndoMove instead of cloning the board.
+//
+// Moves played with DoMove are not recorded for UnmakeMove.
+func (b *Board) DoMove(move Move) Undo {
+	if move.IsDrop() {
+		return b.doDrop(move)
+	}
+
+	piece := *b.squares[move.From]
+	castle := b.isCastle(piece, move)
+
+	undo := Undo{
+		move:       move,
+		piece:      piece,
+		capturedSq: NoSquare,
+		castle:     castle,
+		castling:   b.castling,
+		checks:     b.checks,
+		promoted:   b.promoted,
+		enPassent:  b.enPassent,
+		halfMove:   b.halfMove,
+		fullMove:   b.fullMove,
+		hash:       b.hash,
+	}
+
+	b.history = append(b.history, b.hash)
+	b.hash ^= b.zobristStateKey()
+
+	var capturedPiece *Piece
+	capturedSq := move.To
+	if !castle {
+		// a Chess960 castling move lands on the king's own rook
+		capturedPiece = b.PieceAt(move.To)
+	}
+
+	if piece.Type == Pawn && move.To == b.enPassent && capturedPiece == nil {
+		capturedSq = Square(move.To.File()*8 + move.From.Rank())
 		capturedPiece = b.PieceAt(capturedSq)
 		b.ClearSquare(capturedSq)
 	}
-
-	if capturedPiece != nil && capturedPiece.Type == Rook {
-		switch move.To {
-		case A1:
-			b.castling.WhiteQueenside = false
-		case H1:
-			b.castling.WhiteKingside = false
-		case A8:
-			b.castling.BlackQueenside = false
-		case H8:
-			b.castling.BlackKingside = false
+	if capturedPiece != nil {
+		undo.captured = *capturedPiece
+		undo.capturedSq = capturedSq
+		if b.hasPockets {
+			b.pocketCapture(piece.Color, *capturedPiece, capturedSq)
 		}
 	}
 
-	isCastle := piece.Type == King && abs(move.To.File()-move.From.File()) == 2 && move.From.Rank() == move.To.Rank()
-
-	b.squares[move.To] = b.squares[move.From]
-	b.ClearSquare(move.From)
-
-	if isCastle {
-		rank := move.To.Rank()
-		if move.To.File() == 6 { // kingside
-			rookFrom := Square(7*8 + rank) // H1/H8
-			rookTo := Square(5*8 + rank)   // F1/F8
-			rook := b.PieceAt(rookFrom)
-			if rook == nil || rook.Type != Rook || rook.Color != piece.Color {
-				return ErrIllegalMove
-			}
-			b.squares[rookTo] = b.squares[rookFrom]
-			b.ClearSquare(rookFrom)
-		}
+	if capturedPiece != nil && capturedPiece.Type == Rook {
+		b.clearCastlingRook(capturedSq)
+	}
 
-		if move.To.File() == 2 { // queenside
-			rookFrom := Square(0*8 + rank) // A1/A8
-			rookTo := Square(3*8 + rank)   // D1/D8
-			rook := b.PieceAt(rookFrom)
-			if rook == nil || rook.Type != Rook || rook.Color != piece.Color {
-				return ErrIllegalMove
-			}
-			b.squares[rookTo] = b.squares[rookFrom]
-			b.ClearSquare(rookFrom)
-		}
+	if castle {
+		// both pieces leave before either lands: in Chess960 the king may
+		// land where the rook stood or the other way round
+		kingTo, rookFrom, rookTo := b.castleSquares(move, piece.Color)
+		b.ClearSquare(move.From)
+		b.ClearSquare(rookFrom)
+		b.setPiece(kingTo, piece)
+		b.setPiece(rookTo, NewPiece(Rook, piece.Color))
+	} else {
+		b.movePiece(move.From, move.To)
 	}
 
 	if move.isPromotion() {
-		promotedPiece := NewPiece(move.Promotion, piece.Color)
-		b.setPiece(move.To, promotedPiece)
+		b.setPiece(move.To, NewPiece(move.Promotion, piece.Color))
+	}
+	if b.hasPockets && (move.isPromotion() || b.promoted&squareBB(move.From) != 0) {
+		b.promoted = b.promoted&^squareBB(move.From) | squareBB(move.To)
+	}
+	if b.explosions && capturedPiece != nil {
+		undo.exploded = b.explode(move.To)
 	}
 
-	b.UpdateCastlingRights(move, piece)
+	b.UpdateCastlingRights(move, &piece)
 
 	if piece.Type == Pawn || capturedPiece != nil {
 		b.halfMove = 0
@@ -176,7 +312,9 @@ func (b *Board) MakeMove(move Move) error {
 		b.halfMove++
 	}
 
-	if piece.Type == Pawn && abs(move.From.Rank()-move.To.Rank()) == 2 {
+	// a double step from the first rank, as in Horde, passes no square a
+	// pawn could take en passant
+	if piece.Type == Pawn && abs(move.From.Rank()-move.To.Rank()) == 2 && move.From.Rank() != homeRank(piece.Color) {
 		direction := 1
 		if piece.Color == Black {
 			direction = -1
@@ -186,74 +324,120 @@ func (b *Board) MakeMove(move Move) error {
 		b.enPassent = NoSquare
 	}
 
+	b.endMove()
+	return undo
+}
+
+// endMove hands the move to the other side once the pieces have moved.
+func (b *Board) endMove() {
 	if b.turn == Black {
 		b.fullMove++
 	}
 
 	b.turn = b.turn.Opposite()
+	if b.countChecks && b.InCheck(b.turn) {
+		b.checks[b.turn.Opposite()]++
+	}
+	b.hash ^= b.zobristStateKey()
+}
 
-	return nil
+// UndoMove reverses the DoMove that returned u. Undos must be applied in the
+// reverse order of the moves they came from.
+func (b *Board) UndoMove(u Undo) {
+	move := u.move
+
+	if move.IsDrop() {
+		b.ClearSquare(move.To)
+		b.pockets[u.piece.Color][u.piece.Type]++
+	} else if u.castle {
+		kingTo, rookFrom, rookTo := b.castleSquares(move, u.piece.Color)
+		b.ClearSquare(kingTo)
+		b.ClearSquare(rookTo)
+		b.setPiece(move.From, u.piece)
+		b.setPiece(rookFrom, NewPiece(Rook, u.piece.Color))
+	} else {
+		b.ClearSquare(move.To)
+		b.setPiece(move.From, u.piece)
+	}
+	for _, p := range u.exploded {
+		b.setPiece(p.sq, p.piece)
+	}
+
+	if u.capturedSq != NoSquare {
+		b.setPiece(u.capturedSq, u.captured)
+		if b.hasPockets {
+			b.pockets[u.piece.Color][pocketType(u.captured, u.promoted&squareBB(u.capturedSq) != 0)]--
+		}
+	}
+
+	b.turn = b.turn.Opposite()
+	b.promoted = u.promoted
+	b.castling = u.castling
+	b.checks = u.checks
+	b.enPassent = u.enPassent
+	b.halfMove = u.halfMove
+	b.fullMove = u.fullMove
+	b.hash = u.hash
+	if len(b.history) > 0 {
+		b.history = b.history[:len(b.history)-1]
+	}
 }
 
 func (b *Board) UpdateCastlingRights(move Move, piece *Piece) {
 
 	if piece.Type == King {
-		if piece.Color == White {
-			b.castling.WhiteKingside = false
-			b.castling.WhiteQueenside = false
-		} else {
-			b.castling.BlackKingside = false
-			b.castling.BlackQueenside = false
-		}
-
+		b.castling.clear(piece.Color, castleKingside)
+		b.castling.clear(piece.Color, castleQueenside)
 		return
 	}
 
-	switch move.From {
-	case A1:
-		b.castling.WhiteQueenside = false
-	case H1:
-		b.castling.WhiteKingside = false
-	case A8:
-		b.castling.BlackQueenside = false
-	case H8:
-		b.castling.BlackKingside = false
-	}
+	b.clearCastlingRook(move.From)
 }
 
 func (b *Board) Clone() *Board {
 	nb := &Board{
-		turn:      b.turn,
-		castling:  b.castling,
-		enPassent: b.enPassent,
-		halfMove:  b.halfMove,
-		fullMove:  b.fullMove,
-	}
-
-	for i := range b.squares {
-		if b.squares[i] != nil {
-			p := *b.squares[i]
-			nb.squares[i] = &p
-		}
+		squares:     b.squares,
+		bb:          b.bb,
+		turn:        b.turn,
+		castling:    b.castling,
+		enPassent:   b.enPassent,
+		halfMove:    b.halfMove,
+		fullMove:    b.fullMove,
+		chess960:    b.chess960,
+		rookFiles:   b.rookFiles,
+		variant:     b.variant,
+		variantKey:  b.variantKey,
+		checks:      b.checks,
+		countChecks: b.countChecks,
+		pockets:     b.pockets,
+		promoted:    b.promoted,
+		hasPockets:  b.hasPockets,
+		explosions:  b.explosions,
+		commonKings: b.commonKings,
+		horde:       b.horde,
+		hash:        b.hash,
+		history:     append([]uint64(nil), b.history...),
+		undo:        append([]Undo(nil), b.undo...),
 	}
 	return nb
 }
 
 func (b *Board) findKingSquare(color Color) Square {
-
-	for sq := A1; sq <= H8; sq++ {
-		p := b.PieceAt(sq)
-		if p != nil && p.Type == King && p.Color == color {
-			return sq
-		}
-	}
-	return NoSquare
+	return b.bb.kingSquare(color)
 }
 
 func (b *Board) InCheck(color Color) bool {
+	if b.commonKings {
+		return false
+	}
 	kingSq := b.findKingSquare(color)
 	if kingSq == NoSquare {
-		return true // if king missing by any chance, treat as "Check"
+		// White plays without a king in Horde; anywhere else treat a missing
+		// king as "Check"
+		return !b.horde
+	}
+	if b.explosions && kingAttacks[kingSq]&b.bb.pieces[color.Opposite()][King] != 0 {
+		return false // capturing a touching king would blow up its own
 	}
 	return b.IsSquareAttacked(kingSq, color.Opposite())
 }
@@ -262,149 +446,15 @@ func (b *Board) IsSquareAttacked(target Square, byColor Color) bool {
 	if !target.isValid() {
 		return false
 	}
-
-	tf := target.File()
-	tr := target.Rank()
-
-	// pawn
-	{
-		dir := 1
-		if byColor == Black {
-			dir = -1
-		}
-		attackersRank := tr - dir
-		if attackersRank >= 0 && attackersRank <= 7 {
-			for _, df := range []int{-1, 1} { //df delta file
-				af := tf + df //af attackers file
-				if af < 0 || af > 7 {
-					continue
-				}
-				sq := Square(af*8 + attackersRank)
-				p := b.PieceAt(sq)
-				if p != nil && p.Color == byColor && p.Type == Pawn {
-					return true
-				}
-			}
-		}
-	}
-
-	//knight
-	{
-		offsets := [8][2]int{
-			{2, 1}, {2, -1}, {-2, 1}, {-2, -1},
-			{1, 2}, {1, -2}, {-1, 2}, {-1, -2},
-		}
-		for _, offset := range offsets {
-			af := tf + offset[0]
-			ar := tr + offset[1]
-			if af < 0 || af > 7 || ar < 0 || ar > 7 {
-				continue
-			}
-			sq := Square(af*8 + ar)
-			p := b.PieceAt(sq)
-			if p != nil && p.Color == byColor && p.Type == Knight {
-				return true
-			}
-		}
-	}
-
-	//king
-	{
-		for df := -1; df <= 1; df++ {
-			for dr := -1; dr <= 1; dr++ {
-				if df == 0 && dr == 0 {
-					continue
-				}
-				af := tf + df
-				ar := tr + dr
-				if af < 0 || af > 7 || ar < 0 || ar > 7 {
-					continue
-				}
-				sq := Square(af*8 + ar)
-				p := b.PieceAt(sq)
-				if p != nil && p.Color == byColor && p.Type == King {
-					return true
-				}
-			}
-		}
-	}
-
-	// sliding attacks
-	if b.isRayAttackedBySlider(target, byColor, StraightDirections, map[PieceType]bool{Rook: true, Queen: true}) {
-		return true
-	}
-	if b.isRayAttackedBySlider(target, byColor, DiagonalDirections, map[PieceType]bool{Bishop: true, Queen: true}) {
-		return true
-	}
-
-	return false
-}
-
-func (b *Board) isRayAttackedBySlider(target Square, byColor Color, dirs []Direction, allowed map[PieceType]bool) bool {
-
-	tf := target.File()
-	tr := target.Rank()
-	for _, dir := range dirs {
-		f := tf + dir.FileStep
-		r := tr + dir.RankStep
-		for f >= 0 && f <= 7 && r >= 0 && r <= 7 {
-			sq := Square(f*8 + r)
-			p := b.PieceAt(sq)
-			if p != nil {
-				if p.Color == byColor && allowed[p.Type] {
-					return true
-				}
-				break // blocked by first piece encountered
-			}
-			f += dir.FileStep
-			r += dir.RankStep
-		}
-	}
-
-	return false
+	return b.bb.isAttacked(target, byColor, b.bb.occupied())
 }
 
-func (b *Board) applyMoveNoValidate(move Move) {
-
-	piece := b.PieceAt(move.From)
-	if piece == nil {
-		return
-	}
-	captured := b.PieceAt(move.To)
-	if piece.Type == Pawn && move.To == b.enPassent && captured == nil {
-		capturedSq := Square(move.To.File()*8 + move.From.Rank())
-		b.ClearSquare(capturedSq)
-	}
-
-	if piece.Type == King && abs(move.To.File()-move.From.File()) == 2 && move.From.Rank() == move.To.Rank() {
-		rank := move.From.Rank()
-
-		// kingside
-		if move.To.File() == 6 {
-			rookFrom := Square(7*8 + rank)
-			rookTo := Square(5*8 + rank)
-			b.squares[rookTo] = b.squares[rookFrom]
-			b.ClearSquare(rookFrom)
-		}
-
-		// queenside
-		if move.To.File() == 2 {
-			rookFrom := Square(0*8 + rank)
-			rookTo := Square(3*8 + rank)
-			b.squares[rookTo] = b.squares[rookFrom]
-			b.ClearSquare(rookFrom)
-		}
-	}
-
-	b.squares[move.To] = b.squares[move.From]
-	b.ClearSquare(move.From)
-
-	if move.isPromotion() {
-		promoted := NewPiece(move.Promotion, piece.Color)
-		b.setPiece(move.To, promoted)
-	}
-
-	// king-safety simulation; not needed to update:
-	// castling rights, halfmove/fullmove, enPassent, etc...
-	// those will be for full engine state. Here, checking only whether would king be in check after 'this' move
+// leavesKingInCheck plays move and reports whether the mover's king is
+// attacked afterwards, restoring the board before it returns.
+func (b *Board) leavesKingInCheck(move Move) bool {
+	mover := b.turn
+	u := b.DoMove(move)
+	inCheck := b.InCheck(mover)
+	b.UndoMove(u)
+	return inCheck
 }
@@ -7,3 +7,14 @@ func (b *Board) HalfMove() int {
 func (b *Board) FullMove() int {
 	return b.fullMove
 }
+
+// Hash returns the Zobrist key of the current position.
+func (b *Board) Hash() uint64 {
+	return b.hash
+}
+
+// Chess960 reports whether the board follows Chess960 castling rules, where
+// castling moves are written as the king taking its own rook.
+func (b *Board) Chess960() bool {
+	return b.chess960
+}
@@ -0,0 +1,64 @@
+package chess
+
+import "testing"
+
+// kiwipete is a standard move generation stress position: castling both ways,
+// en passant, promotions and plenty of pins.
+const kiwipeteFEN = "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+
+func benchmarkBoard(b *testing.B, fen string) *Board {
+	b.Helper()
+	board, err := LoadFEN(fen)
+	if err != nil {
+		b.Fatalf("load fen: %v", err)
+	}
+	return board
+}
+
+func BenchmarkLegalMovesStart(b *testing.B) {
+	board := NewBoard()
+	b.ReportAllocs()
+	for b.Loop() {
+		board.LegalMoves()
+	}
+}
+
+func BenchmarkLegalMovesKiwipete(b *testing.B) {
+	board := benchmarkBoard(b, kiwipeteFEN)
+	b.ReportAllocs()
+	for b.Loop() {
+		board.LegalMoves()
+	}
+}
+
+func BenchmarkPseudoLegalMovesKiwipete(b *testing.B) {
+	board := benchmarkBoard(b, kiwipeteFEN)
+	b.ReportAllocs()
+	for b.Loop() {
+		board.PseudoLegalMoves()
+	}
+}
+
+func BenchmarkIsSquareAttacked(b *testing.B) {
+	board := benchmarkBoard(b, kiwipeteFEN)
+	b.ReportAllocs()
+	for b.Loop() {
+		for sq := A1; sq <= H8; sq++ {
+			board.IsSquareAttacked(sq, Black)
+		}
+	}
+}
+
+func BenchmarkMakeMove(b *testing.B) {
+	board := benchmarkBoard(b, kiwipeteFEN)
+	moves := board.LegalMoves()
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, m := range moves {
+			sim := board.Clone()
+			if err := sim.MakeMove(m); err != nil {
+				b.Fatalf("make %s: %v", m, err)
+			}
+		}
+	}
+}
@@ -75,3 +75,125 @@ func TestMakeMove_CaptureRook_DisablesCastlingRights(t *testing.T) {
 		t.Fatalf("expected white kingside castling right to remain true")
 	}
 }
+
+func TestUnmakeMove_RestoresPosition(t *testing.T) {
+	cases := []struct {
+		name string
+		fen  string
+		move Move
+	}{
+		{"quiet", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 3 10", NewMove(A1, A5)},
+		{"kingside castle", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 3 10", NewMove(E1, G1)},
+		{"queenside castle", "r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 3 10", NewMove(E8, C8)},
+		{"rook capture", "r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 3 10", NewMove(A1, A8)},
+		{"en passant", "4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 2", NewMove(E5, D6)},
+		{"capture promotion", "1n2k3/P7/8/8/8/8/8/4K3 w - - 0 1", NewMoveWithPromotion(A7, B8, Queen)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := LoadFEN(tc.fen)
+			if err != nil {
+				t.Fatalf("load fen: %v", err)
+			}
+			hash := b.Hash()
+			if err := b.MakeMove(tc.move); err != nil {
+				t.Fatalf("make move: %v", err)
+			}
+			if err := b.UnmakeMove(); err != nil {
+				t.Fatalf("unmake move: %v", err)
+			}
+			if got := b.ToFEN(); got != tc.fen {
+				t.Fatalf("expected %q after unmake, got %q", tc.fen, got)
+			}
+			if b.Hash() != hash || b.Hash() != b.computeHash() {
+				t.Fatalf("hash not restored")
+			}
+		})
+	}
+}
+
+func TestUnmakeMove_WalksBackAGame(t *testing.T) {
+	b := NewBoard()
+	if err := b.UnmakeMove(); err != ErrNoMoveToUnmake {
+		t.Fatalf("expected ErrNoMoveToUnmake on a fresh board, got %v", err)
+	}
+
+	var fens []string
+	for _, uci := range []string{"e2e4", "d7d5", "e4d5", "g8f6", "f1b5", "c7c6", "d5c6", "d8d2", "b1d2", "b7c6", "g1f3", "c8g4", "e1g1"} {
+		fens = append(fens, b.ToFEN())
+		move, err := ParseUCI(uci)
+		if err != nil {
+			t.Fatalf("parse %s: %v", uci, err)
+		}
+		if err := b.MakeMove(move); err != nil {
+			t.Fatalf("make %s: %v", uci, err)
+		}
+	}
+
+	for i := len(fens) - 1; i >= 0; i-- {
+		if err := b.UnmakeMove(); err != nil {
+			t.Fatalf("unmake ply %d: %v", i+1, err)
+		}
+		if got := b.ToFEN(); got != fens[i] {
+			t.Fatalf("ply %d: expected %q, got %q", i+1, fens[i], got)
+		}
+	}
+	if b.repetitionCount() != 1 {
+		t.Fatalf("expected history to be unwound with the moves")
+	}
+}
+
+func TestDoMoveUndoMove_RestoresExactly(t *testing.T) {
+	same := func(a, b *Board) bool {
+		return a.squares == b.squares && a.bb == b.bb && a.turn == b.turn &&
+			a.castling == b.castling && a.enPassent == b.enPassent &&
+			a.halfMove == b.halfMove && a.fullMove == b.fullMove &&
+			a.hash == b.hash && len(a.history) == len(b.history)
+	}
+
+	for _, tc := range perftCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := LoadFEN(tc.fen)
+			if err != nil {
+				t.Fatalf("load fen: %v", err)
+			}
+			before := b.Clone()
+
+			// pseudo-legal moves include ones that leave the king in check,
+			// which legality checking also plays and takes back
+			for _, m := range b.PseudoLegalMoves() {
+				u := b.DoMove(m)
+				if b.hash != b.computeHash() {
+					t.Fatalf("%s: incremental hash differs from recomputed hash", m)
+				}
+				for _, reply := range b.LegalMoves() {
+					b.UndoMove(b.DoMove(reply))
+				}
+				b.UndoMove(u)
+				if !same(b, before) {
+					t.Fatalf("%s: position not restored, got %q", m, b.ToFEN())
+				}
+			}
+		})
+	}
+}
+
+func TestReplayMove_RecordsForUnmake(t *testing.T) {
+	b := NewBoard()
+	if err := b.ReplayMove(NewMove(E2, E4)); err != nil {
+		t.Fatalf("ReplayMove error: %v", err)
+	}
+	if err := b.ReplayMove(NewMove(E2, E4)); err == nil {
+		t.Fatalf("expected a move from an empty square to fail")
+	}
+	if err := b.ReplayMove(NewMove(E4, E5)); err == nil {
+		t.Fatalf("expected a move of the wrong colour to fail")
+	}
+	if err := b.UnmakeMove(); err != nil {
+		t.Fatalf("UnmakeMove error: %v", err)
+	}
+	if b.ToFEN() != StartingFEN {
+		t.Fatalf("expected the start position back, got %s", b.ToFEN())
+	}
+}
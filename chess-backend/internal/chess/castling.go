@@ -0,0 +1,116 @@
+package chess
+
+// Castling sides, used to index Board.rookFiles.
+const (
+	castleKingside = iota
+	castleQueenside
+)
+
+// standardRookFiles are the castling rooks of standard chess: h for
+// kingside, a for queenside.
+var standardRookFiles = [2][2]int{{7, 0}, {7, 0}}
+
+func homeRank(c Color) int {
+	if c == Black {
+		return 7
+	}
+	return 0
+}
+
+func (cr CastlingRights) has(c Color, side int) bool {
+	switch {
+	case c == White && side == castleKingside:
+		return cr.WhiteKingside
+	case c == White:
+		return cr.WhiteQueenside
+	case side == castleKingside:
+		return cr.BlackKingside
+	default:
+		return cr.BlackQueenside
+	}
+}
+
+func (cr *CastlingRights) set(c Color, side int, value bool) {
+	switch {
+	case c == White && side == castleKingside:
+		cr.WhiteKingside = value
+	case c == White:
+		cr.WhiteQueenside = value
+	case side == castleKingside:
+		cr.BlackKingside = value
+	default:
+		cr.BlackQueenside = value
+	}
+}
+
+func (cr *CastlingRights) clear(c Color, side int) {
+	cr.set(c, side, false)
+}
+
+// isCastle reports whether the king move is a castling move: two files along
+// the rank on a standard board, onto one of its own rooks in Chess960.
+func (b *Board) isCastle(piece Piece, move Move) bool {
+	if piece.Type != King {
+		return false
+	}
+	if b.chess960 {
+		target := b.squares[move.To]
+		return target != nil && target.Type == Rook && target.Color == piece.Color
+	}
+	return abs(move.To.File()-move.From.File()) == 2 && move.From.Rank() == move.To.Rank()
+}
+
+func castleSide(move Move) int {
+	if move.To.File() > move.From.File() {
+		return castleKingside
+	}
+	return castleQueenside
+}
+
+// castleSquares returns where the king lands and where the rook starts and
+// lands for a castling move. Whatever the start files, the king ends on the g
+// or c file and the rook beside it on f or d.
+func (b *Board) castleSquares(move Move, c Color) (kingTo, rookFrom, rookTo Square) {
+	rank := move.From.Rank()
+	side := castleSide(move)
+	rookFrom = move.To
+	if !b.chess960 {
+		rookFrom = Square(b.rookFiles[c][side]*8 + rank)
+	}
+	if side == castleKingside {
+		return Square(6*8 + rank), rookFrom, Square(5*8 + rank)
+	}
+	return Square(2*8 + rank), rookFrom, Square(3*8 + rank)
+}
+
+// castlingRook is the square of the rook that castles on side.
+func (b *Board) castlingRook(c Color, side int) Square {
+	return Square(b.rookFiles[c][side]*8 + homeRank(c))
+}
+
+// clearCastlingRook drops the castling right that belongs to the rook on sq,
+// if any, when something moves from or onto that square.
+func (b *Board) clearCastlingRook(sq Square) {
+	for _, c := range [...]Color{White, Black} {
+		for _, side := range [...]int{castleKingside, castleQueenside} {
+			if sq == b.castlingRook(c, side) {
+				b.castling.clear(c, side)
+			}
+		}
+	}
+}
+
+// castlePathClear reports whether every square the king and rook cross or
+// land on is empty, apart from the two of them.
+func (b *Board) castlePathClear(kingFrom, rookFrom, kingTo, rookTo Square) bool {
+	lo := min(kingFrom.File(), rookFrom.File(), kingTo.File(), rookTo.File())
+	hi := max(kingFrom.File(), rookFrom.File(), kingTo.File(), rookTo.File())
+	rank := kingFrom.Rank()
+	for file := lo; file <= hi; file++ {
+		sq := Square(file*8 + rank)
+		if sq != kingFrom && sq != rookFrom && !b.IsEmpty(sq) {
+			return false
+		}
+	}
+	return true
+}
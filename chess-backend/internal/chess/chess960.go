@@ -0,0 +1,76 @@
+package chess
+
+import "fmt"
+
+// StandardChess960Position is the number of the standard starting position
+// among the Chess960 positions.
+const StandardChess960Position = 518
+
+// chess960Knights places the two knights on the five squares left after the
+// bishops and queen, indexed by what remains of the position number.
+var chess960Knights = [10][2]int{
+	{0, 1}, {0, 2}, {0, 3}, {0, 4}, {1, 2},
+	{1, 3}, {1, 4}, {2, 3}, {2, 4}, {3, 4},
+}
+
+// NewChess960Board sets up Chess960 starting position n, 0 to 959, using
+// Scharnagl's numbering, so the same number always gives the same position.
+// Position 518 is the standard starting position.
+func NewChess960Board(n int) (*Board, error) {
+	if n < 0 || n >= 960 {
+		return nil, fmt.Errorf("chess960 position must be 0-959, got %d", n)
+	}
+
+	var rank [8]PieceType
+	filled := [8]bool{}
+	place := func(file int, pt PieceType) {
+		rank[file] = pt
+		filled[file] = true
+	}
+	// nthEmpty is the file of the i-th empty square, counting from a
+	nthEmpty := func(i int) int {
+		for file := range 8 {
+			if !filled[file] {
+				if i == 0 {
+					return file
+				}
+				i--
+			}
+		}
+		return -1
+	}
+
+	place(2*(n%4)+1, Bishop) // light squares: b, d, f, h
+	n /= 4
+	place(2*(n%4), Bishop) // dark squares: a, c, e, g
+	n /= 4
+	place(nthEmpty(n%6), Queen)
+	n /= 6
+	knights := chess960Knights[n]
+	place(nthEmpty(knights[0]), Knight)
+	place(nthEmpty(knights[1]-1), Knight) // the first knight took a square before it
+	place(nthEmpty(0), Rook)
+	place(nthEmpty(0), King)
+	place(nthEmpty(0), Rook)
+
+	b := newEmptyBoard(White)
+	b.fullMove = 1
+	b.chess960 = true
+	rooks := make([]int, 0, 2)
+	for file, pt := range rank {
+		b.setPiece(Square(file*8+0), NewPiece(pt, White))
+		b.setPiece(Square(file*8+1), NewPiece(Pawn, White))
+		b.setPiece(Square(file*8+6), NewPiece(Pawn, Black))
+		b.setPiece(Square(file*8+7), NewPiece(pt, Black))
+		if pt == Rook {
+			rooks = append(rooks, file)
+		}
+	}
+	for _, c := range [...]Color{White, Black} {
+		b.rookFiles[c] = [2]int{rooks[1], rooks[0]}
+		b.castling.set(c, castleKingside, true)
+		b.castling.set(c, castleQueenside, true)
+	}
+	b.hash = b.computeHash()
+	return b, nil
+}
@@ -0,0 +1,199 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewChess960Board(t *testing.T) {
+	cases := map[int]string{
+		0:   "bbqnnrkr/pppppppp/8/8/8/8/PPPPPPPP/BBQNNRKR w HFhf - 0 1",
+		518: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w HAha - 0 1",
+		959: "rkrnnqbb/pppppppp/8/8/8/8/PPPPPPPP/RKRNNQBB w CAca - 0 1",
+	}
+	for n, want := range cases {
+		b, err := NewChess960Board(n)
+		if err != nil {
+			t.Fatalf("position %d: %v", n, err)
+		}
+		if got := b.ToFEN(); got != want {
+			t.Fatalf("position %d: expected %s, got %s", n, want, got)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for n := range 960 {
+		b, _ := NewChess960Board(n)
+		var kingFile, bishopColors int
+		var rookFiles []int
+		for file := range 8 {
+			switch b.PieceAt(Square(file * 8)).Type {
+			case King:
+				kingFile = file
+			case Rook:
+				rookFiles = append(rookFiles, file)
+			case Bishop:
+				bishopColors |= 1 << (file % 2)
+			}
+		}
+		if len(rookFiles) != 2 || rookFiles[0] > kingFile || rookFiles[1] < kingFile || bishopColors != 3 {
+			t.Fatalf("position %d is not a Chess960 position: %s", n, b.ToFEN())
+		}
+		seen[b.ToFEN()] = true
+	}
+	if len(seen) != 960 {
+		t.Fatalf("expected 960 distinct positions, got %d", len(seen))
+	}
+
+	if _, err := NewChess960Board(960); err == nil {
+		t.Fatalf("expected position 960 to be rejected")
+	}
+}
+
+func TestChess960_CastlingOntoRookSquare(t *testing.T) {
+	// king f1, kingside rook g1: the king lands on the rook's square
+	b, err := LoadFEN("1r2k2r/8/8/8/8/8/8/1R3KR1 w GBhb - 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+	if !b.Chess960() {
+		t.Fatalf("expected Shredder-FEN castling rights to make a Chess960 board")
+	}
+
+	castle := NewMove(F1, G1)
+	san, err := b.SAN(castle)
+	if err != nil || san != "O-O" {
+		t.Fatalf("expected f1g1 to be O-O, got %q (%v)", san, err)
+	}
+	if err := b.MakeMove(castle); err != nil {
+		t.Fatalf("expected castling to be legal, got %v", err)
+	}
+	if k := b.PieceAt(G1); k == nil || k.Type != King {
+		t.Fatalf("expected king on g1, got %v", k)
+	}
+	if r := b.PieceAt(F1); r == nil || r.Type != Rook {
+		t.Fatalf("expected rook on f1, got %v", r)
+	}
+	if got := b.ToFEN(); got != "1r2k2r/8/8/8/8/8/8/1R3RK1 b hb - 1 1" {
+		t.Fatalf("unexpected position after castling: %s", got)
+	}
+
+	if err := b.UnmakeMove(); err != nil {
+		t.Fatalf("UnmakeMove error: %v", err)
+	}
+	if got := b.ToFEN(); got != "1r2k2r/8/8/8/8/8/8/1R3KR1 w GBhb - 0 1" {
+		t.Fatalf("unexpected position after unmake: %s", got)
+	}
+
+	// queenside: the king travels f1-e1-d1-c1 and the rook b1-c1-d1
+	long, err := b.ParseSAN("O-O-O")
+	if err != nil || long != NewMove(F1, B1) {
+		t.Fatalf("expected O-O-O to be f1b1, got %v (%v)", long, err)
+	}
+	if err := b.MakeMove(long); err != nil {
+		t.Fatalf("expected O-O-O to be legal, got %v", err)
+	}
+	if got := b.ToFEN(); got != "1r2k2r/8/8/8/8/8/8/2KR2R1 b hb - 1 1" {
+		t.Fatalf("unexpected position after O-O-O: %s", got)
+	}
+}
+
+func TestChess960_KingDoesNotMove(t *testing.T) {
+	b, err := LoadFEN("4k3/8/8/8/8/8/8/5RKR w H - 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+	if err := ValidateMove(b, NewMove(G1, H1)); err == nil {
+		t.Fatalf("expected castling to be blocked by the rook on f1")
+	}
+
+	b, _ = LoadFEN("4k3/8/8/8/8/8/8/6KR w H - 0 1")
+	if err := b.MakeMove(NewMove(G1, H1)); err != nil {
+		t.Fatalf("expected castling with the king already on g1, got %v", err)
+	}
+	if got := b.ToFEN(); got != "4k3/8/8/8/8/8/8/5RK1 b - - 1 1" {
+		t.Fatalf("unexpected position after castling: %s", got)
+	}
+}
+
+func TestChess960_IllegalCastling(t *testing.T) {
+	cases := []struct {
+		name string
+		fen  string
+		move Move
+	}{
+		{"king passes an attacked square", "3rk3/8/8/8/8/8/8/1R3K2 w B - 0 1", NewMove(F1, B1)},
+		{"rook right lost", "4k3/8/8/8/8/8/8/1R1K4 w - - 0 1", NewMove(D1, B1)},
+		{"king destination occupied", "4k3/8/8/8/8/8/8/RNK5 w A - 0 1", NewMove(C1, A1)},
+		{"castled rook uncovers a check", "4k3/8/8/8/8/8/8/rRK5 w B - 0 1", NewMove(C1, B1)},
+		{"two-square king move", "4k3/8/8/8/8/8/8/1R1K3R w HB - 0 1", NewMove(D1, F1)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := LoadFEN(tc.fen)
+			if err != nil {
+				t.Fatalf("LoadFEN error: %v", err)
+			}
+			if err := ValidateMove(b, tc.move); err == nil {
+				t.Fatalf("expected %s to be illegal", tc.move.UCI())
+			}
+		})
+	}
+}
+
+func TestLoadChess960FEN_XFEN(t *testing.T) {
+	// KQkq names the outermost rooks; the inner rook on b1 needs its file
+	b, err := LoadChess960FEN("rr2k2r/8/8/8/8/8/8/RR2K2R w KBkq - 0 1")
+	if err != nil {
+		t.Fatalf("LoadChess960FEN error: %v", err)
+	}
+	if got := b.ToFEN(); got != "rr2k2r/8/8/8/8/8/8/RR2K2R w HBha - 0 1" {
+		t.Fatalf("unexpected FEN: %s", got)
+	}
+
+	if _, err := LoadFEN("4k3/8/8/8/8/8/8/4K3 w H - 0 1"); err == nil {
+		t.Fatalf("expected a castling file without a rook to be rejected")
+	}
+
+	std, _ := LoadFEN(StartingFEN)
+	if std.Chess960() {
+		t.Fatalf("expected KQkq to load a standard board")
+	}
+}
+
+func TestChess960_PGNRoundTrip(t *testing.T) {
+	start, _ := NewChess960Board(StandardChess960Position)
+	b := start.Clone()
+	var moves []Move
+	for _, san := range []string{"Nf3", "Nf6", "g3", "g6", "Bg2", "Bg7", "O-O", "O-O"} {
+		m, err := b.ParseSAN(san)
+		if err != nil {
+			t.Fatalf("%s: %v", san, err)
+		}
+		_ = b.MakeMove(m)
+		moves = append(moves, m)
+	}
+	if moves[6].UCI() != "e1h1" {
+		t.Fatalf("expected Chess960 castling as e1h1, got %s", moves[6].UCI())
+	}
+
+	g, err := NewPGNGameFromMoves(start, moves)
+	if err != nil {
+		t.Fatalf("NewPGNGameFromMoves error: %v", err)
+	}
+	if g.Tags["Variant"] != "Chess960" || g.Tags["FEN"] != start.ToFEN() {
+		t.Fatalf("expected Variant and FEN tags, got %v", g.Tags)
+	}
+
+	games, err := ParsePGN(strings.NewReader(g.String()))
+	if err != nil {
+		t.Fatalf("ParsePGN error: %v", err)
+	}
+	replayed, got, err := games[0].Replay()
+	if err != nil {
+		t.Fatalf("Replay error: %v", err)
+	}
+	if !replayed.Chess960() || len(got) != len(moves) || got[7] != moves[7] {
+		t.Fatalf("expected the Chess960 game back, got %v", got)
+	}
+}
@@ -0,0 +1,128 @@
+package chess
+
+// Crazyhouse is standard chess in which captured pieces change sides: they go
+// to the capturer's pocket and may be dropped on any empty square instead of
+// a move. Pawns are not dropped on the first or last rank, and promoted
+// pieces go back to the pocket as pawns.
+var Crazyhouse Variant = crazyhouse{}
+
+type crazyhouse struct{}
+
+func (crazyhouse) Name() string     { return "crazyhouse" }
+func (crazyhouse) Title() string    { return "Crazyhouse" }
+func (crazyhouse) NewBoard() *Board { return newVariantBoard(Crazyhouse, StartingFEN) }
+
+func (crazyhouse) setup(b *Board) { b.hasPockets = true }
+
+func (crazyhouse) FilterMoves(b *Board, pseudo []Move) []Move {
+	return b.KingSafeMoves(pseudo)
+}
+
+func (crazyhouse) Outcome(b *Board) (Outcome, bool) {
+	// material never runs out: every capture can be dropped back
+	if o, ok := b.mateOutcome(); ok {
+		return o, ok
+	}
+	return b.drawRuleOutcome()
+}
+
+// Pocket counts the pieces a side holds for dropping, by PieceType. The King
+// entry is always zero.
+type Pocket [6]int
+
+// Pocket is what c holds in Crazyhouse.
+func (b *Board) Pocket(c Color) Pocket {
+	return b.pockets[c]
+}
+
+// HasPockets reports whether captured pieces go to the pockets, as in
+// Crazyhouse.
+func (b *Board) HasPockets() bool {
+	return b.hasPockets
+}
+
+// dropTypes are the piece types that can be in a pocket, in FEN order.
+var dropTypes = [...]PieceType{Queen, Rook, Bishop, Knight, Pawn}
+
+// dropRanks are the ranks pawns may be dropped on, the second to the seventh.
+const dropRanks uint64 = 0x7E7E7E7E7E7E7E7E
+
+func (b *Board) appendDrops(moves []Move, empty uint64) []Move {
+	pocket := &b.pockets[b.turn]
+	for _, pt := range dropTypes {
+		if pocket[pt] == 0 {
+			continue
+		}
+		targets := empty
+		if pt == Pawn {
+			targets &= dropRanks
+		}
+		for targets != 0 {
+			moves = append(moves, NewDrop(pt, popLSB(&targets)))
+		}
+	}
+	return moves
+}
+
+// pocketType is the type a captured piece takes in the pocket.
+func pocketType(captured Piece, promoted bool) PieceType {
+	if promoted {
+		return Pawn
+	}
+	return captured.Type
+}
+
+// pocketCapture gives the piece captured on sq to the capturer.
+func (b *Board) pocketCapture(capturer Color, captured Piece, sq Square) {
+	b.pockets[capturer][pocketType(captured, b.promoted&squareBB(sq) != 0)]++
+	b.promoted &^= squareBB(sq)
+}
+
+// doDrop is DoMove for a drop.
+func (b *Board) doDrop(move Move) Undo {
+	piece := NewPiece(move.Drop, b.turn)
+	undo := Undo{
+		move:       move,
+		piece:      piece,
+		capturedSq: NoSquare,
+		castling:   b.castling,
+		checks:     b.checks,
+		promoted:   b.promoted,
+		enPassent:  b.enPassent,
+		halfMove:   b.halfMove,
+		fullMove:   b.fullMove,
+		hash:       b.hash,
+	}
+
+	b.history = append(b.history, b.hash)
+	b.hash ^= b.zobristStateKey()
+
+	b.pockets[b.turn][move.Drop]--
+	b.setPiece(move.To, piece)
+	b.halfMove++
+	b.enPassent = NoSquare
+
+	b.endMove()
+	return undo
+}
+
+// validateDrop checks a drop against the pockets and the board; whether it
+// leaves the king in check is left to the caller.
+func validateDrop(board *Board, move Move) error {
+	if !board.hasPockets || move.Drop == King || move.Drop < Pawn || move.Drop > Queen {
+		return ErrIllegalDrop
+	}
+	if !move.To.isValid() {
+		return ErrInvalidSquare
+	}
+	if !board.IsEmpty(move.To) {
+		return ErrIllegalDrop
+	}
+	if board.pockets[board.turn][move.Drop] == 0 {
+		return ErrEmptyPocket
+	}
+	if move.Drop == Pawn && dropRanks&squareBB(move.To) == 0 {
+		return ErrIllegalDrop
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package chess
+
+import "testing"
+
+func TestCrazyhouse_CaptureAndDrop(t *testing.T) {
+	b := Crazyhouse.NewBoard()
+	if got := b.ToFEN(); got != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR[] w KQkq - 0 1" {
+		t.Fatalf("unexpected start FEN: %s", got)
+	}
+
+	playUCI(t, b, "e2e4", "d7d5", "e4d5")
+	if b.Pocket(White)[Pawn] != 1 {
+		t.Fatalf("expected the captured pawn in White's pocket, got %v", b.Pocket(White))
+	}
+	if got := b.ToFEN(); got != "rnbqkbnr/ppp1pppp/8/3P4/8/8/PPPP1PPP/RNBQKBNR[P] b KQkq - 0 2" {
+		t.Fatalf("unexpected FEN after the capture: %s", got)
+	}
+
+	playUCI(t, b, "d8d5")
+	drop, err := ParseUCI("P@e6")
+	if err != nil || drop != NewDrop(Pawn, E6) {
+		t.Fatalf("expected P@e6 to parse as a drop, got %v (%v)", drop, err)
+	}
+	san, err := b.SAN(drop)
+	if err != nil || san != "@e6" {
+		t.Fatalf("expected SAN @e6, got %q (%v)", san, err)
+	}
+	hash := b.Hash()
+	if err := b.MakeMove(drop); err != nil {
+		t.Fatalf("expected the drop to be legal, got %v", err)
+	}
+	if p := b.PieceAt(E6); p == nil || p.Type != Pawn || p.Color != White {
+		t.Fatalf("expected a white pawn on e6, got %v", p)
+	}
+	if b.Pocket(White)[Pawn] != 0 || b.Pocket(Black)[Pawn] != 1 {
+		t.Fatalf("unexpected pockets: %v %v", b.Pocket(White), b.Pocket(Black))
+	}
+
+	if err := b.UnmakeMove(); err != nil {
+		t.Fatalf("UnmakeMove error: %v", err)
+	}
+	if b.Pocket(White)[Pawn] != 1 || b.PieceAt(E6) != nil || b.Hash() != hash {
+		t.Fatalf("expected unmake to put the pawn back in the pocket")
+	}
+
+	if m, err := b.ParseSAN("@e6"); err != nil || m != drop {
+		t.Fatalf("expected ParseSAN(@e6) to find the drop, got %v (%v)", m, err)
+	}
+}
+
+func TestCrazyhouse_IllegalDrops(t *testing.T) {
+	b, err := LoadVariantFEN(Crazyhouse, "4k3/8/8/8/8/8/8/4K2r[PNn] w - - 0 1")
+	if err != nil {
+		t.Fatalf("LoadVariantFEN error: %v", err)
+	}
+	cases := map[string]Move{
+		"pawn on the last rank":    NewDrop(Pawn, A8),
+		"pawn on the first rank":   NewDrop(Pawn, A1),
+		"piece not in pocket":      NewDrop(Queen, D4),
+		"occupied square":          NewDrop(Knight, E8),
+		"leaves the king in check": NewDrop(Knight, A4),
+	}
+	for name, m := range cases {
+		if err := ValidateMove(b, m); err == nil {
+			t.Fatalf("%s: expected %s to be illegal", name, m.UCI())
+		}
+	}
+	// blocking the check is fine
+	if err := ValidateMove(b, NewDrop(Knight, G1)); err != nil {
+		t.Fatalf("expected N@g1 to block the check, got %v", err)
+	}
+	for _, m := range b.LegalMoves() {
+		if m.IsDrop() && m.To != F1 && m.To != G1 {
+			t.Fatalf("expected only blocking drops in check, got %s", m.UCI())
+		}
+	}
+}
+
+func TestCrazyhouse_PromotedPieceReturnsAsPawn(t *testing.T) {
+	b, err := LoadVariantFEN(Crazyhouse, "4k3/1P6/8/8/8/8/1r6/4K3[] w - - 0 1")
+	if err != nil {
+		t.Fatalf("LoadVariantFEN error: %v", err)
+	}
+	playUCI(t, b, "b7b8q")
+	if got := b.ToFEN(); got != "1Q~2k3/8/8/8/8/8/1r6/4K3[] b - - 0 1" {
+		t.Fatalf("expected the queen marked as promoted, got %s", got)
+	}
+	playUCI(t, b, "b2b8")
+	if p := b.Pocket(Black); p[Pawn] != 1 || p[Queen] != 0 {
+		t.Fatalf("expected the promoted queen to go to the pocket as a pawn, got %v", p)
+	}
+
+	// the marker and a ninth-rank pocket load back
+	b, err = LoadVariantFEN(Crazyhouse, "1Q~2k3/8/8/8/8/8/8/r3K3/Rp b - - 0 1")
+	if err != nil {
+		t.Fatalf("LoadVariantFEN error: %v", err)
+	}
+	if got := b.ToFEN(); got != "1Q~2k3/8/8/8/8/8/8/r3K3[Rp] b - - 0 1" {
+		t.Fatalf("unexpected FEN: %s", got)
+	}
+
+	if _, err := LoadFEN("4k3/8/8/8/8/8/8/4K3[Q] w - - 0 1"); err == nil {
+		t.Fatalf("expected pockets to be rejected outside Crazyhouse")
+	}
+}
+
+func TestCrazyhouse_Perft(t *testing.T) {
+	b, err := LoadVariantFEN(Crazyhouse, "2k5/8/8/8/8/8/8/4K3[QRBNPqrbnp] w - - 0 1")
+	if err != nil {
+		t.Fatalf("LoadVariantFEN error: %v", err)
+	}
+	for depth, want := range []uint64{301, 75353} {
+		if got := b.Perft(depth + 1); got != want {
+			t.Fatalf("perft(%d): expected %d, got %d", depth+1, want, got)
+		}
+	}
+}
@@ -1,9 +1,65 @@
 package chess
 
+import "math/bits"
+
 func (b *Board) CanClaimFiftyMoveDraw() bool {
 	return b.halfMove >= 100
 }
 
+// IsSeventyFiveMoveRule reports the automatic draw after 75 moves by each
+// side without a capture or pawn move. A mate on the last move still counts.
+func (b *Board) IsSeventyFiveMoveRule() bool {
+	return b.halfMove >= 150
+}
+
+func (b *Board) CanClaimThreefoldRepetition() bool {
+	return b.repetitionCount() >= 3
+}
+
+func (b *Board) IsFivefoldRepetition() bool {
+	return b.repetitionCount() >= 5
+}
+
+// IsRepetition reports whether the current position has occurred before.
+// Search treats this as a draw, since the side that could avoid it would.
+func (b *Board) IsRepetition() bool {
+	return b.repetitionCount() >= 2
+}
+
+// repetitionCount reports how many times the current position has occurred,
+// looking back only as far as the last capture or pawn move.
+func (b *Board) repetitionCount() int {
+	count := 1
+	n := len(b.history)
+	oldest := max(n-b.halfMove, 0)
+	for i := n - 2; i >= oldest; i -= 2 {
+		if b.history[i] == b.hash {
+			count++
+		}
+	}
+	return count
+}
+
+func (b *Board) HasOnlyKing(color Color) bool {
+	for sq := A1; sq <= H8; sq++ {
+		p := b.PieceAt(sq)
+		if p != nil && p.Color == color && p.Type != King {
+			return false
+		}
+	}
+	return true
+}
+
+// HasInsufficientMaterial reports whether color is down to a lone king or a
+// king and a single knight or bishop, which can never mate by themselves.
+func (b *Board) HasInsufficientMaterial(color Color) bool {
+	p := &b.bb.pieces[color]
+	if p[Pawn]|p[Rook]|p[Queen] != 0 {
+		return false
+	}
+	return bits.OnesCount64(p[Knight]|p[Bishop]) <= 1
+}
+
 func (b *Board) IsInsufficientMaterial() bool {
 	type counts struct {
 		pawns   int
@@ -97,3 +153,163 @@ func isLightSquare(sq Square) bool {
 	// doesnt matter as long as consistent
 	return (sq.File()+sq.Rank())%2 == 0
 }
+
+// IsDeadPosition reports positions where neither side can checkmate by any
+// legal sequence of moves but which IsInsufficientMaterial does not cover:
+// only bishops left and all of them on one square colour, or kings that can
+// never get past a fully locked pawn chain.
+func (b *Board) IsDeadPosition() bool {
+	return b.onlySameColoredBishops() || b.isLockedPawnPosition()
+}
+
+// CannotForceMate reports material that can mate only with help from the
+// defender (K+N+N vs K). Such games are not drawn automatically.
+func (b *Board) CannotForceMate() bool {
+	var knights, pieces [2]int
+	for sq := A1; sq <= H8; sq++ {
+		p := b.PieceAt(sq)
+		if p == nil || p.Type == King {
+			continue
+		}
+		pieces[p.Color]++
+		if p.Type == Knight {
+			knights[p.Color]++
+		}
+	}
+
+	for _, c := range []Color{White, Black} {
+		other := c.Opposite()
+		if knights[c] == 2 && pieces[c] == 2 && pieces[other] == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Board) onlySameColoredBishops() bool {
+	light, dark := 0, 0
+	for sq := A1; sq <= H8; sq++ {
+		p := b.PieceAt(sq)
+		if p == nil || p.Type == King {
+			continue
+		}
+		if p.Type != Bishop {
+			return false
+		}
+		if isLightSquare(sq) {
+			light++
+		} else {
+			dark++
+		}
+	}
+	return light == 0 || dark == 0
+}
+
+// isLockedPawnPosition handles king-and-pawn endings where every pawn is
+// blocked head-on by an enemy pawn, no pawn can capture, and neither king can
+// reach an undefended enemy pawn.
+func (b *Board) isLockedPawnPosition() bool {
+	pawns := 0
+	for sq := A1; sq <= H8; sq++ {
+		p := b.PieceAt(sq)
+		if p == nil || p.Type == King {
+			continue
+		}
+		if p.Type != Pawn {
+			return false
+		}
+		pawns++
+
+		dir := 1
+		if p.Color == Black {
+			dir = -1
+		}
+		r := sq.Rank() + dir
+		if r < 0 || r > 7 {
+			return false
+		}
+		front := b.PieceAt(Square(sq.File()*8 + r))
+		if front == nil || front.Type != Pawn || front.Color == p.Color {
+			return false
+		}
+		for _, df := range []int{-1, 1} {
+			f := sq.File() + df
+			if f < 0 || f > 7 {
+				continue
+			}
+			target := b.PieceAt(Square(f*8 + r))
+			if target != nil && target.Type == Pawn && target.Color != p.Color {
+				return false
+			}
+		}
+	}
+	if pawns == 0 {
+		return false
+	}
+
+	return !b.kingCanReachPawn(White) && !b.kingCanReachPawn(Black)
+}
+
+func (b *Board) kingCanReachPawn(color Color) bool {
+	start := b.findKingSquare(color)
+	if start == NoSquare {
+		return false
+	}
+
+	var seen [64]bool
+	seen[start] = true
+	queue := []Square{start}
+	for len(queue) > 0 {
+		sq := queue[0]
+		queue = queue[1:]
+
+		for df := -1; df <= 1; df++ {
+			for dr := -1; dr <= 1; dr++ {
+				f, r := sq.File()+df, sq.Rank()+dr
+				if (df == 0 && dr == 0) || f < 0 || f > 7 || r < 0 || r > 7 {
+					continue
+				}
+				next := Square(f*8 + r)
+				if seen[next] {
+					continue
+				}
+				seen[next] = true
+
+				p := b.PieceAt(next)
+				if p != nil && p.Type == Pawn && p.Color == color {
+					continue
+				}
+				if b.isAttackedByPawn(next, color.Opposite()) {
+					continue
+				}
+				if p != nil && p.Type == Pawn {
+					return true // undefended enemy pawn within reach
+				}
+				queue = append(queue, next)
+			}
+		}
+	}
+	return false
+}
+
+func (b *Board) isAttackedByPawn(target Square, byColor Color) bool {
+	dir := 1
+	if byColor == Black {
+		dir = -1
+	}
+	r := target.Rank() - dir
+	if r < 0 || r > 7 {
+		return false
+	}
+	for _, df := range []int{-1, 1} {
+		f := target.File() + df
+		if f < 0 || f > 7 {
+			continue
+		}
+		p := b.PieceAt(Square(f*8 + r))
+		if p != nil && p.Type == Pawn && p.Color == byColor {
+			return true
+		}
+	}
+	return false
+}
@@ -75,3 +75,209 @@ func TestIsInsufficientMaterial_NotInsufficientWithTwoMinors(t *testing.T) {
 		t.Fatalf("expected K+BN vs K to NOT be insufficient material")
 	}
 }
+
+func TestHasInsufficientMaterial(t *testing.T) {
+	b := newEmptyBoard(White)
+	b.setPiece(E1, NewPiece(King, White))
+	b.setPiece(E8, NewPiece(King, Black))
+	b.setPiece(E2, NewPiece(Pawn, White))
+	b.setPiece(C6, NewPiece(Knight, Black))
+
+	if !b.HasInsufficientMaterial(Black) {
+		t.Fatalf("expected K+N to be insufficient to mate")
+	}
+	if b.HasInsufficientMaterial(White) {
+		t.Fatalf("expected K+P to be able to mate")
+	}
+	b.setPiece(F6, NewPiece(Bishop, Black))
+	if b.HasInsufficientMaterial(Black) {
+		t.Fatalf("expected K+BN to be able to mate")
+	}
+}
+
+func playMoves(t *testing.T, b *Board, moves ...Move) {
+	t.Helper()
+	for _, m := range moves {
+		if err := b.MakeMove(m); err != nil {
+			t.Fatalf("expected %s to be legal, got %v", m, err)
+		}
+	}
+}
+
+func TestRepetition_ThreefoldAndFivefold(t *testing.T) {
+	b := NewBoard()
+	shuffle := []Move{NewMove(G1, F3), NewMove(G8, F6), NewMove(F3, G1), NewMove(F6, G8)}
+
+	playMoves(t, b, shuffle...)
+	if b.CanClaimThreefoldRepetition() {
+		t.Fatalf("did not expect threefold after one repetition")
+	}
+
+	playMoves(t, b, shuffle...)
+	if !b.CanClaimThreefoldRepetition() {
+		t.Fatalf("expected threefold after the start position occurred three times")
+	}
+	if b.IsFivefoldRepetition() {
+		t.Fatalf("did not expect fivefold yet")
+	}
+
+	playMoves(t, b, shuffle...)
+	playMoves(t, b, shuffle...)
+	if !b.IsFivefoldRepetition() {
+		t.Fatalf("expected fivefold after the start position occurred five times")
+	}
+}
+
+func TestRepetition_IrreversibleMoveResetsWindow(t *testing.T) {
+	b := NewBoard()
+	shuffle := []Move{NewMove(G1, F3), NewMove(G8, F6), NewMove(F3, G1), NewMove(F6, G8)}
+
+	playMoves(t, b, shuffle...)
+	playMoves(t, b, NewMove(E2, E4), NewMove(E7, E5))
+	playMoves(t, b, shuffle...)
+	if b.CanClaimThreefoldRepetition() {
+		t.Fatalf("positions before a pawn move must not count towards repetition")
+	}
+}
+
+func TestRepetition_CastlingRightsDistinguishPositions(t *testing.T) {
+	b, err := LoadFEN("r3k3/8/8/8/8/8/8/4K2R w Kq - 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+	start := b.Hash()
+
+	// rook leaves and returns: same placement, but white has lost kingside castling
+	playMoves(t, b, NewMove(H1, H2), NewMove(A8, A7), NewMove(H2, H1), NewMove(A7, A8))
+	if b.Hash() == start {
+		t.Fatalf("expected lost castling rights to change the position hash")
+	}
+}
+
+func TestZobrist_IncrementalMatchesFullRecompute(t *testing.T) {
+	b, err := LoadFEN("r3k2r/1P4p1/8/3pP3/8/8/8/R3K2R w KQkq d6 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+
+	moves := []Move{
+		NewMove(E5, D6),                     // en passant
+		NewMove(E8, G8),                     // kingside castle
+		NewMoveWithPromotion(B7, A8, Queen), // capture-promotion removing a rook
+		NewMove(G7, G5),                     // double step
+		NewMove(E1, C1),                     // queenside castle
+		NewMove(G8, G7),
+	}
+	for _, m := range moves {
+		playMoves(t, b, m)
+		if b.Hash() != b.computeHash() {
+			t.Fatalf("incremental hash diverged after %s", m)
+		}
+	}
+}
+
+func TestZobrist_UnusableEnPassantSquareIgnored(t *testing.T) {
+	withEP, err := LoadFEN("4k3/8/8/8/4P3/8/8/4K3 b - e3 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+	withoutEP, err := LoadFEN("4k3/8/8/8/4P3/8/8/4K3 b - - 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+	if withEP.Hash() != withoutEP.Hash() {
+		t.Fatalf("expected en passant square with no capturing pawn to be ignored")
+	}
+
+	capturable, err := LoadFEN("4k3/8/8/8/3pP3/8/8/4K3 b - e3 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+	notCapturable, err := LoadFEN("4k3/8/8/8/3pP3/8/8/4K3 b - - 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+	if capturable.Hash() == notCapturable.Hash() {
+		t.Fatalf("expected usable en passant square to change the hash")
+	}
+}
+
+func TestIsSeventyFiveMoveRule(t *testing.T) {
+	b := newEmptyBoard(White)
+	b.halfMove = 149
+	if b.IsSeventyFiveMoveRule() {
+		t.Fatalf("expected no automatic draw at 149 plies")
+	}
+
+	b.halfMove = 150
+	if !b.IsSeventyFiveMoveRule() {
+		t.Fatalf("expected automatic draw at 150 plies")
+	}
+}
+
+func TestIsDeadPosition_SameColoredBishops(t *testing.T) {
+	b := newEmptyBoard(White)
+	b.setPiece(E1, NewPiece(King, White))
+	b.setPiece(E8, NewPiece(King, Black))
+	b.setPiece(C1, NewPiece(Bishop, White))
+	b.setPiece(E3, NewPiece(Bishop, White))
+	b.setPiece(F4, NewPiece(Bishop, Black))
+
+	if b.IsInsufficientMaterial() {
+		t.Fatalf("two white bishops are not covered by IsInsufficientMaterial")
+	}
+	if !b.IsDeadPosition() {
+		t.Fatalf("expected bishops all on one colour to be a dead position")
+	}
+
+	b.setPiece(D3, NewPiece(Bishop, Black))
+	if b.IsDeadPosition() {
+		t.Fatalf("expected bishops on both colours not to be dead")
+	}
+}
+
+func TestIsDeadPosition_LockedPawns(t *testing.T) {
+	locked, err := LoadFEN("8/8/3k4/1p1p1p1p/1P1P1P1P/3K4/8/8 w - - 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+	if !locked.IsDeadPosition() {
+		t.Fatalf("expected fully locked pawn chain to be a dead position")
+	}
+
+	open, err := LoadFEN("8/8/3k4/1p1p1p2/1P1P1P2/3K4/8/8 w - - 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+	if open.IsDeadPosition() {
+		t.Fatalf("expected king able to walk round the chain not to be dead")
+	}
+
+	capture, err := LoadFEN("8/8/3k4/1p1p1p1p/1P1P1PP1/3K4/8/8 w - - 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+	if capture.IsDeadPosition() {
+		t.Fatalf("expected position with a pawn capture available not to be dead")
+	}
+}
+
+func TestCannotForceMate_TwoKnights(t *testing.T) {
+	b := newEmptyBoard(White)
+	b.setPiece(E1, NewPiece(King, White))
+	b.setPiece(E8, NewPiece(King, Black))
+	b.setPiece(B1, NewPiece(Knight, White))
+	b.setPiece(G1, NewPiece(Knight, White))
+
+	if b.IsInsufficientMaterial() || b.IsDeadPosition() {
+		t.Fatalf("K+NN vs K is not a dead position")
+	}
+	if !b.CannotForceMate() {
+		t.Fatalf("expected K+NN vs K to be flagged as unwinnable by force")
+	}
+
+	b.setPiece(A7, NewPiece(Pawn, Black))
+	if b.CannotForceMate() {
+		t.Fatalf("did not expect flag once the defender has material")
+	}
+}
@@ -11,4 +11,9 @@ var (
 	ErrIllegalMove      = errors.New("illegal move for this piece")
 	ErrPathBlocked      = errors.New("path is blocked")
 	ErrInvalidPromotion = errors.New("invalid promotion")
+	ErrInvalidSAN       = errors.New("invalid SAN")
+	ErrAmbiguousSAN     = errors.New("ambiguous SAN")
+	ErrNoMoveToUnmake   = errors.New("no move to unmake")
+	ErrIllegalDrop      = errors.New("illegal drop")
+	ErrEmptyPocket      = errors.New("no such piece in pocket")
 )
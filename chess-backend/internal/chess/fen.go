@@ -24,6 +24,9 @@ func (b *Board) ToFEN() string {
 				empty = 0
 			}
 			sb.WriteByte(fenPieceChar(*p))
+			if b.hasPockets && b.promoted&squareBB(sq) != 0 {
+				sb.WriteByte('~')
+			}
 		}
 		if empty > 0 {
 			sb.WriteByte(byte('0' + empty))
@@ -32,6 +35,9 @@ func (b *Board) ToFEN() string {
 			sb.WriteByte('/')
 		}
 	}
+	if b.hasPockets {
+		sb.WriteString(b.fenPockets())
+	}
 
 	// side to move
 	sb.WriteByte(' ')
@@ -43,8 +49,7 @@ func (b *Board) ToFEN() string {
 
 	// castling rights
 	sb.WriteByte(' ')
-	castle := fenCastlingString(b.castling)
-	sb.WriteString(castle)
+	sb.WriteString(b.fenCastlingString())
 
 	// en passant
 	sb.WriteByte(' ')
@@ -54,6 +59,14 @@ func (b *Board) ToFEN() string {
 		sb.WriteString(b.enPassent.String())
 	}
 
+	// checks remaining, Three-check only
+	if b.countChecks {
+		sb.WriteByte(' ')
+		sb.WriteString(intToString(max(3-b.checks[White], 0)))
+		sb.WriteByte('+')
+		sb.WriteString(intToString(max(3-b.checks[Black], 0)))
+	}
+
 	// halfmove / fullmove
 	sb.WriteByte(' ')
 	sb.WriteString(intToString(b.halfMove))
@@ -87,19 +100,30 @@ func fenPieceChar(p Piece) byte {
 	return c
 }
 
-func fenCastlingString(cr CastlingRights) string {
+// fenCastlingString writes KQkq on standard boards. Chess960 boards use
+// Shredder-FEN, naming each castling rook's file (e.g. "HAha"), so that
+// LoadFEN recognises them as Chess960.
+func (b *Board) fenCastlingString() string {
 	out := make([]byte, 0, 4)
-	if cr.WhiteKingside {
-		out = append(out, 'K')
-	}
-	if cr.WhiteQueenside {
-		out = append(out, 'Q')
-	}
-	if cr.BlackKingside {
-		out = append(out, 'k')
-	}
-	if cr.BlackQueenside {
-		out = append(out, 'q')
+	for _, c := range [...]Color{White, Black} {
+		for _, side := range [...]int{castleKingside, castleQueenside} {
+			if !b.castling.has(c, side) {
+				continue
+			}
+			var ch byte
+			switch {
+			case b.chess960:
+				ch = byte('a' + b.rookFiles[c][side])
+			case side == castleKingside:
+				ch = 'k'
+			default:
+				ch = 'q'
+			}
+			if c == White {
+				ch -= 32
+			}
+			out = append(out, ch)
+		}
 	}
 	if len(out) == 0 {
 		return "-"
@@ -130,9 +154,48 @@ func intToString(n int) string {
 	return string(buf[i:])
 }
 
+// LoadFEN parses a FEN string. Castling rights are KQkq, or the castling
+// rooks' files as in Shredder-FEN and X-FEN ("HAha", "Kq" with "B" and so on);
+// files make the board a Chess960 board.
 func LoadFEN(fen string) (*Board, error) {
+	b, err := loadFEN(fen, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.checkVariantFields(b.Variant()); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// LoadChess960FEN parses a FEN string for a Chess960 game. Unlike LoadFEN it
+// also reads KQkq as X-FEN: the outermost rook on each side of the king.
+func LoadChess960FEN(fen string) (*Board, error) {
+	b, err := loadFEN(fen, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.checkVariantFields(Chess960); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func loadFEN(fen string, chess960 bool) (*Board, error) {
 
 	fields := strings.Fields(fen)
+	// Three-check adds the checks remaining after the en passant field
+	// ("3+3"), or the checks given at the end ("+0+0")
+	var checksStr string
+	if len(fields) == 7 {
+		if strings.HasPrefix(fields[6], "+") {
+			checksStr = fields[6]
+			fields = fields[:6]
+		} else {
+			checksStr = fields[4]
+			fields = append(fields[:4:4], fields[5:]...)
+		}
+	}
 	if len(fields) != 6 {
 		return nil, fmt.Errorf("invalid FEN: expected 6 fields, got %d", len(fields))
 	}
@@ -150,10 +213,25 @@ func LoadFEN(fen string) (*Board, error) {
 		enPassent: NoSquare,
 		halfMove:  0,
 		fullMove:  1,
+		chess960:  chess960,
+		rookFiles: standardRookFiles,
 	}
 
-	// piece placement
+	// Crazyhouse pockets follow the placement in brackets or as a ninth rank
 	ranks := strings.Split(placement, "/")
+	if open := strings.IndexByte(placement, '['); open >= 0 && strings.HasSuffix(placement, "]") {
+		ranks = strings.Split(placement[:open], "/")
+		if err := b.parsePockets(placement[open+1 : len(placement)-1]); err != nil {
+			return nil, err
+		}
+	} else if len(ranks) == 9 {
+		if err := b.parsePockets(ranks[8]); err != nil {
+			return nil, err
+		}
+		ranks = ranks[:8]
+	}
+
+	// piece placement
 	if len(ranks) != 8 {
 		return nil, fmt.Errorf("invalid FEN: expected 8 ranks, got %d", len(ranks))
 	}
@@ -185,6 +263,10 @@ func LoadFEN(fen string) (*Board, error) {
 			}
 			sq := Square(file*8 + boardRank)
 			b.setPiece(sq, p)
+			if i+1 < len(rankStr) && rankStr[i+1] == '~' {
+				b.promoted |= squareBB(sq)
+				i++
+			}
 			file++
 		}
 
@@ -205,19 +287,8 @@ func LoadFEN(fen string) (*Board, error) {
 
 	// castling rights
 	if castling != "-" {
-		for i := 0; i < len(castling); i++ {
-			switch castling[i] {
-			case 'K':
-				b.castling.WhiteKingside = true
-			case 'Q':
-				b.castling.WhiteQueenside = true
-			case 'k':
-				b.castling.BlackKingside = true
-			case 'q':
-				b.castling.BlackQueenside = true
-			default:
-				return nil, fmt.Errorf("invalid FEN: unknown castling char %q", castling[i])
-			}
+		if err := b.parseCastling(castling); err != nil {
+			return nil, err
 		}
 	}
 
@@ -244,9 +315,138 @@ func LoadFEN(fen string) (*Board, error) {
 	b.halfMove = half
 	b.fullMove = full
 
+	if checksStr != "" {
+		if err := b.parseChecks(checksStr); err != nil {
+			return nil, err
+		}
+	}
+	b.hash = b.computeHash()
+
 	return b, nil
 }
 
+// fenPockets writes the pockets in brackets, White's pieces first.
+func (b *Board) fenPockets() string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for c := White; c <= Black; c++ {
+		for _, pt := range dropTypes {
+			for range b.pockets[c][pt] {
+				sb.WriteByte(fenPieceChar(NewPiece(pt, c)))
+			}
+		}
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+func (b *Board) parsePockets(field string) error {
+	for i := 0; i < len(field); i++ {
+		if field[i] == '-' && len(field) == 1 {
+			break
+		}
+		p, err := pieceFromFENChar(field[i])
+		if err != nil || p.Type == King {
+			return fmt.Errorf("invalid FEN: bad pocket %q", field)
+		}
+		b.pockets[p.Color][p.Type]++
+	}
+	b.hasPockets = true
+	return nil
+}
+
+// parseChecks reads a Three-check field: "W+B" counts the checks each side
+// still needs, "+W+B" the checks each side has given.
+func (b *Board) parseChecks(field string) error {
+	given := strings.HasPrefix(field, "+")
+	parts := strings.Split(strings.TrimPrefix(field, "+"), "+")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid FEN: bad checks field %q", field)
+	}
+	for c, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || n > 3 {
+			return fmt.Errorf("invalid FEN: bad checks field %q", field)
+		}
+		if !given {
+			n = 3 - n
+		}
+		b.checks[c] = n
+	}
+	b.countChecks = true
+	return nil
+}
+
+func (b *Board) parseCastling(castling string) error {
+	if strings.ContainsFunc(castling, func(r rune) bool { return !strings.ContainsRune("KQkq", r) }) {
+		b.chess960 = true
+	}
+
+	for i := 0; i < len(castling); i++ {
+		ch := castling[i]
+		c := Black
+		if ch >= 'A' && ch <= 'Z' {
+			c = White
+			ch += 32
+		}
+
+		if !b.chess960 {
+			switch ch {
+			case 'k':
+				b.castling.set(c, castleKingside, true)
+			case 'q':
+				b.castling.set(c, castleQueenside, true)
+			}
+			continue
+		}
+
+		king := b.findKingSquare(c)
+		if king == NoSquare || king.Rank() != homeRank(c) {
+			return fmt.Errorf("invalid FEN: castling right %q without a king on its home rank", castling[i])
+		}
+		file := -1
+		switch {
+		case ch == 'k':
+			file = b.outermostRookFile(c, king, 1)
+		case ch == 'q':
+			file = b.outermostRookFile(c, king, -1)
+		case ch >= 'a' && ch <= 'h':
+			file = int(ch - 'a')
+		default:
+			return fmt.Errorf("invalid FEN: unknown castling char %q", castling[i])
+		}
+		if file < 0 || file == king.File() {
+			return fmt.Errorf("invalid FEN: no rook for castling right %q", castling[i])
+		}
+		if rook := b.PieceAt(Square(file*8 + king.Rank())); rook == nil || rook.Type != Rook || rook.Color != c {
+			return fmt.Errorf("invalid FEN: no rook for castling right %q", castling[i])
+		}
+
+		side := castleKingside
+		if file < king.File() {
+			side = castleQueenside
+		}
+		b.rookFiles[c][side] = file
+		b.castling.set(c, side, true)
+	}
+	return nil
+}
+
+// outermostRookFile finds the rook furthest from the king in direction dir
+// (1 towards h, -1 towards a), or -1 if there is none.
+func (b *Board) outermostRookFile(c Color, king Square, dir int) int {
+	start, stop := 7, king.File()
+	if dir < 0 {
+		start = 0
+	}
+	for file := start; file != stop; file -= dir {
+		if p := b.PieceAt(Square(file*8 + king.Rank())); p != nil && p.Type == Rook && p.Color == c {
+			return file
+		}
+	}
+	return -1
+}
+
 func pieceFromFENChar(ch byte) (Piece, error) {
 	color := Black
 	if ch >= 'A' && ch <= 'Z' {
@@ -0,0 +1,33 @@
+package chess
+
+// HordeFEN is the Horde starting position: 36 white pawns against Black's
+// full army.
+const HordeFEN = "rnbqkbnr/pppppppp/8/1PP2PP1/PPPPPPPP/PPPPPPPP/PPPPPPPP/PPPPPPPP w kq - 0 1"
+
+// Horde pits a horde of white pawns, and whatever they promote to, against
+// Black's ordinary army. White has no king and wins by mating Black; Black
+// wins by capturing every white piece. Pawns on the first rank may step two
+// squares, though not en passant.
+var Horde Variant = horde{}
+
+type horde struct{}
+
+func (horde) Name() string     { return "horde" }
+func (horde) Title() string    { return "Horde" }
+func (horde) NewBoard() *Board { return newVariantBoard(Horde, HordeFEN) }
+
+func (horde) setup(b *Board) { b.horde = true }
+
+func (horde) FilterMoves(b *Board, pseudo []Move) []Move {
+	return b.KingSafeMoves(pseudo)
+}
+
+func (horde) Outcome(b *Board) (Outcome, bool) {
+	if b.bb.colors[White] == 0 {
+		return win(Black, EndAllPiecesLost)
+	}
+	if o, ok := b.mateOutcome(); ok {
+		return o, ok
+	}
+	return b.drawRuleOutcome()
+}
@@ -0,0 +1,98 @@
+package chess
+
+import "testing"
+
+func TestHorde_FirstRankPawns(t *testing.T) {
+	b := Horde.NewBoard()
+	if got := b.ToFEN(); got != HordeFEN {
+		t.Fatalf("unexpected start FEN: %s", got)
+	}
+	if b.InCheck(White) {
+		t.Fatalf("expected the kingless horde not to be in check")
+	}
+	if got := len(b.LegalMoves()); got != 8 {
+		t.Fatalf("expected 8 moves from the start, got %d", got)
+	}
+
+	// a first-rank pawn steps one or two squares, leaving no en passant
+	b, err := LoadVariantFEN(Horde, "4k3/8/8/8/8/8/8/P7 w - - 0 1")
+	if err != nil {
+		t.Fatalf("LoadVariantFEN error: %v", err)
+	}
+	if got := len(b.LegalMoves()); got != 2 {
+		t.Fatalf("expected a2 and a3, got %v", b.LegalMoves())
+	}
+	playUCI(t, b, "a1a3")
+	if got := b.ToFEN(); got != "4k3/8/8/8/8/P7/8/8 b - - 0 1" {
+		t.Fatalf("unexpected FEN after the double step: %s", got)
+	}
+
+	// only in Horde
+	std, _ := LoadFEN("4k3/8/8/8/8/8/8/P3K3 w - - 0 1")
+	if err := ValidateMove(std, NewMove(A1, A3)); err == nil {
+		t.Fatalf("expected no double step from the first rank in standard chess")
+	}
+	for _, m := range std.LegalMoves() {
+		if m == NewMove(A1, A3) {
+			t.Fatalf("expected a1a3 not to be generated in standard chess")
+		}
+	}
+}
+
+func TestHorde_Outcome(t *testing.T) {
+	// capturing the last white piece wins for Black
+	b, _ := LoadVariantFEN(Horde, "4k3/8/8/8/8/8/1q6/P7 b - - 0 1")
+	playUCI(t, b, "b2a1")
+	o, over := b.Outcome()
+	if !over || o.Winner != Black || o.Reason != EndAllPiecesLost {
+		t.Fatalf("expected Black to win with the horde gone, got %+v (%t)", o, over)
+	}
+
+	// White wins by mate
+	b, _ = LoadVariantFEN(Horde, "k7/8/1Q6/8/8/8/8/7R w - - 0 1")
+	playUCI(t, b, "h1h8")
+	o, over = b.Outcome()
+	if !over || o.Winner != White || o.Reason != EndCheckmate {
+		t.Fatalf("expected White to mate, got %+v (%t)", o, over)
+	}
+}
+
+func TestHorde_Perft(t *testing.T) {
+	b := Horde.NewBoard()
+	for depth, want := range []uint64{8, 128, 1274, 23310} {
+		if got := b.Perft(depth + 1); got != want {
+			t.Fatalf("perft(%d): expected %d, got %d", depth+1, want, got)
+		}
+	}
+
+	b, _ = LoadVariantFEN(Horde, "4k3/pp4q1/3P2p1/8/P3PP2/PPP2r2/PPP5/PPPP4 b - - 0 1")
+	for depth, want := range []uint64{30, 241, 6633, 56539} {
+		if got := b.Perft(depth + 1); got != want {
+			t.Fatalf("open flank perft(%d): expected %d, got %d", depth+1, want, got)
+		}
+	}
+}
+
+func TestOddsByName(t *testing.T) {
+	cases := map[string]Odds{
+		"knight":        KnightOdds,
+		"Knight odds":   KnightOdds,
+		"rook-odds":     RookOdds,
+		"queen":         QueenOdds,
+		"pawn and move": PawnAndMove,
+		"pawnAndMove":   PawnAndMove,
+	}
+	for name, want := range cases {
+		if got, ok := OddsByName(name); !ok || got != want {
+			t.Fatalf("OddsByName(%q) = %v, expected %s", name, got, want.Name)
+		}
+	}
+	if _, ok := OddsByName("bishop"); ok {
+		t.Fatalf("expected unknown odds to be rejected")
+	}
+
+	b := KnightOdds.NewBoard()
+	if b.Variant() != Standard || b.PieceAt(B1) != nil || b.ToFEN() != KnightOdds.FEN {
+		t.Fatalf("expected a standard game without the b1 knight, got %s", b.ToFEN())
+	}
+}
@@ -0,0 +1,41 @@
+package chess
+
+// KingOfTheHill is won by checkmate or by bringing the king to one of the
+// four centre squares. A lone king can still reach the hill, so there are no
+// draws by material.
+var KingOfTheHill Variant = kingOfTheHill{}
+
+// hill is d4, d5, e4 and e5.
+var hill = squareBB(D4) | squareBB(D5) | squareBB(E4) | squareBB(E5)
+
+type kingOfTheHill struct{}
+
+func (kingOfTheHill) Name() string     { return "kingOfTheHill" }
+func (kingOfTheHill) Title() string    { return "King of the Hill" }
+func (kingOfTheHill) NewBoard() *Board { return newVariantBoard(KingOfTheHill, StartingFEN) }
+
+func (kingOfTheHill) FilterMoves(b *Board, pseudo []Move) []Move {
+	return b.KingSafeMoves(pseudo)
+}
+
+func (kingOfTheHill) Outcome(b *Board) (Outcome, bool) {
+	// normally only the side that just moved can have arrived
+	for _, c := range [...]Color{b.turn.Opposite(), b.turn} {
+		if b.bb.pieces[c][King]&hill != 0 {
+			return win(c, EndKingOfTheHill)
+		}
+	}
+	if o, ok := b.mateOutcome(); ok {
+		return o, ok
+	}
+	return b.drawRuleOutcome()
+}
+
+// newVariantBoard loads one of the variants' fixed start positions.
+func newVariantBoard(v Variant, fen string) *Board {
+	b, err := LoadVariantFEN(v, fen)
+	if err != nil {
+		panic("chess: invalid start position for " + v.Name() + ": " + err.Error())
+	}
+	return b
+}
@@ -3,103 +3,62 @@ package chess
 type KingValidator struct{}
 
 func (v *KingValidator) IsLegalMove(board *Board, move Move) bool {
-	fileDiff := abs(move.From.File() - move.To.File())
-	rankDiff := abs(move.From.Rank() - move.To.Rank())
-
-	if fileDiff <= 1 && rankDiff <= 1 {
-		return true
-	}
-
-	// castling: king moves 2 files, stays in same rank
-	if rankDiff == 0 && fileDiff == 2 {
+	if king := board.PieceAt(move.From); king != nil && board.isCastle(*king, move) {
 		return v.isLegalCastle(board, move)
 	}
 
-	return false
+	fileDiff := abs(move.From.File() - move.To.File())
+	rankDiff := abs(move.From.Rank() - move.To.Rank())
+
+	return fileDiff <= 1 && rankDiff <= 1
 }
 
+// isLegalCastle checks the castling rules except for the king's final square,
+// which ValidateMove and LegalMoves test like any other move: the right must
+// be held, the path clear and the king neither in check nor passing through
+// an attacked square.
 func (v *KingValidator) isLegalCastle(board *Board, move Move) bool {
 	king := board.PieceAt(move.From)
-	if king == nil || king.Type != King {
+	if king == nil || !board.isCastle(*king, move) {
+		return false
+	}
+	if move.From.Rank() != homeRank(king.Color) {
 		return false
 	}
 
-	if !board.IsEmpty(move.To) {
+	side := castleSide(move)
+	if !board.castling.has(king.Color, side) {
 		return false
 	}
 
-	homeRank := 0
-	if king.Color == Black {
-		homeRank = 7
+	kingTo, rookFrom, rookTo := board.castleSquares(move, king.Color)
+	if !board.chess960 && move.To != kingTo {
+		return false
+	}
+	if rookFrom != board.castlingRook(king.Color, side) {
+		return false
+	}
+	rook := board.PieceAt(rookFrom)
+	if rook == nil || rook.Type != Rook || rook.Color != king.Color {
+		return false
 	}
-	if move.From.File() != 4 || move.From.Rank() != homeRank { // must be E1/E8
+	if !board.castlePathClear(move.From, rookFrom, kingTo, rookTo) {
 		return false
 	}
 
 	if board.InCheck(king.Color) {
 		return false
 	}
-
-	if move.To.File() == 6 && move.To.Rank() == homeRank {
-		if king.Color == White && !board.castling.WhiteKingside {
-			return false
-		}
-		if king.Color == Black && !board.castling.BlackKingside {
-			return false
-		}
-
-		rookSq := Square(7*8 + homeRank)
-		rook := board.PieceAt(rookSq)
-		if rook == nil || rook.Type != Rook || rook.Color != king.Color {
-			return false
-		}
-
-		fSq := Square(5*8 + homeRank)
-		gSq := Square(6*8 + homeRank)
-		if !board.IsEmpty(fSq) || !board.IsEmpty(gSq) {
-			return false
-		}
-
-		if board.IsSquareAttacked(fSq, king.Color.Opposite()) {
-			return false
-		}
-		if board.IsSquareAttacked(gSq, king.Color.Opposite()) {
-			return false
-		}
-
-		return true
+	step := 1
+	if kingTo.File() < move.From.File() {
+		step = -1
 	}
-
-	if move.To.File() == 2 && move.To.Rank() == homeRank {
-		if king.Color == White && !board.castling.WhiteQueenside {
+	for file := move.From.File(); file != kingTo.File(); {
+		file += step
+		if board.IsSquareAttacked(Square(file*8+kingTo.Rank()), king.Color.Opposite()) {
 			return false
 		}
-		if king.Color == Black && !board.castling.BlackQueenside {
-			return false
-		}
-
-		rookSq := Square(0*8 + homeRank)
-		rook := board.PieceAt(rookSq)
-		if rook == nil || rook.Type != Rook || rook.Color != king.Color {
-			return false
-		}
-
-		dSq := Square(3*8 + homeRank)
-		cSq := Square(2*8 + homeRank)
-		bSq := Square(1*8 + homeRank)
-		if !board.IsEmpty(dSq) || !board.IsEmpty(cSq) || !board.IsEmpty(bSq) {
-			return false
-		}
-
-		if board.IsSquareAttacked(dSq, king.Color.Opposite()) {
-			return false
-		}
-		if board.IsSquareAttacked(cSq, king.Color.Opposite()) {
-			return false
-		}
-
-		return true
 	}
 
-	return false
+	return true
 }
@@ -4,10 +4,13 @@ import (
 	"fmt"
 )
 
+// Move is a piece moving From one square To another, or, in Crazyhouse, a
+// Drop of a pocketed piece on To, with From set to NoSquare.
 type Move struct {
 	From      Square
 	To        Square
 	Promotion PieceType
+	Drop      PieceType // meaningful only when From is NoSquare
 }
 
 func NewMove(from, to Square) Move {
@@ -26,11 +29,29 @@ func NewMoveWithPromotion(from, to Square, promotion_piece PieceType) Move {
 	}
 }
 
+// NewDrop is a Crazyhouse move dropping a piece of type pt from the pocket
+// on to.
+func NewDrop(pt PieceType, to Square) Move {
+	return Move{
+		From: NoSquare,
+		To:   to,
+		Drop: pt,
+	}
+}
+
 func (m Move) isPromotion() bool {
 	return m.Promotion != 0
 }
 
+// IsDrop reports whether the move drops a piece from the pocket.
+func (m Move) IsDrop() bool {
+	return m.From == NoSquare
+}
+
 func (m Move) String() string {
+	if m.IsDrop() {
+		return m.UCI()
+	}
 	if m.Promotion != 0 {
 		return fmt.Sprintf("%s%s=%s", m.From, m.To, m.Promotion)
 	}
@@ -1,248 +1,137 @@
 package chess
 
+// LegalMoves lists the moves the board's variant allows in the position.
 func (b *Board) LegalMoves() []Move {
-
-	pseudo := b.PseudoLegalMoves()
-
-	legal := make([]Move, 0, len(pseudo))
-	for _, m := range pseudo {
-		if err := ValidateBasicMove(b, m); err != nil {
-			continue
-		}
-
-		p := b.PieceAt(m.From)
-		if p == nil {
-			continue
-		}
-		v := getValidator(p.Type)
-		if !v.IsLegalMove(b, m) {
-			continue
-		}
-
-		sim := b.Clone()
-		sim.applyMoveNoValidate(m)
-		if sim.InCheck(p.Color) {
-			continue
-		}
-
-		legal = append(legal, m)
+	if b.variant != nil {
+		return b.variant.FilterMoves(b, b.PseudoLegalMoves())
 	}
-
-	return legal
+	return b.KingSafeMoves(b.PseudoLegalMoves())
 }
 
+// PseudoLegalMoves lists moves that follow the piece movement rules but may
+// leave the mover's king in check. Castling is included whenever the right is
+// held and the squares between king and rook are empty, so every move can be
+// played with DoMove; LegalMoves checks the attacked squares.
 func (b *Board) PseudoLegalMoves() []Move {
 	moves := make([]Move, 0, 64)
 
-	for from := A1; from <= H8; from++ {
-		p := b.PieceAt(from)
-		if p == nil || p.Color != b.turn {
-			continue
-		}
+	us := b.turn
+	own := b.bb.colors[us]
+	enemy := b.bb.colors[us.Opposite()]
+	occupied := own | enemy
 
-		switch p.Type {
+	for pieces := own; pieces != 0; {
+		from := popLSB(&pieces)
+
+		var targets uint64
+		switch b.squares[from].Type {
 		case Pawn:
-			moves = append(moves, b.pawnPseudoMoves(from)...)
+			moves = b.appendPawnMoves(moves, from, enemy, occupied)
+			continue
 		case Knight:
-			moves = append(moves, b.knightPseudoMoves(from)...)
+			targets = knightAttacks[from]
 		case Bishop:
-			moves = append(moves, b.sliderPseudoMoves(from, DiagonalDirections)...)
+			targets = bishopAttacks(from, occupied)
 		case Rook:
-			moves = append(moves, b.sliderPseudoMoves(from, StraightDirections)...)
+			targets = rookAttacks(from, occupied)
 		case Queen:
-			all := append(StraightDirections, DiagonalDirections...)
-			moves = append(moves, b.sliderPseudoMoves(from, all)...)
+			targets = queenAttacks(from, occupied)
 		case King:
-			moves = append(moves, b.kingPseudoMoves(from)...)
+			targets = kingAttacks[from]
+			moves = b.appendCastlingCandidates(moves, from)
 		}
-	}
-
-	return moves
-}
 
-func (b *Board) knightPseudoMoves(from Square) []Move {
-	moves := []Move{}
-	p := b.PieceAt(from)
-	if p == nil {
-		return moves
-	}
-
-	offsets := [][2]int{
-		{2, 1}, {2, -1}, {-2, 1}, {-2, -1},
-		{1, 2}, {1, -2}, {-1, 2}, {-1, -2},
+		for targets &^= own; targets != 0; {
+			moves = append(moves, NewMove(from, popLSB(&targets)))
+		}
 	}
 
-	f := from.File()
-	r := from.Rank()
-
-	for _, o := range offsets {
-		nf := f + o[0] //newfile
-		nr := r + o[1] //newrank
-		if nf < 0 || nf > 7 || nr < 0 || nr > 7 {
-			continue
-		}
-		to := Square(nf*8 + nr)
-		dest := b.PieceAt(to)
-		if dest == nil || dest.Color != p.Color {
-			moves = append(moves, NewMove(from, to))
-		}
+	if b.hasPockets {
+		moves = b.appendDrops(moves, ^occupied)
 	}
 
 	return moves
 }
 
-func (b *Board) kingPseudoMoves(from Square) []Move {
-	moves := []Move{}
-	p := b.PieceAt(from)
-	if p == nil {
-		return moves
-	}
-
-	f := from.File()
-	r := from.Rank()
+func (b *Board) appendPawnMoves(moves []Move, from Square, enemy, occupied uint64) []Move {
+	color := b.turn
 
-	for df := -1; df <= 1; df++ {
-		for dr := -1; dr <= 1; dr++ {
-			if df == 0 && dr == 0 {
-				continue
-			}
-			nf := f + df
-			nr := r + dr
-			if nf < 0 || nf > 7 || nr < 0 || nr > 7 {
-				continue
-			}
-			to := Square(nf*8 + nr)
-			dest := b.PieceAt(to)
-			if dest == nil || dest.Color != p.Color {
-				moves = append(moves, NewMove(from, to))
-			}
-		}
+	startRank, promoRank := 1, 7
+	if color == Black {
+		startRank, promoRank = 6, 0
 	}
 
-	homeRank := 0
-	if p.Color == Black {
-		homeRank = 7
-	}
-	if from.File() == 4 && from.Rank() == homeRank { // E1/E8
-		// E -> G (kingside), E -> C (queenside)
-		moves = append(moves, NewMove(from, Square(6*8+homeRank)))
-		moves = append(moves, NewMove(from, Square(2*8+homeRank)))
+	// in Horde, pawns on the first rank may also step two squares
+	doubleStep := from.Rank() == startRank || b.horde && from.Rank() == homeRank(color)
+
+	var pushes uint64
+	switch {
+	case from.Rank() == promoRank:
+		// only in hand-built positions; shifting would wrap into the next file
+	case color == White:
+		pushes = squareBB(from) << 1 &^ occupied
+		if doubleStep && pushes != 0 {
+			pushes |= pushes << 1 &^ occupied
+		}
+	default:
+		pushes = squareBB(from) >> 1 &^ occupied
+		if doubleStep && pushes != 0 {
+			pushes |= pushes >> 1 &^ occupied
+		}
 	}
 
-	return moves
-}
-
-func (b *Board) sliderPseudoMoves(from Square, dirs []Direction) []Move {
-	moves := []Move{}
-	p := b.PieceAt(from)
-	if p == nil {
-		return moves
+	captures := pawnAttacks[color][from] & enemy
+	if b.enPassent != NoSquare {
+		// promotion is not possible with an en passant capture
+		captures |= pawnAttacks[color][from] & squareBB(b.enPassent)
 	}
 
-	ff := from.File()
-	fr := from.Rank()
-
-	for _, d := range dirs {
-		f := ff + d.FileStep
-		r := fr + d.RankStep
-
-		for f >= 0 && f <= 7 && r >= 0 && r <= 7 {
-			to := Square(f*8 + r)
-			dest := b.PieceAt(to)
-
-			if dest == nil {
-				moves = append(moves, NewMove(from, to))
-			} else {
-				// capture enemy then stop
-				if dest.Color != p.Color {
-					moves = append(moves, NewMove(from, to))
-				}
-				break
-			}
-
-			f += d.FileStep
-			r += d.RankStep
+	for targets := pushes | captures; targets != 0; {
+		to := popLSB(&targets)
+		if to.Rank() == promoRank {
+			moves = append(moves,
+				NewMoveWithPromotion(from, to, Queen),
+				NewMoveWithPromotion(from, to, Rook),
+				NewMoveWithPromotion(from, to, Bishop),
+				NewMoveWithPromotion(from, to, Knight),
+			)
+			continue
 		}
+		moves = append(moves, NewMove(from, to))
 	}
-
 	return moves
 }
 
-func (b *Board) pawnPseudoMoves(from Square) []Move {
-	moves := []Move{}
-	p := b.PieceAt(from)
-	if p == nil {
+// appendCastlingCandidates adds the castling moves whose right is held and
+// whose path is clear, written as the king taking the rook in Chess960.
+func (b *Board) appendCastlingCandidates(moves []Move, from Square) []Move {
+	us := b.turn
+	if from.Rank() != homeRank(us) {
 		return moves
 	}
-
-	dir := 1
-	startRank := 1
-	promoRank := 7
-	if p.Color == Black {
-		dir = -1
-		startRank = 6
-		promoRank = 0
+	if !b.chess960 && from.File() != 4 { // E1/E8
+		return moves
 	}
 
-	f := from.File()
-	r := from.Rank()
-
-	oneRank := r + dir
-	if oneRank >= 0 && oneRank <= 7 {
-		one := Square(f*8 + oneRank)
-
-		// forward 1 if empty
-		if b.IsEmpty(one) {
-			if oneRank == promoRank {
-				moves = append(moves,
-					NewMoveWithPromotion(from, one, Queen),
-					NewMoveWithPromotion(from, one, Rook),
-					NewMoveWithPromotion(from, one, Bishop),
-					NewMoveWithPromotion(from, one, Knight),
-				)
-			} else {
-				moves = append(moves, NewMove(from, one))
-			}
-
-			// forward 2 from start if both empty
-			twoRank := r + 2*dir
-			if r == startRank && twoRank >= 0 && twoRank <= 7 {
-				two := Square(f*8 + twoRank)
-				if b.IsEmpty(two) {
-					moves = append(moves, NewMove(from, two))
-				}
-			}
+	for _, side := range [...]int{castleKingside, castleQueenside} {
+		if !b.castling.has(us, side) {
+			continue
 		}
-
-		// captures (diagonals) + en passant
-		for _, df := range []int{-1, 1} {
-			nf := f + df
-			if nf < 0 || nf > 7 {
-				continue
-			}
-			to := Square(nf*8 + oneRank)
-			dest := b.PieceAt(to)
-
-			// normal capture
-			if dest != nil && dest.Color != p.Color {
-				if oneRank == promoRank {
-					moves = append(moves,
-						NewMoveWithPromotion(from, to, Queen),
-						NewMoveWithPromotion(from, to, Rook),
-						NewMoveWithPromotion(from, to, Bishop),
-						NewMoveWithPromotion(from, to, Knight),
-					)
-				} else {
-					moves = append(moves, NewMove(from, to))
-				}
-			}
-
-			// en passant capture candidate
-			if dest == nil && b.enPassent == to {
-				moves = append(moves, NewMove(from, to)) // promotion not allowed with capture
+		rookFrom := b.castlingRook(us, side)
+		if rook := b.squares[rookFrom]; rook == nil || rook.Type != Rook || rook.Color != us {
+			continue
+		}
+		move := NewMove(from, rookFrom)
+		if !b.chess960 {
+			move.To = Square(6*8 + from.Rank()) // E -> G
+			if side == castleQueenside {
+				move.To = Square(2*8 + from.Rank()) // E -> C
 			}
 		}
+		kingTo, _, rookTo := b.castleSquares(move, us)
+		if b.castlePathClear(from, rookFrom, kingTo, rookTo) {
+			moves = append(moves, move)
+		}
 	}
-
 	return moves
 }
@@ -0,0 +1,42 @@
+package chess
+
+import "strings"
+
+// Odds is a classic handicap game: standard chess from the starting position
+// with material taken from the stronger player's side. By tradition the
+// stronger player has White, except in pawn and move, where they give up
+// the f-pawn and the first move by taking Black.
+type Odds struct {
+	// Name identifies the odds in the API, e.g. "knight".
+	Name  string
+	Title string
+	FEN   string
+}
+
+var (
+	PawnAndMove = Odds{"pawnAndMove", "Pawn and move", "rnbqkbnr/ppppp1pp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"}
+	KnightOdds  = Odds{"knight", "Knight odds", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/R1BQKBNR w KQkq - 0 1"}
+	RookOdds    = Odds{"rook", "Rook odds", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/1NBQKBNR w Kkq - 0 1"}
+	QueenOdds   = Odds{"queen", "Queen odds", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNB1KBNR w KQkq - 0 1"}
+)
+
+// OddsGames lists every supported handicap.
+var OddsGames = []Odds{PawnAndMove, KnightOdds, RookOdds, QueenOdds}
+
+// OddsByName finds a handicap by its Name or Title, ignoring case, spaces,
+// dashes and a trailing "odds", so "knight", "Knight odds" and
+// "knight-odds" all match.
+func OddsByName(name string) (Odds, bool) {
+	key := strings.TrimSuffix(variantKey(name), "odds")
+	for _, o := range OddsGames {
+		if key == strings.TrimSuffix(variantKey(o.Name), "odds") || key == strings.TrimSuffix(variantKey(o.Title), "odds") {
+			return o, true
+		}
+	}
+	return Odds{}, false
+}
+
+// NewBoard sets up the handicap's starting position.
+func (o Odds) NewBoard() *Board {
+	return newVariantBoard(Standard, o.FEN)
+}
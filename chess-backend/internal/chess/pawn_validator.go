@@ -32,13 +32,14 @@ func (v *PawnValidator) IsLegalMove(board *Board, move Move) bool {
 
 		// single square forward
 		if rankDiff == direction {
-			return v.checkPromotion(move, piece.Color)
+			return v.checkPromotion(board, move, piece.Color)
 		}
 
-		// Two squares forward from starting position
-		if rankDiff == 2*direction && move.From.Rank() == startRank {
+		// Two squares forward from the starting position, or from the first
+		// rank in Horde
+		if rankDiff == 2*direction && (move.From.Rank() == startRank || board.horde && move.From.Rank() == homeRank(piece.Color)) {
 			middleSquare := Square(move.From.File()*8 + move.From.Rank() + direction)
-			return board.IsEmpty(middleSquare) && v.checkPromotion(move, piece.Color)
+			return board.IsEmpty(middleSquare) && v.checkPromotion(board, move, piece.Color)
 		}
 
 		return false
@@ -47,7 +48,7 @@ func (v *PawnValidator) IsLegalMove(board *Board, move Move) bool {
 	if abs(fileDiff) == 1 && rankDiff == direction {
 		// Normal capture
 		if destPiece != nil && destPiece.Color != piece.Color {
-			return v.checkPromotion(move, piece.Color)
+			return v.checkPromotion(board, move, piece.Color)
 		}
 
 		// en passant capture (destination must be empty, captured pawn must exist).
@@ -64,7 +65,7 @@ func (v *PawnValidator) IsLegalMove(board *Board, move Move) bool {
 	return false
 }
 
-func (v *PawnValidator) checkPromotion(move Move, color Color) bool {
+func (v *PawnValidator) checkPromotion(board *Board, move Move, color Color) bool {
 
 	promotionRank := 7
 	if color == Black {
@@ -75,7 +76,8 @@ func (v *PawnValidator) checkPromotion(move Move, color Color) bool {
 		return move.Promotion == Queen ||
 			move.Promotion == Rook ||
 			move.Promotion == Bishop ||
-			move.Promotion == Knight
+			move.Promotion == Knight ||
+			move.Promotion == King && board.commonKings
 	}
 
 	return move.Promotion == 0
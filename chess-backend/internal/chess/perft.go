@@ -0,0 +1,38 @@
+package chess
+
+// Perft counts the leaf nodes of the legal move tree depth plies deep. The
+// counts for well-known positions are published, which makes this the
+// standard way to check a move generator.
+func (b *Board) Perft(depth int) uint64 {
+	if depth <= 0 {
+		return 1
+	}
+
+	moves := b.LegalMoves()
+	if depth == 1 {
+		return uint64(len(moves))
+	}
+
+	var nodes uint64
+	for _, m := range moves {
+		u := b.DoMove(m)
+		nodes += b.Perft(depth - 1)
+		b.UndoMove(u)
+	}
+	return nodes
+}
+
+// Divide runs Perft(depth-1) below each legal move, which narrows a wrong
+// total down to the move whose subtree disagrees with a reference engine.
+func (b *Board) Divide(depth int) map[Move]uint64 {
+	counts := make(map[Move]uint64)
+	if depth <= 0 {
+		return counts
+	}
+	for _, m := range b.LegalMoves() {
+		u := b.DoMove(m)
+		counts[m] = b.Perft(depth - 1)
+		b.UndoMove(u)
+	}
+	return counts
+}
@@ -0,0 +1,6 @@
+//go:build perft
+
+package chess
+
+// perftNodeLimit covers every published count; expect this to take a minute.
+const perftNodeLimit = 200_000_000
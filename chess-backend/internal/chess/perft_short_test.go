@@ -0,0 +1,6 @@
+//go:build !perft
+
+package chess
+
+// perftNodeLimit keeps the default suite fast enough for CI.
+const perftNodeLimit = 1_500_000
@@ -0,0 +1,99 @@
+package chess
+
+import "testing"
+
+type perftCase struct {
+	name  string
+	fen   string
+	nodes []uint64 // nodes[d-1] is the published count at depth d; 0 means not listed
+}
+
+// Published counts from the chessprogramming wiki perft results and Peter
+// Jones' collection of move generator edge cases.
+var perftCases = []perftCase{
+	{"initial", StartingFEN, []uint64{20, 400, 8902, 197281, 4865609, 119060324}},
+	{"kiwipete", kiwipeteFEN, []uint64{48, 2039, 97862, 4085603, 193690690}},
+	{"position 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", []uint64{14, 191, 2812, 43238, 674624, 11030083}},
+	{"position 4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", []uint64{6, 264, 9467, 422333, 15833292}},
+	{"position 4 mirrored", "r2q1rk1/pP1p2pp/Q4n2/bbp1p3/Np6/1B3NBn/pPPP1PPP/R3K2R b KQ - 0 1", []uint64{6, 264, 9467, 422333, 15833292}},
+	{"position 5", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", []uint64{44, 1486, 62379, 2103487, 89941194}},
+	{"position 6", "r4rk1/1pp1qppp/p1np1n2/2b1p1B1/2B1P1b1/P1NP1N2/1PP1QPPP/R4RK1 w - - 0 10", []uint64{46, 2079, 89890, 3894594, 164075551}},
+
+	{"illegal en passant exposes king", "3k4/3p4/8/K1P4r/8/8/8/8 b - - 0 1", []uint64{0, 0, 0, 0, 0, 1134888}},
+	{"en passant gives discovered check", "8/8/4k3/8/2p5/8/B2P2K1/8 w - - 0 1", []uint64{0, 0, 0, 0, 0, 1015133}},
+	{"en passant capture gives check", "8/8/1k6/2b5/2pP4/8/5K2/8 b - d3 0 1", []uint64{0, 0, 0, 0, 0, 1440467}},
+	{"short castling gives check", "5k2/8/8/8/8/8/8/4K2R w K - 0 1", []uint64{0, 0, 0, 0, 0, 661072}},
+	{"long castling gives check", "3k4/8/8/8/8/8/8/R3K3 w Q - 0 1", []uint64{0, 0, 0, 0, 0, 803711}},
+	{"castling rights lost on capture", "r3k2r/1b4bq/8/8/8/8/7B/R3K2R w KQkq - 0 1", []uint64{0, 0, 0, 1274206}},
+	{"castling prevented", "r3k2r/8/3Q4/8/8/5q2/8/R3K2R b KQkq - 0 1", []uint64{0, 0, 0, 1720476}},
+	{"promote out of check", "2K2r2/4P3/8/8/8/8/8/3k4 w - - 0 1", []uint64{0, 0, 0, 0, 0, 3821001}},
+	{"discovered check", "8/8/1P2K3/8/2n5/1q6/8/5k2 b - - 0 1", []uint64{0, 0, 0, 0, 1004658}},
+	{"promote to give check", "4k3/1P6/8/8/8/8/K7/8 w - - 0 1", []uint64{0, 0, 0, 0, 0, 217342}},
+	{"underpromote to give check", "8/P1k5/K7/8/8/8/8/8 w - - 0 1", []uint64{0, 0, 0, 0, 0, 92683}},
+	{"self stalemate", "K1k5/8/P7/8/8/8/8/8 w - - 0 1", []uint64{0, 0, 0, 0, 0, 2217}},
+	{"stalemate and checkmate", "8/k1P5/8/1K6/8/8/8/8 w - - 0 1", []uint64{0, 0, 0, 0, 0, 0, 567584}},
+	{"double check", "8/8/2k5/5q2/5n2/8/5K2/8 b - - 0 1", []uint64{0, 0, 0, 23527}},
+
+	// Chess960 positions in Shredder-FEN, from the chessprogramming wiki
+	{"chess960 king and rook cross", "bqnb1rkr/pp3ppp/3ppn2/2p5/5P2/P2P4/NPP1P1PP/BQ1BNRKR w HFhf - 2 9", []uint64{21, 528, 12189, 326672, 8146062}},
+	{"chess960 rook beside king", "2nnrbkr/p1qppppp/8/1ppb4/6PP/3PP3/PPP2P2/BQNNRBKR w HEhe - 1 9", []uint64{21, 807, 18002, 667366}},
+	{"chess960 white rights only", "b1q1rrkb/pppppppp/3nn3/8/P7/1PPP4/4PPPP/BQNNRKRB w GE - 1 9", []uint64{20, 479, 10471, 273318, 6417013}},
+	{"chess960 black rights only", "qbbnnrkr/2pp2pp/p7/1p2pp2/8/P3PP2/1PPP1KPP/QBBNNR1R w hf - 0 9", []uint64{22, 593, 13440, 382958, 9183776}},
+	{"chess960 queenside rook on b", "1rqbkrbn/1ppppp1p/1n6/p1N3p1/8/2P4P/PP1PPPP1/1RQBKRBN w FBfb - 0 9", []uint64{29, 502, 14569, 287739, 8652810}},
+	{"chess960 rooks on a and h", "rbbqn1kr/pp2p1pp/6n1/2pp1p2/2P4P/P7/BP1PPPP1/R1BQNNKR w HAha - 0 9", []uint64{27, 916, 25798, 890435}},
+}
+
+// TestPerft runs every listed depth up to perftNodeLimit nodes. Build with
+// -tags perft to run the deep counts as well.
+func TestPerft(t *testing.T) {
+	for _, tc := range perftCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := LoadFEN(tc.fen)
+			if err != nil {
+				t.Fatalf("load fen: %v", err)
+			}
+			for i, want := range tc.nodes {
+				if want == 0 || want > perftNodeLimit {
+					continue
+				}
+				if got := b.Perft(i + 1); got != want {
+					t.Fatalf("depth %d: expected %d nodes, got %d", i+1, want, got)
+				}
+			}
+			if b.ToFEN() != tc.fen {
+				t.Fatalf("perft left the board changed: %q", b.ToFEN())
+			}
+		})
+	}
+}
+
+func TestDivide(t *testing.T) {
+	b := NewBoard()
+	hash := b.Hash()
+
+	counts := b.Divide(3)
+	if len(counts) != 20 {
+		t.Fatalf("expected 20 root moves, got %d", len(counts))
+	}
+
+	var total uint64
+	for _, n := range counts {
+		total += n
+	}
+	if total != 8902 {
+		t.Fatalf("expected divide to sum to 8902, got %d", total)
+	}
+
+	for uci, want := range map[string]uint64{"e2e4": 600, "a2a3": 380, "b2b4": 421, "g1f3": 440} {
+		move, err := ParseUCI(uci)
+		if err != nil {
+			t.Fatalf("parse %s: %v", uci, err)
+		}
+		if counts[move] != want {
+			t.Fatalf("%s: expected %d, got %d", uci, want, counts[move])
+		}
+	}
+	if b.Hash() != hash || b.ToFEN() != StartingFEN {
+		t.Fatalf("divide left the board changed")
+	}
+}
@@ -0,0 +1,162 @@
+package chess
+
+import (
+	"io"
+	"sort"
+	"strings"
+)
+
+const StartingFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+const (
+	ResultWhiteWins = "1-0"
+	ResultBlackWins = "0-1"
+	ResultDraw      = "1/2-1/2"
+	ResultUnknown   = "*"
+)
+
+const pgnLineWidth = 80
+
+// sevenTagRoster is the mandatory tag order from the PGN standard.
+var sevenTagRoster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+type PGNGame struct {
+	Tags  map[string]string
+	Moves []string // SAN, mainline only
+}
+
+func NewPGNGame() *PGNGame {
+	return &PGNGame{
+		Tags: map[string]string{
+			"Event":  "?",
+			"Site":   "?",
+			"Date":   "????.??.??",
+			"Round":  "?",
+			"White":  "?",
+			"Black":  "?",
+			"Result": ResultUnknown,
+		},
+	}
+}
+
+// NewPGNGameFromMoves replays moves from start and records them in SAN.
+// FEN and SetUp tags are added when start is not the standard position, and
+// a Variant tag for games that are not standard chess.
+func NewPGNGameFromMoves(start *Board, moves []Move) (*PGNGame, error) {
+	g := NewPGNGame()
+	if fen := start.ToFEN(); fen != StartingFEN {
+		g.Tags["SetUp"] = "1"
+		g.Tags["FEN"] = fen
+	}
+	if v := start.Variant(); v != Standard {
+		g.Tags["Variant"] = v.Title()
+	}
+
+	b := start.Clone()
+	g.Moves = make([]string, 0, len(moves))
+	for _, m := range moves {
+		san, err := b.SAN(m)
+		if err != nil {
+			return nil, err
+		}
+		if err := b.MakeMove(m); err != nil {
+			return nil, err
+		}
+		g.Moves = append(g.Moves, san)
+	}
+	return g, nil
+}
+
+func (g *PGNGame) Result() string {
+	if r, ok := g.Tags["Result"]; ok && r != "" {
+		return r
+	}
+	return ResultUnknown
+}
+
+func (g *PGNGame) String() string {
+	var sb strings.Builder
+	_ = g.Write(&sb)
+	return sb.String()
+}
+
+func (g *PGNGame) Write(w io.Writer) error {
+	var sb strings.Builder
+
+	for _, name := range g.tagOrder() {
+		value := g.Tags[name]
+		if name == "Result" {
+			value = g.Result()
+		}
+		if value == "" {
+			value = "?"
+		}
+		sb.WriteByte('[')
+		sb.WriteString(name)
+		sb.WriteString(` "`)
+		sb.WriteString(escapePGNString(value))
+		sb.WriteString("\"]\n")
+	}
+	sb.WriteByte('\n')
+
+	turn, fullMove := White, 1
+	if b, err := g.startBoard(); err == nil {
+		turn, fullMove = b.Turn(), b.FullMove()
+	}
+
+	tokens := make([]string, 0, len(g.Moves)*3/2+1)
+	for i, san := range g.Moves {
+		if turn == White {
+			tokens = append(tokens, intToString(fullMove)+".")
+		} else if i == 0 {
+			tokens = append(tokens, intToString(fullMove)+"...")
+		}
+		tokens = append(tokens, san)
+		if turn == Black {
+			fullMove++
+		}
+		turn = turn.Opposite()
+	}
+	tokens = append(tokens, g.Result())
+
+	lineLen := 0
+	for _, tok := range tokens {
+		if lineLen > 0 && lineLen+1+len(tok) > pgnLineWidth {
+			sb.WriteByte('\n')
+			lineLen = 0
+		}
+		if lineLen > 0 {
+			sb.WriteByte(' ')
+			lineLen++
+		}
+		sb.WriteString(tok)
+		lineLen += len(tok)
+	}
+	sb.WriteString("\n\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (g *PGNGame) tagOrder() []string {
+	order := make([]string, 0, len(g.Tags)+len(sevenTagRoster))
+	seen := make(map[string]bool, len(sevenTagRoster))
+	for _, name := range sevenTagRoster {
+		order = append(order, name)
+		seen[name] = true
+	}
+
+	extra := make([]string, 0, len(g.Tags))
+	for name := range g.Tags {
+		if !seen[name] {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	return append(order, extra...)
+}
+
+func escapePGNString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, `"`, `\"`)
+}
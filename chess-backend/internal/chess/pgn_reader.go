@@ -0,0 +1,280 @@
+package chess
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParsePGN reads every game in a PGN document. Only the mainline is kept:
+// comments, NAGs and recursive variations are skipped.
+func ParsePGN(r io.Reader) ([]*PGNGame, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &pgnParser{src: string(data)}
+	return p.parse()
+}
+
+type pgnParser struct {
+	src  string
+	pos  int
+	line int
+}
+
+func (p *pgnParser) parse() ([]*PGNGame, error) {
+	var games []*PGNGame
+	var cur *PGNGame
+	inMovetext := false
+	depth := 0
+
+	finish := func() {
+		if cur != nil {
+			games = append(games, cur)
+		}
+		cur = nil
+		inMovetext = false
+		depth = 0
+	}
+	start := func() {
+		if cur == nil {
+			cur = &PGNGame{Tags: map[string]string{}}
+		}
+	}
+
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			break
+		}
+
+		ch := p.src[p.pos]
+		switch {
+		case ch == '%' && p.atLineStart():
+			p.skipLine()
+
+		case ch == ';':
+			p.skipLine()
+
+		case ch == '{':
+			end := strings.IndexByte(p.src[p.pos:], '}')
+			if end < 0 {
+				return nil, p.errorf("unterminated comment")
+			}
+			p.advance(end + 1)
+
+		case ch == '[' && depth == 0:
+			if inMovetext {
+				// a new tag section without a result token still starts a new game
+				finish()
+			}
+			start()
+			name, value, err := p.readTag()
+			if err != nil {
+				return nil, err
+			}
+			cur.Tags[name] = value
+
+		case ch == '(':
+			start()
+			inMovetext = true
+			depth++
+			p.pos++
+
+		case ch == ')':
+			if depth == 0 {
+				return nil, p.errorf("unbalanced ')'")
+			}
+			depth--
+			p.pos++
+
+		case ch == '$':
+			p.pos++
+			p.readSymbol()
+
+		default:
+			tok := p.readSymbol()
+			if tok == "" {
+				return nil, p.errorf("unexpected character %q", ch)
+			}
+			start()
+			inMovetext = true
+			if depth > 0 {
+				continue
+			}
+
+			if isPGNResult(tok) {
+				if _, ok := cur.Tags["Result"]; !ok {
+					cur.Tags["Result"] = tok
+				}
+				finish()
+				continue
+			}
+
+			san := strings.TrimLeft(tok, "0123456789")
+			if len(san) < len(tok) && strings.HasPrefix(san, ".") {
+				san = strings.TrimLeft(san, ".")
+			} else {
+				san = tok
+			}
+			if san == "" {
+				continue
+			}
+			if san == "--" || san == "Z0" {
+				return nil, p.errorf("null moves are not supported")
+			}
+			cur.Moves = append(cur.Moves, san)
+		}
+	}
+
+	if depth != 0 {
+		return nil, p.errorf("unterminated variation")
+	}
+	finish()
+	return games, nil
+}
+
+// Replay loads the starting position of the game (honouring the FEN and
+// Variant tags) and resolves the mainline SAN moves against it.
+func (g *PGNGame) Replay() (*Board, []Move, error) {
+	start, err := g.startBoard()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b := start.Clone()
+	moves := make([]Move, 0, len(g.Moves))
+	for i, san := range g.Moves {
+		m, err := b.ParseSAN(san)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ply %d: %w", i+1, err)
+		}
+		if err := b.MakeMove(m); err != nil {
+			return nil, nil, fmt.Errorf("ply %d: %w", i+1, err)
+		}
+		moves = append(moves, m)
+	}
+	return start, moves, nil
+}
+
+// Variant is the variant the Variant tag names; games without the tag are
+// standard chess.
+func (g *PGNGame) Variant() (Variant, error) {
+	v, ok := VariantByName(g.Tags["Variant"])
+	if !ok {
+		return nil, fmt.Errorf("unsupported variant %q", g.Tags["Variant"])
+	}
+	return v, nil
+}
+
+// startBoard is the position the game starts from: the FEN tag read under
+// the game's variant, or the variant's starting position.
+func (g *PGNGame) startBoard() (*Board, error) {
+	v, err := g.Variant()
+	if err != nil {
+		return nil, err
+	}
+	if fen, ok := g.Tags["FEN"]; ok && strings.TrimSpace(fen) != "" {
+		return LoadVariantFEN(v, fen)
+	}
+	return v.NewBoard(), nil
+}
+
+func (p *pgnParser) readTag() (string, string, error) {
+	p.pos++ // '['
+	p.skipSpace()
+	name := p.readSymbol()
+	if name == "" {
+		return "", "", p.errorf("missing tag name")
+	}
+	p.skipSpace()
+	if p.pos >= len(p.src) || p.src[p.pos] != '"' {
+		return "", "", p.errorf("missing value for tag %s", name)
+	}
+	p.pos++
+
+	var sb strings.Builder
+	for {
+		if p.pos >= len(p.src) || p.src[p.pos] == '\n' {
+			return "", "", p.errorf("unterminated value for tag %s", name)
+		}
+		ch := p.src[p.pos]
+		if ch == '\\' && p.pos+1 < len(p.src) {
+			sb.WriteByte(p.src[p.pos+1])
+			p.pos += 2
+			continue
+		}
+		p.pos++
+		if ch == '"' {
+			break
+		}
+		sb.WriteByte(ch)
+	}
+
+	p.skipSpace()
+	if p.pos >= len(p.src) || p.src[p.pos] != ']' {
+		return "", "", p.errorf("missing ']' after tag %s", name)
+	}
+	p.pos++
+	return name, sb.String(), nil
+}
+
+func (p *pgnParser) readSymbol() string {
+	begin := p.pos
+	for p.pos < len(p.src) && !isPGNDelimiter(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.src[begin:p.pos]
+}
+
+func (p *pgnParser) skipSpace() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\n':
+			p.line++
+		case ' ', '\t', '\r':
+		default:
+			return
+		}
+		p.pos++
+	}
+}
+
+func (p *pgnParser) skipLine() {
+	end := strings.IndexByte(p.src[p.pos:], '\n')
+	if end < 0 {
+		p.pos = len(p.src)
+		return
+	}
+	p.advance(end)
+}
+
+func (p *pgnParser) advance(n int) {
+	p.line += strings.Count(p.src[p.pos:p.pos+n], "\n")
+	p.pos += n
+}
+
+func (p *pgnParser) atLineStart() bool {
+	return p.pos == 0 || p.src[p.pos-1] == '\n'
+}
+
+func (p *pgnParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid PGN (line %d): %s", p.line+1, fmt.Sprintf(format, args...))
+}
+
+func isPGNDelimiter(ch byte) bool {
+	switch ch {
+	case ' ', '\t', '\r', '\n', '{', '}', '(', ')', '[', ']', ';', '$', '"':
+		return true
+	}
+	return false
+}
+
+func isPGNResult(tok string) bool {
+	switch tok {
+	case ResultWhiteWins, ResultBlackWins, ResultDraw, ResultUnknown:
+		return true
+	}
+	return false
+}
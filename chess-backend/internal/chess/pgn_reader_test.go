@@ -0,0 +1,101 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePGN_TagsCommentsVariationsAndNAGs(t *testing.T) {
+	src := `[Event "Club \"Open\""]
+[Site "Somewhere"]
+[Result "1-0"]
+
+1. e4 {best by test} e5 $1 2. Nf3 (2. f4 exf4 (2... d5) 3. Nf3) 2... Nc6
+; rest of line comment
+3.Bb5 a6 1-0
+
+[Event "Second"]
+
+1. d4 d5 *
+`
+	games, err := ParsePGN(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ParsePGN error: %v", err)
+	}
+	if len(games) != 2 {
+		t.Fatalf("expected 2 games, got %d", len(games))
+	}
+
+	first := games[0]
+	if first.Tags["Event"] != `Club "Open"` {
+		t.Fatalf("unexpected Event tag %q", first.Tags["Event"])
+	}
+	want := []string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6"}
+	if strings.Join(first.Moves, " ") != strings.Join(want, " ") {
+		t.Fatalf("unexpected mainline %v, want %v", first.Moves, want)
+	}
+	if first.Result() != ResultWhiteWins {
+		t.Fatalf("expected 1-0, got %q", first.Result())
+	}
+
+	second := games[1]
+	if second.Tags["Event"] != "Second" || len(second.Moves) != 2 || second.Result() != ResultUnknown {
+		t.Fatalf("unexpected second game: %+v", second)
+	}
+}
+
+func TestParsePGN_Errors(t *testing.T) {
+	cases := []string{
+		"1. e4 {unterminated",
+		"1. e4 (1. d4",
+		"1. e4 e5 )",
+		`[Event "open`,
+	}
+	for _, src := range cases {
+		if _, err := ParsePGN(strings.NewReader(src)); err == nil {
+			t.Fatalf("expected error for %q", src)
+		}
+	}
+}
+
+func TestPGNGame_Replay(t *testing.T) {
+	src := `[SetUp "1"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 b - - 0 12"]
+
+12... Kd7 13. e4 Ke6 *`
+	games, err := ParsePGN(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("ParsePGN error: %v", err)
+	}
+
+	start, moves, err := games[0].Replay()
+	if err != nil {
+		t.Fatalf("Replay error: %v", err)
+	}
+	if start.ToFEN() != "4k3/8/8/8/8/8/4P3/4K3 b - - 0 12" {
+		t.Fatalf("unexpected start position %s", start.ToFEN())
+	}
+	if len(moves) != 3 || moves[1] != NewMove(E2, E4) {
+		t.Fatalf("unexpected moves %v", moves)
+	}
+
+	games[0].Moves = append(games[0].Moves, "Qh5")
+	if _, _, err := games[0].Replay(); err == nil {
+		t.Fatalf("expected replay of illegal move to fail")
+	}
+}
+
+func TestParsePGN_RoundTripsWriter(t *testing.T) {
+	g, err := NewPGNGameFromMoves(NewBoard(), []Move{NewMove(E2, E4), NewMove(C7, C5), NewMove(E1, E2)})
+	if err != nil {
+		t.Fatalf("NewPGNGameFromMoves error: %v", err)
+	}
+
+	games, err := ParsePGN(strings.NewReader(g.String()))
+	if err != nil {
+		t.Fatalf("ParsePGN error: %v", err)
+	}
+	if len(games) != 1 || strings.Join(games[0].Moves, " ") != "e4 c5 Ke2" {
+		t.Fatalf("unexpected parsed games: %+v", games)
+	}
+}
@@ -0,0 +1,100 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPGNGame_WritesSevenTagRosterAndMovetext(t *testing.T) {
+	b := NewBoard()
+	moves := []Move{NewMove(E2, E4), NewMove(E7, E5), NewMove(G1, F3), NewMove(B8, C6)}
+
+	g, err := NewPGNGameFromMoves(b, moves)
+	if err != nil {
+		t.Fatalf("NewPGNGameFromMoves error: %v", err)
+	}
+	g.Tags["White"] = "Alice"
+	g.Tags["Annotator"] = `Bob "the" reviewer`
+
+	got := g.String()
+	want := `[Event "?"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "Alice"]
+[Black "?"]
+[Result "*"]
+[Annotator "Bob \"the\" reviewer"]
+
+1. e4 e5 2. Nf3 Nc6 *
+
+`
+	if got != want {
+		t.Fatalf("unexpected PGN\n got: %q\nwant: %q", got, want)
+	}
+}
+
+func TestPGNGame_CustomStartPositionUsesFENTags(t *testing.T) {
+	b, err := LoadFEN("4k3/8/8/8/8/8/4P3/4K3 b - - 0 12")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+
+	g, err := NewPGNGameFromMoves(b, []Move{NewMove(E8, D7), NewMove(E2, E4)})
+	if err != nil {
+		t.Fatalf("NewPGNGameFromMoves error: %v", err)
+	}
+	g.Tags["Result"] = ResultDraw
+
+	got := g.String()
+	if !strings.Contains(got, `[SetUp "1"]`) || !strings.Contains(got, `[FEN "4k3/8/8/8/8/8/4P3/4K3 b - - 0 12"]`) {
+		t.Fatalf("expected SetUp and FEN tags, got:\n%s", got)
+	}
+	if !strings.Contains(got, "12... Kd7 13. e4 1/2-1/2") {
+		t.Fatalf("expected black-first movetext numbering, got:\n%s", got)
+	}
+}
+
+func TestPGNGame_VariantStartPositionNumbering(t *testing.T) {
+	for v, fen := range map[Variant]string{
+		Crazyhouse: "4k3/8/8/8/8/8/4P3/4K3[Nn] b - - 0 12",
+		ThreeCheck: "4k3/8/8/8/8/8/4P3/4K3 b - - 2+3 0 12",
+	} {
+		b, err := LoadVariantFEN(v, fen)
+		if err != nil {
+			t.Fatalf("LoadVariantFEN error: %v", err)
+		}
+		g, err := NewPGNGameFromMoves(b, []Move{NewMove(E8, D7), NewMove(E2, E4)})
+		if err != nil {
+			t.Fatalf("NewPGNGameFromMoves error: %v", err)
+		}
+		if got := g.String(); !strings.Contains(got, "12... Kd7 13. e4 *") {
+			t.Fatalf("%s: expected black-first movetext numbering, got:\n%s", v.Name(), got)
+		}
+	}
+}
+
+func TestPGNGame_WrapsLongMovetext(t *testing.T) {
+	b := NewBoard()
+	shuffle := []Move{NewMove(G1, F3), NewMove(G8, F6), NewMove(F3, G1), NewMove(F6, G8)}
+	var moves []Move
+	for i := 0; i < 10; i++ {
+		moves = append(moves, shuffle...)
+	}
+
+	g, err := NewPGNGameFromMoves(b, moves)
+	if err != nil {
+		t.Fatalf("NewPGNGameFromMoves error: %v", err)
+	}
+
+	movetext := strings.SplitN(g.String(), "\n\n", 2)[1]
+	lines := strings.Split(strings.TrimSpace(movetext), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("expected movetext to wrap, got %d line(s)", len(lines))
+	}
+	for _, line := range lines {
+		if len(line) > pgnLineWidth {
+			t.Fatalf("line exceeds %d chars: %q", pgnLineWidth, line)
+		}
+	}
+}
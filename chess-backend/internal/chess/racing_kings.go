@@ -0,0 +1,72 @@
+package chess
+
+// RacingKings starts with both sides on the first two ranks and is won by the
+// first king to reach the eighth rank. Nobody may give check. If White gets
+// there first, Black has one move to follow and draw.
+var RacingKings Variant = racingKings{}
+
+// RacingKingsFEN is the Racing Kings starting position.
+const RacingKingsFEN = "8/8/8/8/8/8/krbnNBRK/qrbnNBRQ w - - 0 1"
+
+type racingKings struct{}
+
+func (racingKings) Name() string     { return "racingKings" }
+func (racingKings) Title() string    { return "Racing Kings" }
+func (racingKings) NewBoard() *Board { return newVariantBoard(RacingKings, RacingKingsFEN) }
+
+func (racingKings) FilterMoves(b *Board, pseudo []Move) []Move {
+	safe := b.KingSafeMoves(pseudo)
+	legal := safe[:0]
+	for _, m := range safe {
+		if !b.givesCheck(m) {
+			legal = append(legal, m)
+		}
+	}
+	return legal
+}
+
+func (racingKings) Outcome(b *Board) (Outcome, bool) {
+	white, black := b.kingOnLastRank(White), b.kingOnLastRank(Black)
+	switch {
+	case white && black:
+		return draw(EndRacingKings)
+	case black:
+		return win(Black, EndRacingKings)
+	case white:
+		if b.turn == Black && b.canReachLastRank() {
+			return Outcome{}, false
+		}
+		return win(White, EndRacingKings)
+	}
+	if o, ok := b.mateOutcome(); ok {
+		return o, ok
+	}
+	return b.drawRuleOutcome()
+}
+
+// kingOnLastRank reports whether c's king stands on the eighth rank; the race
+// is to the same rank for both sides.
+func (b *Board) kingOnLastRank(c Color) bool {
+	k := b.findKingSquare(c)
+	return k != NoSquare && k.Rank() == 7
+}
+
+// canReachLastRank reports whether the side to move has a legal king move to
+// the eighth rank.
+func (b *Board) canReachLastRank() bool {
+	k := b.findKingSquare(b.turn)
+	for _, m := range b.LegalMoves() {
+		if m.From == k && m.To.Rank() == 7 {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Board) givesCheck(move Move) bool {
+	mover := b.turn
+	u := b.DoMove(move)
+	check := b.InCheck(mover.Opposite())
+	b.UndoMove(u)
+	return check
+}
@@ -0,0 +1,268 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SAN returns the Standard Algebraic Notation for a legal move in the current position.
+func (b *Board) SAN(move Move) (string, error) {
+	legal := b.LegalMoves()
+	if !containsMove(legal, move) {
+		if err := ValidateMove(b, move); err != nil {
+			return "", err
+		}
+		return "", ErrIllegalMove
+	}
+
+	piece := b.PieceAt(move.From)
+
+	var sb strings.Builder
+	if move.IsDrop() {
+		// pawn drops are written without the P, e.g. "@e4"
+		if move.Drop != Pawn {
+			sb.WriteByte(sanPieceChar(move.Drop))
+		}
+		sb.WriteByte('@')
+		sb.WriteString(move.To.String())
+	} else if b.isCastle(*piece, move) {
+		if castleSide(move) == castleKingside {
+			sb.WriteString("O-O")
+		} else {
+			sb.WriteString("O-O-O")
+		}
+	} else if piece.Type == Pawn {
+		if move.From.File() != move.To.File() {
+			sb.WriteByte(fileChar(move.From))
+			sb.WriteByte('x')
+		}
+		sb.WriteString(move.To.String())
+		if move.isPromotion() {
+			sb.WriteByte('=')
+			sb.WriteByte(sanPieceChar(move.Promotion))
+		}
+	} else {
+		sb.WriteByte(sanPieceChar(piece.Type))
+		sb.WriteString(b.sanDisambiguation(legal, move, piece.Type))
+		if !b.IsEmpty(move.To) {
+			sb.WriteByte('x')
+		}
+		sb.WriteString(move.To.String())
+	}
+
+	sim := b.Clone()
+	if err := sim.MakeMove(move); err != nil {
+		return "", err
+	}
+	if sim.InCheck(sim.turn) {
+		if len(sim.LegalMoves()) == 0 {
+			sb.WriteByte('#')
+		} else {
+			sb.WriteByte('+')
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// ParseSAN resolves a SAN string such as "Nbd7", "exd6", "O-O", "e8=Q+" or,
+// in Crazyhouse, "N@f3" to a legal move.
+func (b *Board) ParseSAN(san string) (Move, error) {
+	s := strings.TrimSpace(san)
+	s = strings.TrimRight(s, "+#!?")
+	if s == "" {
+		return Move{}, fmt.Errorf("%w: %q", ErrInvalidSAN, san)
+	}
+
+	legal := b.LegalMoves()
+
+	switch s {
+	case "O-O", "0-0":
+		return b.findCastle(legal, castleKingside, san)
+	case "O-O-O", "0-0-0":
+		return b.findCastle(legal, castleQueenside, san)
+	}
+
+	if at := strings.IndexByte(s, '@'); at >= 0 {
+		return b.findDrop(legal, s[:at], s[at+1:], san)
+	}
+
+	pieceType := Pawn
+	if strings.IndexByte("NBRQK", s[0]) >= 0 {
+		pieceType, _ = pieceTypeFromSANChar(s[0])
+		s = s[1:]
+	}
+
+	var promotion PieceType
+	if eq := strings.IndexByte(s, '='); eq >= 0 {
+		if eq != len(s)-2 {
+			return Move{}, fmt.Errorf("%w: %q", ErrInvalidSAN, san)
+		}
+		pt, ok := pieceTypeFromSANChar(s[eq+1])
+		if !ok {
+			return Move{}, fmt.Errorf("%w: %q", ErrInvalidSAN, san)
+		}
+		promotion = pt
+		s = s[:eq]
+	} else if pieceType == Pawn && len(s) >= 3 && strings.IndexByte("NBRQ", s[len(s)-1]) >= 0 {
+		// tolerate "e8Q" without the '='
+		promotion, _ = pieceTypeFromSANChar(s[len(s)-1])
+		s = s[:len(s)-1]
+	}
+
+	if len(s) < 2 {
+		return Move{}, fmt.Errorf("%w: %q", ErrInvalidSAN, san)
+	}
+	to, err := GetSquare(s[len(s)-2:])
+	if err != nil {
+		return Move{}, fmt.Errorf("%w: %q", ErrInvalidSAN, san)
+	}
+	s = strings.TrimSuffix(s[:len(s)-2], "x")
+
+	fromFile, fromRank := -1, -1
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c >= 'a' && c <= 'h':
+			fromFile = int(c - 'a')
+		case c >= '1' && c <= '8':
+			fromRank = int(c - '1')
+		default:
+			return Move{}, fmt.Errorf("%w: %q", ErrInvalidSAN, san)
+		}
+	}
+
+	var found []Move
+	for _, m := range legal {
+		p := b.PieceAt(m.From)
+		if p == nil || p.Type != pieceType || m.To != to || m.Promotion != promotion {
+			continue
+		}
+		if fromFile >= 0 && m.From.File() != fromFile {
+			continue
+		}
+		if fromRank >= 0 && m.From.Rank() != fromRank {
+			continue
+		}
+		found = append(found, m)
+	}
+
+	switch len(found) {
+	case 0:
+		return Move{}, fmt.Errorf("%w: %q", ErrIllegalMove, san)
+	case 1:
+		return found[0], nil
+	default:
+		return Move{}, fmt.Errorf("%w: %q", ErrAmbiguousSAN, san)
+	}
+}
+
+func (b *Board) findCastle(legal []Move, side int, san string) (Move, error) {
+	for _, m := range legal {
+		p := b.PieceAt(m.From)
+		if p != nil && b.isCastle(*p, m) && castleSide(m) == side {
+			return m, nil
+		}
+	}
+	return Move{}, fmt.Errorf("%w: %q", ErrIllegalMove, san)
+}
+
+func (b *Board) findDrop(legal []Move, piece, square, san string) (Move, error) {
+	pieceType := Pawn
+	switch {
+	case piece == "" || piece == "P":
+	case len(piece) == 1 && strings.IndexByte("NBRQ", piece[0]) >= 0:
+		pieceType, _ = pieceTypeFromSANChar(piece[0])
+	default:
+		return Move{}, fmt.Errorf("%w: %q", ErrInvalidSAN, san)
+	}
+	to, err := GetSquare(square)
+	if err != nil {
+		return Move{}, fmt.Errorf("%w: %q", ErrInvalidSAN, san)
+	}
+	if drop := NewDrop(pieceType, to); containsMove(legal, drop) {
+		return drop, nil
+	}
+	return Move{}, fmt.Errorf("%w: %q", ErrIllegalMove, san)
+}
+
+func (b *Board) sanDisambiguation(legal []Move, move Move, pieceType PieceType) string {
+	ambiguous, sameFile, sameRank := false, false, false
+	for _, m := range legal {
+		if m.To != move.To || m.From == move.From || m.IsDrop() {
+			continue
+		}
+		p := b.PieceAt(m.From)
+		if p.Type != pieceType {
+			continue
+		}
+		ambiguous = true
+		if m.From.File() == move.From.File() {
+			sameFile = true
+		}
+		if m.From.Rank() == move.From.Rank() {
+			sameRank = true
+		}
+	}
+
+	switch {
+	case !ambiguous:
+		return ""
+	case !sameFile:
+		return string(fileChar(move.From))
+	case !sameRank:
+		return string(rankChar(move.From))
+	default:
+		return move.From.String()
+	}
+}
+
+func containsMove(moves []Move, move Move) bool {
+	for _, m := range moves {
+		if m == move {
+			return true
+		}
+	}
+	return false
+}
+
+func fileChar(sq Square) byte {
+	return byte('a' + sq.File())
+}
+
+func rankChar(sq Square) byte {
+	return byte('1' + sq.Rank())
+}
+
+func sanPieceChar(pt PieceType) byte {
+	switch pt {
+	case Knight:
+		return 'N'
+	case Bishop:
+		return 'B'
+	case Rook:
+		return 'R'
+	case Queen:
+		return 'Q'
+	case King:
+		return 'K'
+	default:
+		return 'P'
+	}
+}
+
+func pieceTypeFromSANChar(c byte) (PieceType, bool) {
+	switch c {
+	case 'N':
+		return Knight, true
+	case 'B':
+		return Bishop, true
+	case 'R':
+		return Rook, true
+	case 'Q':
+		return Queen, true
+	case 'K':
+		return King, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,150 @@
+package chess
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSAN_BasicMoves(t *testing.T) {
+	b := NewBoard()
+
+	cases := []struct {
+		move Move
+		want string
+	}{
+		{NewMove(E2, E4), "e4"},
+		{NewMove(G1, F3), "Nf3"},
+	}
+	for _, tc := range cases {
+		got, err := b.SAN(tc.move)
+		if err != nil {
+			t.Fatalf("SAN(%s) error: %v", tc.move, err)
+		}
+		if got != tc.want {
+			t.Fatalf("SAN(%s) = %q, want %q", tc.move, got, tc.want)
+		}
+	}
+
+	if _, err := b.SAN(NewMove(E2, E5)); err == nil {
+		t.Fatalf("expected illegal move to return an error")
+	}
+}
+
+func TestSAN_Disambiguation(t *testing.T) {
+	// knights on b8 and f6 can both reach d7; rooks on a1 and a5 can both reach a3
+	b, err := LoadFEN("1n2k3/8/5n2/r7/8/8/7K/r7 b - - 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+
+	got, err := b.SAN(NewMove(B8, D7))
+	if err != nil || got != "Nbd7" {
+		t.Fatalf("expected Nbd7, got %q (%v)", got, err)
+	}
+
+	got, err = b.SAN(NewMove(A5, A3))
+	if err != nil || got != "R5a3" {
+		t.Fatalf("expected R5a3, got %q (%v)", got, err)
+	}
+}
+
+func TestSAN_CastlingPromotionAndMate(t *testing.T) {
+	b, err := LoadFEN("6k1/1P6/8/8/8/8/5PPP/4K2R w K - 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+
+	got, err := b.SAN(NewMove(E1, G1))
+	if err != nil || got != "O-O" {
+		t.Fatalf("expected O-O, got %q (%v)", got, err)
+	}
+
+	got, err = b.SAN(NewMoveWithPromotion(B7, B8, Queen))
+	if err != nil || got != "b8=Q+" {
+		t.Fatalf("expected b8=Q+, got %q (%v)", got, err)
+	}
+
+	mate, err := LoadFEN("6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+	got, err = mate.SAN(NewMove(A1, A8))
+	if err != nil || got != "Ra8#" {
+		t.Fatalf("expected Ra8#, got %q (%v)", got, err)
+	}
+}
+
+func TestSAN_EnPassantCapture(t *testing.T) {
+	b, err := LoadFEN("4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+
+	got, err := b.SAN(NewMove(E5, D6))
+	if err != nil || got != "exd6" {
+		t.Fatalf("expected exd6, got %q (%v)", got, err)
+	}
+}
+
+func TestParseSAN(t *testing.T) {
+	b, err := LoadFEN("1n2k3/8/5n2/r7/8/8/7K/r7 b - - 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+
+	m, err := b.ParseSAN("Nbd7")
+	if err != nil {
+		t.Fatalf("ParseSAN error: %v", err)
+	}
+	if m != NewMove(B8, D7) {
+		t.Fatalf("expected b8d7, got %s", m)
+	}
+
+	if _, err := b.ParseSAN("Nd7"); !errors.Is(err, ErrAmbiguousSAN) {
+		t.Fatalf("expected ambiguous error, got %v", err)
+	}
+	if _, err := b.ParseSAN("Qd7"); !errors.Is(err, ErrIllegalMove) {
+		t.Fatalf("expected illegal move error, got %v", err)
+	}
+	if _, err := b.ParseSAN("Zz9"); !errors.Is(err, ErrInvalidSAN) {
+		t.Fatalf("expected invalid SAN error, got %v", err)
+	}
+}
+
+func TestParseSAN_CastlingAndPromotion(t *testing.T) {
+	b, err := LoadFEN("6k1/1P6/8/8/8/8/5PPP/R3K2R w KQ - 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+
+	m, err := b.ParseSAN("O-O-O")
+	if err != nil || m != NewMove(E1, C1) {
+		t.Fatalf("expected e1c1, got %s (%v)", m, err)
+	}
+
+	m, err = b.ParseSAN("b8=N")
+	if err != nil || m != NewMoveWithPromotion(B7, B8, Knight) {
+		t.Fatalf("expected b7b8=knight, got %s (%v)", m, err)
+	}
+}
+
+func TestSAN_RoundTripsLegalMoves(t *testing.T) {
+	b, err := LoadFEN("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	if err != nil {
+		t.Fatalf("LoadFEN error: %v", err)
+	}
+
+	for _, m := range b.LegalMoves() {
+		san, err := b.SAN(m)
+		if err != nil {
+			t.Fatalf("SAN(%s) error: %v", m, err)
+		}
+		parsed, err := b.ParseSAN(san)
+		if err != nil {
+			t.Fatalf("ParseSAN(%q) error: %v", san, err)
+		}
+		if parsed != m {
+			t.Fatalf("round trip mismatch for %q: got %s, want %s", san, parsed, m)
+		}
+	}
+}
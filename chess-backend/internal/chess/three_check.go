@@ -0,0 +1,39 @@
+package chess
+
+// ThreeCheck is standard chess that a side also wins by giving check three
+// times. The board counts the checks; FENs carry the checks each side still
+// needs ("3+3") after the en passant field.
+var ThreeCheck Variant = threeCheck{}
+
+type threeCheck struct{}
+
+func (threeCheck) Name() string     { return "threeCheck" }
+func (threeCheck) Title() string    { return "Three-check" }
+func (threeCheck) NewBoard() *Board { return newVariantBoard(ThreeCheck, StartingFEN) }
+
+func (threeCheck) setup(b *Board) { b.countChecks = true }
+
+func (threeCheck) FilterMoves(b *Board, pseudo []Move) []Move {
+	return b.KingSafeMoves(pseudo)
+}
+
+func (threeCheck) Outcome(b *Board) (Outcome, bool) {
+	for _, c := range [...]Color{b.turn.Opposite(), b.turn} {
+		if b.checks[c] >= 3 {
+			return win(c, EndThreeCheck)
+		}
+	}
+	if o, ok := b.mateOutcome(); ok {
+		return o, ok
+	}
+	// any piece can give check, so only bare kings are a dead draw
+	if b.bb.occupied() == b.bb.pieces[White][King]|b.bb.pieces[Black][King] {
+		return draw(EndInsufficientMaterial)
+	}
+	return b.drawRuleOutcome()
+}
+
+// Checks is how many times c has given check, counted in Three-check only.
+func (b *Board) Checks(c Color) int {
+	return b.checks[c]
+}
@@ -0,0 +1,97 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseUCI parses long algebraic notation as used by UCI, e.g. "e2e4" or
+// "e7e8q", and Crazyhouse drops such as "N@f3".
+func ParseUCI(input string) (Move, error) {
+	input = strings.TrimSpace(input)
+	if len(input) == 4 && input[1] == '@' {
+		return parseUCIDrop(input)
+	}
+	input = strings.ToLower(input)
+	if len(input) != 4 && len(input) != 5 {
+		return Move{}, fmt.Errorf("uci must be 4 or 5 chars")
+	}
+
+	from, err := GetSquare(input[0:2])
+	if err != nil {
+		return Move{}, err
+	}
+	to, err := GetSquare(input[2:4])
+	if err != nil {
+		return Move{}, err
+	}
+
+	if len(input) == 4 {
+		return NewMove(from, to), nil
+	}
+
+	promo, err := parseUCIPromotion(input[4])
+	if err != nil {
+		return Move{}, err
+	}
+	return NewMoveWithPromotion(from, to, promo), nil
+}
+
+func (m Move) UCI() string {
+	if m.IsDrop() {
+		return string(sanPieceChar(m.Drop)) + "@" + m.To.String()
+	}
+	if m.Promotion == 0 {
+		return m.From.String() + m.To.String()
+	}
+	return m.From.String() + m.To.String() + uciPromotionSuffix(m.Promotion)
+}
+
+func parseUCIDrop(input string) (Move, error) {
+	pt, ok := pieceTypeFromSANChar(input[0] &^ 0x20) // either case
+	if input[0] == 'P' || input[0] == 'p' {
+		pt, ok = Pawn, true
+	}
+	if !ok || pt == King {
+		return Move{}, fmt.Errorf("invalid drop piece %q", input[0])
+	}
+	to, err := GetSquare(strings.ToLower(input[2:]))
+	if err != nil {
+		return Move{}, err
+	}
+	return NewDrop(pt, to), nil
+}
+
+func parseUCIPromotion(b byte) (PieceType, error) {
+	switch b {
+	case 'q':
+		return Queen, nil
+	case 'r':
+		return Rook, nil
+	case 'b':
+		return Bishop, nil
+	case 'n':
+		return Knight, nil
+	case 'k':
+		return King, nil // Antichess only
+	default:
+		return 0, fmt.Errorf("invalid promotion piece %q", b)
+	}
+}
+
+func uciPromotionSuffix(p PieceType) string {
+	switch p {
+	case Queen:
+		return "q"
+	case Rook:
+		return "r"
+	case Bishop:
+		return "b"
+	case Knight:
+		return "n"
+	case King:
+		return "k"
+	default:
+		return ""
+	}
+}
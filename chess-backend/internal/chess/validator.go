@@ -12,6 +12,32 @@ type MoveValidator interface {
 }
 
 func ValidateMove(board *Board, move Move) error {
+	if move.IsDrop() {
+		if err := validateDrop(board, move); err != nil {
+			return err
+		}
+	} else if err := validatePieceMove(board, move); err != nil {
+		return err
+	}
+
+	// variants decide legality in their move filters, which can depend on
+	// the other moves, e.g. compulsory captures in Antichess
+	if board.variant != nil {
+		if !containsMove(board.LegalMoves(), move) {
+			return ErrIllegalMove
+		}
+		return nil
+	}
+
+	if board.leavesKingInCheck(move) {
+		return ErrIllegalMove
+	}
+
+	return nil
+
+}
+
+func validatePieceMove(board *Board, move Move) error {
 	if err := ValidateBasicMove(board, move); err != nil {
 		return err
 	}
@@ -26,15 +52,7 @@ func ValidateMove(board *Board, move Move) error {
 	if !validator.IsLegalMove(board, move) {
 		return ErrIllegalMove
 	}
-
-	sim := board.Clone()
-	sim.applyMoveNoValidate(move)
-	if sim.InCheck(piece.Color) {
-		return ErrIllegalMove
-	}
-
 	return nil
-
 }
 
 func ValidateBasicMove(board *Board, move Move) error {
@@ -54,7 +72,7 @@ func ValidateBasicMove(board *Board, move Move) error {
 	}
 
 	destPiece := board.PieceAt(move.To)
-	if destPiece != nil && destPiece.Color == piece.Color {
+	if destPiece != nil && destPiece.Color == piece.Color && !board.isCastle(*piece, move) {
 		return ErrCaptureOwnPiece
 	}
 
@@ -0,0 +1,251 @@
+package chess
+
+import (
+	"errors"
+	"hash/fnv"
+	"strings"
+)
+
+// Variant is a rule set played on the Board. The board keeps its variant,
+// so LegalMoves, ValidateMove and Outcome follow the variant's rules
+// wherever the board goes, including into engine searches.
+type Variant interface {
+	// Name identifies the variant in the API and the store, e.g.
+	// "kingOfTheHill".
+	Name() string
+	// Title is the variant's name in a PGN Variant tag, e.g.
+	// "King of the Hill".
+	Title() string
+	// NewBoard sets up the variant's starting position.
+	NewBoard() *Board
+	// FilterMoves picks the legal moves of the position from its
+	// pseudo-legal moves. Orthodox variants use Board.KingSafeMoves.
+	FilterMoves(b *Board, pseudo []Move) []Move
+	// Outcome reports whether the game is over in the position and how.
+	Outcome(b *Board) (Outcome, bool)
+}
+
+// Reasons a game ends by the rules, as reported in Outcome.Reason.
+const (
+	EndCheckmate            = "checkmate"
+	EndStalemate            = "stalemate"
+	EndInsufficientMaterial = "insufficient_material"
+	EndDeadPosition         = "dead_position"
+	EndFivefoldRepetition   = "fivefold_repetition"
+	EndSeventyFiveMove      = "seventy_five_move"
+	EndKingOfTheHill        = "king_of_the_hill"
+	EndThreeCheck           = "three_check"
+	EndRacingKings          = "racing_kings"
+	EndKingExploded         = "king_exploded"
+	EndAllPiecesLost        = "all_pieces_lost"
+)
+
+// Outcome is how a finished game ended.
+type Outcome struct {
+	Winner Color // meaningless for a draw
+	Draw   bool
+	Reason string // one of the End constants
+}
+
+// Result is the outcome as a PGN result.
+func (o Outcome) Result() string {
+	switch {
+	case o.Draw:
+		return ResultDraw
+	case o.Winner == White:
+		return ResultWhiteWins
+	default:
+		return ResultBlackWins
+	}
+}
+
+func win(c Color, reason string) (Outcome, bool) {
+	return Outcome{Winner: c, Reason: reason}, true
+}
+
+func draw(reason string) (Outcome, bool) {
+	return Outcome{Draw: true, Reason: reason}, true
+}
+
+// variantSetup is implemented by variants that keep state of their own on
+// the board, such as the checks counted in Three-check.
+type variantSetup interface {
+	setup(b *Board)
+}
+
+// Variants lists every supported variant.
+var Variants = []Variant{Standard, Chess960, KingOfTheHill, ThreeCheck, RacingKings, Crazyhouse, Atomic, Antichess, Horde}
+
+// VariantByName finds a variant by its Name or Title, ignoring case, spaces
+// and dashes, so "kingOfTheHill", "King of the Hill" and "king-of-the-hill"
+// all match.
+func VariantByName(name string) (Variant, bool) {
+	key := variantKey(name)
+	if key == "" || key == "chess" {
+		return Standard, true
+	}
+	if key == "fischerandom" || key == "fischerrandom" || key == "fischerrandomchess" {
+		return Chess960, true
+	}
+	if key == "giveaway" || key == "losingchess" {
+		return Antichess, true
+	}
+	for _, v := range Variants {
+		if key == variantKey(v.Name()) || key == variantKey(v.Title()) {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func variantKey(name string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(strings.TrimSpace(name)))
+}
+
+// Variant is the rule set the board is played under.
+func (b *Board) Variant() Variant {
+	switch {
+	case b.variant != nil:
+		return b.variant
+	case b.chess960:
+		return Chess960
+	default:
+		return Standard
+	}
+}
+
+// Orthodox reports whether the board follows the rules of standard chess,
+// as Standard and Chess960 boards do; they differ only in castling.
+func (b *Board) Orthodox() bool {
+	return b.variant == nil
+}
+
+// Outcome reports whether the game is over under the board's variant.
+func (b *Board) Outcome() (Outcome, bool) {
+	return b.Variant().Outcome(b)
+}
+
+// LoadVariantFEN parses a FEN string for a game of v. Chess960 FENs may use
+// X-FEN castling rights, and Three-check FENs may carry the checks remaining
+// ("3+3") after the en passant field and Crazyhouse FENs pockets ("[Qp]").
+func LoadVariantFEN(v Variant, fen string) (*Board, error) {
+	b, err := loadFEN(fen, v == Chess960)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.checkVariantFields(v); err != nil {
+		return nil, err
+	}
+	b.setVariant(v)
+	return b, nil
+}
+
+// checkVariantFields rejects FEN fields that belong to variants other than v.
+func (b *Board) checkVariantFields(v Variant) error {
+	switch {
+	case b.chess960 && v != Chess960:
+		return errors.New("invalid FEN: castling rights name rook files; use the chess960 variant")
+	case b.countChecks && v != ThreeCheck:
+		return errors.New("invalid FEN: checks field outside Three-check")
+	case b.hasPockets && v != Crazyhouse:
+		return errors.New("invalid FEN: pockets outside Crazyhouse")
+	}
+	return nil
+}
+
+func (b *Board) setVariant(v Variant) {
+	if v == Standard || v == Chess960 {
+		return
+	}
+	b.variant = v
+	key := fnv.New64a()
+	key.Write([]byte(v.Name()))
+	b.variantKey = key.Sum64()
+	if s, ok := v.(variantSetup); ok {
+		s.setup(b)
+	}
+	b.hash = b.computeHash()
+}
+
+// KingSafeMoves keeps the moves that are legal in orthodox chess: castling
+// only through unattacked squares, and nothing that leaves the mover's king
+// in check.
+func (b *Board) KingSafeMoves(pseudo []Move) []Move {
+	legal := make([]Move, 0, len(pseudo))
+	for _, m := range pseudo {
+		if p := b.PieceAt(m.From); p != nil && b.isCastle(*p, m) {
+			v := KingValidator{}
+			if !v.isLegalCastle(b, m) {
+				continue
+			}
+		}
+		if b.leavesKingInCheck(m) {
+			continue
+		}
+
+		legal = append(legal, m)
+	}
+	return legal
+}
+
+// mateOutcome ends the game when the side to move has no legal move.
+func (b *Board) mateOutcome() (Outcome, bool) {
+	if len(b.LegalMoves()) > 0 {
+		return Outcome{}, false
+	}
+	if b.InCheck(b.turn) {
+		return win(b.turn.Opposite(), EndCheckmate)
+	}
+	return draw(EndStalemate)
+}
+
+// drawRuleOutcome applies the automatic draws by fivefold repetition and the
+// seventy-five-move rule.
+func (b *Board) drawRuleOutcome() (Outcome, bool) {
+	switch {
+	case b.IsFivefoldRepetition():
+		return draw(EndFivefoldRepetition)
+	case b.IsSeventyFiveMoveRule():
+		return draw(EndSeventyFiveMove)
+	}
+	return Outcome{}, false
+}
+
+type orthodox struct {
+	name, title string
+}
+
+func (v orthodox) Name() string  { return v.name }
+func (v orthodox) Title() string { return v.title }
+
+func (v orthodox) NewBoard() *Board {
+	if v == Chess960 {
+		b, _ := NewChess960Board(StandardChess960Position)
+		return b
+	}
+	return NewBoard()
+}
+
+func (orthodox) FilterMoves(b *Board, pseudo []Move) []Move {
+	return b.KingSafeMoves(pseudo)
+}
+
+func (orthodox) Outcome(b *Board) (Outcome, bool) {
+	if o, ok := b.mateOutcome(); ok {
+		return o, ok
+	}
+	switch {
+	case b.IsInsufficientMaterial():
+		return draw(EndInsufficientMaterial)
+	case b.IsDeadPosition():
+		return draw(EndDeadPosition)
+	}
+	return b.drawRuleOutcome()
+}
+
+var (
+	Standard Variant = orthodox{"standard", "Standard"}
+	// Chess960's NewBoard is the standard position; NewChess960Board sets
+	// up the others.
+	Chess960 Variant = orthodox{"chess960", "Chess960"}
+)
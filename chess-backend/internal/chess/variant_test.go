@@ -0,0 +1,169 @@
+package chess
+
+import "testing"
+
+func playUCI(t *testing.T, b *Board, moves ...string) {
+	t.Helper()
+	for _, uci := range moves {
+		m, err := ParseUCI(uci)
+		if err != nil {
+			t.Fatalf("ParseUCI(%s): %v", uci, err)
+		}
+		if err := b.MakeMove(m); err != nil {
+			t.Fatalf("%s: %v", uci, err)
+		}
+	}
+}
+
+func TestVariantByName(t *testing.T) {
+	cases := map[string]Variant{
+		"":                 Standard,
+		"Standard":         Standard,
+		"chess960":         Chess960,
+		"Fischer Random":   Chess960,
+		"kingOfTheHill":    KingOfTheHill,
+		"King of the Hill": KingOfTheHill,
+		"three-check":      ThreeCheck,
+		"racing_kings":     RacingKings,
+	}
+	for name, want := range cases {
+		if got, ok := VariantByName(name); !ok || got != want {
+			t.Fatalf("VariantByName(%q) = %v, expected %s", name, got, want.Name())
+		}
+	}
+	if _, ok := VariantByName("shogi"); ok {
+		t.Fatalf("expected an unknown variant to be rejected")
+	}
+}
+
+func TestKingOfTheHill(t *testing.T) {
+	b, err := LoadVariantFEN(KingOfTheHill, "4k3/8/8/8/8/4K3/8/8 w - - 0 1")
+	if err != nil {
+		t.Fatalf("LoadVariantFEN error: %v", err)
+	}
+	if _, over := b.Outcome(); over {
+		t.Fatalf("expected bare kings to play on in King of the Hill")
+	}
+
+	playUCI(t, b, "e3e4")
+	o, over := b.Outcome()
+	if !over || o.Draw || o.Winner != White || o.Reason != EndKingOfTheHill {
+		t.Fatalf("expected White to win on the hill, got %+v (%t)", o, over)
+	}
+	if o.Result() != ResultWhiteWins {
+		t.Fatalf("expected result 1-0, got %s", o.Result())
+	}
+
+	std, _ := LoadFEN("4k3/8/8/8/4K3/8/8/8 b - - 0 1")
+	if o, _ := std.Outcome(); o.Reason != EndInsufficientMaterial {
+		t.Fatalf("expected a standard board to ignore the hill, got %+v", o)
+	}
+	if std.Hash() == b.Hash() {
+		t.Fatalf("expected variant positions to hash apart from standard ones")
+	}
+}
+
+func TestThreeCheck(t *testing.T) {
+	b := ThreeCheck.NewBoard()
+	if got := b.ToFEN(); got != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 3+3 0 1" {
+		t.Fatalf("unexpected start FEN: %s", got)
+	}
+
+	playUCI(t, b, "e2e4", "e7e5", "f1c4", "b8c6", "c4f7")
+	if b.Checks(White) != 1 || b.Checks(Black) != 0 {
+		t.Fatalf("expected one check by White, got %d and %d", b.Checks(White), b.Checks(Black))
+	}
+	if got := b.ToFEN(); got != "r1bqkbnr/pppp1Bpp/2n5/4p3/4P3/8/PPPP1PPP/RNBQK1NR b KQkq - 2+3 0 3" {
+		t.Fatalf("unexpected FEN: %s", got)
+	}
+	hash := b.Hash()
+	if err := b.UnmakeMove(); err != nil {
+		t.Fatalf("UnmakeMove error: %v", err)
+	}
+	if b.Checks(White) != 0 {
+		t.Fatalf("expected unmake to take the check back")
+	}
+	playUCI(t, b, "c4f7")
+	if b.Hash() != hash {
+		t.Fatalf("expected the hash to be restored with the checks")
+	}
+
+	// the checks field also loads in the "+given+given" form
+	b, err := LoadVariantFEN(ThreeCheck, "4k3/8/8/8/8/8/8/4K2R w - - 0 1 +2+0")
+	if err != nil {
+		t.Fatalf("LoadVariantFEN error: %v", err)
+	}
+	if _, over := b.Outcome(); over {
+		t.Fatalf("expected the game to go on after two checks")
+	}
+	playUCI(t, b, "h1h8")
+	o, over := b.Outcome()
+	if !over || o.Winner != White || o.Reason != EndThreeCheck {
+		t.Fatalf("expected White to win by the third check, got %+v (%t)", o, over)
+	}
+
+	if _, err := LoadFEN("4k3/8/8/8/8/8/8/4K2R w - - 3+3 0 1"); err == nil {
+		t.Fatalf("expected a checks field to be rejected outside Three-check")
+	}
+}
+
+func TestRacingKings(t *testing.T) {
+	b := RacingKings.NewBoard()
+	if got := len(b.LegalMoves()); got != 21 {
+		t.Fatalf("expected 21 moves from the start, got %d", got)
+	}
+
+	// the rook may not go to g8 and give check
+	b, err := LoadVariantFEN(RacingKings, "7k/8/8/8/8/8/8/K5R1 w - - 0 1")
+	if err != nil {
+		t.Fatalf("LoadVariantFEN error: %v", err)
+	}
+	if err := ValidateMove(b, NewMove(G1, G8)); err == nil {
+		t.Fatalf("expected a checking move to be illegal")
+	}
+
+	// White arrives first, Black can follow: a draw
+	b, _ = LoadVariantFEN(RacingKings, "8/K5k1/8/8/8/8/8/8 w - - 0 1")
+	playUCI(t, b, "a7a8")
+	if _, over := b.Outcome(); over {
+		t.Fatalf("expected Black to get a move to catch up")
+	}
+	playUCI(t, b, "g7g8")
+	if o, over := b.Outcome(); !over || !o.Draw || o.Reason != EndRacingKings {
+		t.Fatalf("expected a draw with both kings home, got %+v (%t)", o, over)
+	}
+
+	// Black is too far behind to follow
+	b, _ = LoadVariantFEN(RacingKings, "8/K7/8/6k1/8/8/8/8 w - - 0 1")
+	playUCI(t, b, "a7a8")
+	if o, over := b.Outcome(); !over || o.Winner != White {
+		t.Fatalf("expected White to win the race, got %+v (%t)", o, over)
+	}
+}
+
+func TestVariantPGNRoundTrip(t *testing.T) {
+	start := KingOfTheHill.NewBoard()
+	b := start.Clone()
+	var moves []Move
+	for _, uci := range []string{"e2e4", "d7d5", "e1e2", "d5e4", "e2e3"} {
+		m, _ := ParseUCI(uci)
+		_ = b.MakeMove(m)
+		moves = append(moves, m)
+	}
+	g, err := NewPGNGameFromMoves(start, moves)
+	if err != nil {
+		t.Fatalf("NewPGNGameFromMoves error: %v", err)
+	}
+	if g.Tags["Variant"] != "King of the Hill" {
+		t.Fatalf("expected a Variant tag, got %v", g.Tags)
+	}
+	replayed, got, err := g.Replay()
+	if err != nil || replayed.Variant() != KingOfTheHill || len(got) != len(moves) {
+		t.Fatalf("expected the King of the Hill game back, got %v (%v)", got, err)
+	}
+
+	g.Tags["Variant"] = "Shogi"
+	if _, _, err := g.Replay(); err == nil {
+		t.Fatalf("expected an unknown variant to fail to replay")
+	}
+}
@@ -0,0 +1,136 @@
+package chess
+
+var (
+	zobristPieces    [2][6][64]uint64
+	zobristSide      uint64
+	zobristCastling  [4]uint64 // K, Q, k, q
+	zobristEnPassant [8]uint64 // by file
+	zobristChecks    [2][4]uint64
+	zobristPockets   [2][6][17]uint64 // by count, up to 16
+)
+
+func init() {
+	// fixed seed so hashes are stable across runs and processes
+	state := uint64(0x9E3779B97F4A7C15)
+	next := func() uint64 {
+		// xorshift64*
+		state ^= state >> 12
+		state ^= state << 25
+		state ^= state >> 27
+		return state * 0x2545F4914F6CDD1D
+	}
+
+	for c := range zobristPieces {
+		for pt := range zobristPieces[c] {
+			for sq := range zobristPieces[c][pt] {
+				zobristPieces[c][pt][sq] = next()
+			}
+		}
+	}
+	zobristSide = next()
+	for i := range zobristCastling {
+		zobristCastling[i] = next()
+	}
+	for i := range zobristEnPassant {
+		zobristEnPassant[i] = next()
+	}
+	for c := range zobristChecks {
+		for n := range zobristChecks[c] {
+			zobristChecks[c][n] = next()
+		}
+	}
+	for c := range zobristPockets {
+		for pt := range zobristPockets[c] {
+			for n := range zobristPockets[c][pt] {
+				zobristPockets[c][pt][n] = next()
+			}
+		}
+	}
+}
+
+func zobristPiece(p Piece, sq Square) uint64 {
+	return zobristPieces[p.Color][p.Type][sq]
+}
+
+func zobristCastlingKey(cr CastlingRights) uint64 {
+	var h uint64
+	if cr.WhiteKingside {
+		h ^= zobristCastling[0]
+	}
+	if cr.WhiteQueenside {
+		h ^= zobristCastling[1]
+	}
+	if cr.BlackKingside {
+		h ^= zobristCastling[2]
+	}
+	if cr.BlackQueenside {
+		h ^= zobristCastling[3]
+	}
+	return h
+}
+
+// zobristEnPassantKey only hashes the en passant file when the side to move
+// has a pawn that could actually capture, so positions that differ only in an
+// unusable en passant square are treated as identical (as FIDE rules require).
+func (b *Board) zobristEnPassantKey() uint64 {
+	if !b.enPassantCapturable() {
+		return 0
+	}
+	return zobristEnPassant[b.enPassent.File()]
+}
+
+// enPassantCapturable reports whether a pawn of the side to move stands next
+// to the en passant target, ignoring pins.
+func (b *Board) enPassantCapturable() bool {
+	if b.enPassent == NoSquare {
+		return false
+	}
+
+	pawnRank := b.enPassent.Rank() - 1
+	if b.turn == Black {
+		pawnRank = b.enPassent.Rank() + 1
+	}
+	if pawnRank < 0 || pawnRank > 7 {
+		return false
+	}
+	for _, df := range []int{-1, 1} {
+		f := b.enPassent.File() + df
+		if f < 0 || f > 7 {
+			continue
+		}
+		p := b.PieceAt(Square(f*8 + pawnRank))
+		if p != nil && p.Type == Pawn && p.Color == b.turn {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *Board) zobristStateKey() uint64 {
+	h := zobristCastlingKey(b.castling) ^ b.zobristEnPassantKey()
+	if b.turn == Black {
+		h ^= zobristSide
+	}
+	h ^= b.variantKey
+	if b.countChecks {
+		h ^= zobristChecks[White][min(b.checks[White], 3)] ^ zobristChecks[Black][min(b.checks[Black], 3)]
+	}
+	if b.hasPockets {
+		for c := range b.pockets {
+			for _, pt := range dropTypes {
+				h ^= zobristPockets[c][pt][min(b.pockets[c][pt], 16)]
+			}
+		}
+	}
+	return h
+}
+
+func (b *Board) computeHash() uint64 {
+	var h uint64
+	for sq := A1; sq <= H8; sq++ {
+		if p := b.squares[sq]; p != nil {
+			h ^= zobristPiece(*p, sq)
+		}
+	}
+	return h ^ b.zobristStateKey()
+}
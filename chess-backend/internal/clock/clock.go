@@ -0,0 +1,223 @@
+package clock
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"chess-backend/internal/chess"
+)
+
+type DelayMode string
+
+const (
+	DelayNone      DelayMode = ""
+	DelaySimple    DelayMode = "simple"    // clock waits for the delay before it starts running
+	DelayBronstein DelayMode = "bronstein" // time used is given back, up to the delay
+)
+
+var ErrInvalidTimeControl = errors.New("invalid time control")
+
+// Stage is one period of a time control, e.g. "40 moves in 90 minutes".
+// Moves == 0 means the stage lasts for the rest of the game.
+type Stage struct {
+	Moves     int           `json:"moves"`
+	Time      time.Duration `json:"time"`
+	Increment time.Duration `json:"increment"`
+	Delay     time.Duration `json:"delay"`
+	DelayMode DelayMode     `json:"delayMode"`
+}
+
+type TimeControl struct {
+	Stages []Stage `json:"stages"`
+}
+
+func (tc TimeControl) Validate() error {
+	if len(tc.Stages) == 0 {
+		return ErrInvalidTimeControl
+	}
+	for i, s := range tc.Stages {
+		if s.Time <= 0 && i == 0 {
+			return ErrInvalidTimeControl
+		}
+		if s.Moves < 0 || s.Time < 0 || s.Increment < 0 || s.Delay < 0 {
+			return ErrInvalidTimeControl
+		}
+		if s.Moves == 0 && i != len(tc.Stages)-1 {
+			return ErrInvalidTimeControl // only the last stage can run to the end of the game
+		}
+		switch s.DelayMode {
+		case DelayNone, DelaySimple, DelayBronstein:
+		default:
+			return ErrInvalidTimeControl
+		}
+	}
+	return nil
+}
+
+// String formats the control like the PGN TimeControl tag, e.g. "40/5400+30:1800+30".
+func (tc TimeControl) String() string {
+	parts := make([]string, 0, len(tc.Stages))
+	for _, s := range tc.Stages {
+		var sb strings.Builder
+		if s.Moves > 0 {
+			sb.WriteString(strconv.Itoa(s.Moves))
+			sb.WriteByte('/')
+		}
+		sb.WriteString(strconv.Itoa(int(s.Time / time.Second)))
+		if s.Increment > 0 {
+			sb.WriteByte('+')
+			sb.WriteString(strconv.Itoa(int(s.Increment / time.Second)))
+		}
+		parts = append(parts, sb.String())
+	}
+	return strings.Join(parts, ":")
+}
+
+// Clock tracks both players' remaining time. It only starts running once the
+// first move has been made, so white's first move is free.
+type Clock struct {
+	Control   TimeControl      `json:"control"`
+	Remaining [2]time.Duration `json:"remaining"` // indexed by chess.Color
+	MovesMade [2]int           `json:"movesMade"` // indexed by chess.Color
+	LastPunch *time.Time       `json:"lastPunch"` // when the side to move's clock started
+}
+
+func New(tc TimeControl) *Clock {
+	first := tc.Stages[0].Time
+	return &Clock{
+		Control:   tc,
+		Remaining: [2]time.Duration{first, first},
+	}
+}
+
+func (c *Clock) Clone() *Clock {
+	if c == nil {
+		return nil
+	}
+	clone := *c
+	clone.Control.Stages = append([]Stage(nil), c.Control.Stages...)
+	if c.LastPunch != nil {
+		ts := *c.LastPunch
+		clone.LastPunch = &ts
+	}
+	return &clone
+}
+
+func (c *Clock) Running() bool {
+	return c.LastPunch != nil
+}
+
+// RemainingAt is color's time left at now, given whose turn it is.
+func (c *Clock) RemainingAt(color, turn chess.Color, now time.Time) time.Duration {
+	remaining := c.Remaining[color]
+	if c.LastPunch != nil && color == turn {
+		remaining -= c.used(c.stage(color), now.Sub(*c.LastPunch))
+	}
+	return max(remaining, 0)
+}
+
+// Flagged reports whether the side to move has run out of time.
+func (c *Clock) Flagged(turn chess.Color, now time.Time) bool {
+	return c.LastPunch != nil && c.RemainingAt(turn, turn, now) <= 0
+}
+
+// Punch stops color's clock after it completes a move at now and starts the
+// opponent's. It returns true, without crediting any bonus, if color's flag
+// fell before the move was made.
+func (c *Clock) Punch(color chess.Color, now time.Time) bool {
+	stage := c.stage(color)
+
+	if c.LastPunch != nil {
+		elapsed := now.Sub(*c.LastPunch)
+		c.Remaining[color] -= c.used(stage, elapsed)
+		if c.Remaining[color] <= 0 {
+			c.Remaining[color] = 0
+			return true
+		}
+		if stage.DelayMode == DelayBronstein {
+			c.Remaining[color] += min(elapsed, stage.Delay)
+		}
+	}
+
+	c.Remaining[color] += stage.Increment
+	c.MovesMade[color]++
+	if next, ok := c.stageStartingAt(c.MovesMade[color]); ok {
+		c.Remaining[color] += next.Time
+	}
+	c.LastPunch = &now
+	return false
+}
+
+// Unpunch takes back what Punch credited color for its last move, the
+// increment and the time of any stage that move reached, when the move is
+// taken back. The time the move used stays spent.
+func (c *Clock) Unpunch(color chess.Color) {
+	if c.MovesMade[color] == 0 {
+		return
+	}
+	if next, ok := c.stageStartingAt(c.MovesMade[color]); ok {
+		c.Remaining[color] -= next.Time
+	}
+	c.MovesMade[color]--
+	c.Remaining[color] = max(c.Remaining[color]-c.stage(color).Increment, 0)
+}
+
+// Stop freezes the side to move's clock, e.g. when the game ends.
+func (c *Clock) Stop(turn chess.Color, now time.Time) {
+	if c.LastPunch == nil {
+		return
+	}
+	c.Remaining[turn] = c.RemainingAt(turn, turn, now)
+	c.LastPunch = nil
+}
+
+func (c *Clock) used(stage Stage, elapsed time.Duration) time.Duration {
+	if stage.DelayMode == DelaySimple {
+		return max(elapsed-stage.Delay, 0)
+	}
+	return elapsed
+}
+
+// stage returns the stage color is currently playing in.
+func (c *Clock) stage(color chess.Color) Stage {
+	moves := c.MovesMade[color]
+	stages := c.Control.Stages
+	for i, s := range stages {
+		if s.Moves == 0 || moves < s.Moves {
+			return s
+		}
+		moves -= s.Moves
+		if i == len(stages)-1 {
+			// a final stage with a move count repeats, e.g. "40/7200" every 40 moves
+			return s
+		}
+	}
+	return stages[len(stages)-1]
+}
+
+// stageStartingAt returns the stage that begins once a player has completed
+// movesMade moves, if one does.
+func (c *Clock) stageStartingAt(movesMade int) (Stage, bool) {
+	boundary := 0
+	stages := c.Control.Stages
+	for i, s := range stages {
+		if s.Moves == 0 {
+			return Stage{}, false
+		}
+		boundary += s.Moves
+		if movesMade == boundary && i+1 < len(stages) {
+			return stages[i+1], true
+		}
+		if movesMade < boundary {
+			return Stage{}, false
+		}
+	}
+
+	last := stages[len(stages)-1]
+	if (movesMade-boundary)%last.Moves == 0 {
+		return last, true
+	}
+	return Stage{}, false
+}
@@ -0,0 +1,148 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"chess-backend/internal/chess"
+)
+
+var epoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func at(seconds int) time.Time {
+	return epoch.Add(time.Duration(seconds) * time.Second)
+}
+
+func TestIncrementAndFirstMoveFree(t *testing.T) {
+	c := New(TimeControl{Stages: []Stage{{Time: time.Minute, Increment: 2 * time.Second}}})
+
+	if c.Punch(chess.White, at(30)) {
+		t.Fatalf("first move should never flag")
+	}
+	if c.Remaining[chess.White] != 62*time.Second {
+		t.Fatalf("expected 62s after first move, got %v", c.Remaining[chess.White])
+	}
+	if got := c.RemainingAt(chess.Black, chess.Black, at(40)); got != 50*time.Second {
+		t.Fatalf("expected black to have 50s while thinking, got %v", got)
+	}
+
+	c.Punch(chess.Black, at(45))
+	if c.Remaining[chess.Black] != 47*time.Second {
+		t.Fatalf("expected 47s after black's move, got %v", c.Remaining[chess.Black])
+	}
+}
+
+func TestSimpleDelay(t *testing.T) {
+	c := New(TimeControl{Stages: []Stage{{Time: time.Minute, Delay: 5 * time.Second, DelayMode: DelaySimple}}})
+	c.Punch(chess.White, at(0))
+
+	c.Punch(chess.Black, at(3))
+	if c.Remaining[chess.Black] != time.Minute {
+		t.Fatalf("a move within the delay should cost nothing, got %v", c.Remaining[chess.Black])
+	}
+	c.Punch(chess.White, at(11))
+	if c.Remaining[chess.White] != 57*time.Second {
+		t.Fatalf("expected 57s after an 8s move with 5s delay, got %v", c.Remaining[chess.White])
+	}
+}
+
+func TestBronsteinDelay(t *testing.T) {
+	c := New(TimeControl{Stages: []Stage{{Time: time.Minute, Delay: 5 * time.Second, DelayMode: DelayBronstein}}})
+	c.Punch(chess.White, at(0))
+
+	c.Punch(chess.Black, at(3))
+	if c.Remaining[chess.Black] != time.Minute {
+		t.Fatalf("time used within the delay should be returned, got %v", c.Remaining[chess.Black])
+	}
+	c.Punch(chess.White, at(11))
+	if c.Remaining[chess.White] != 57*time.Second {
+		t.Fatalf("expected 57s after an 8s move with 5s delay, got %v", c.Remaining[chess.White])
+	}
+}
+
+func TestStages(t *testing.T) {
+	tc := TimeControl{Stages: []Stage{
+		{Moves: 2, Time: time.Minute},
+		{Time: 30 * time.Second, Increment: time.Second},
+	}}
+	if err := tc.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if got := tc.String(); got != "2/60:30+1" {
+		t.Fatalf("unexpected time control string %q", got)
+	}
+
+	c := New(tc)
+	c.Punch(chess.White, at(0))
+	c.Punch(chess.Black, at(10))
+	c.Punch(chess.White, at(20))
+	if c.Remaining[chess.White] != 80*time.Second {
+		t.Fatalf("expected second stage time after two moves, got %v", c.Remaining[chess.White])
+	}
+	c.Punch(chess.Black, at(30))
+	c.Punch(chess.White, at(40))
+	if c.Remaining[chess.White] != 71*time.Second {
+		t.Fatalf("expected second stage increment, got %v", c.Remaining[chess.White])
+	}
+}
+
+func TestUnpunch(t *testing.T) {
+	c := New(TimeControl{Stages: []Stage{
+		{Moves: 2, Time: time.Minute, Increment: 2 * time.Second},
+		{Time: 30 * time.Second, Increment: time.Second},
+	}})
+	c.Punch(chess.White, at(0))
+	c.Punch(chess.Black, at(10))
+	c.Punch(chess.White, at(20))
+	if c.Remaining[chess.White] != 84*time.Second {
+		t.Fatalf("expected second stage time after two moves, got %v", c.Remaining[chess.White])
+	}
+
+	c.Unpunch(chess.White)
+	if c.MovesMade[chess.White] != 1 || c.Remaining[chess.White] != 52*time.Second {
+		t.Fatalf("expected the increment and stage time taken back, got %d moves and %v",
+			c.MovesMade[chess.White], c.Remaining[chess.White])
+	}
+	c.Punch(chess.White, at(25))
+	if c.Remaining[chess.White] != 79*time.Second {
+		t.Fatalf("expected the stage to start again on the replayed move, got %v", c.Remaining[chess.White])
+	}
+}
+
+func TestFlagged(t *testing.T) {
+	c := New(TimeControl{Stages: []Stage{{Time: 10 * time.Second}}})
+	if c.Flagged(chess.White, at(100)) {
+		t.Fatalf("clock should not run before the first move")
+	}
+	c.Punch(chess.White, at(0))
+	if c.Flagged(chess.Black, at(9)) {
+		t.Fatalf("black should still have time")
+	}
+	if !c.Flagged(chess.Black, at(10)) {
+		t.Fatalf("black should have flagged")
+	}
+	if !c.Punch(chess.Black, at(12)) {
+		t.Fatalf("a move after the flag fell should report it")
+	}
+
+	c = New(TimeControl{Stages: []Stage{{Time: 10 * time.Second}}})
+	c.Punch(chess.White, at(0))
+	c.Stop(chess.Black, at(4))
+	if c.Running() || c.Flagged(chess.Black, at(100)) || c.Remaining[chess.Black] != 6*time.Second {
+		t.Fatalf("stopped clock should keep its time, got %+v", c)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	invalid := []TimeControl{
+		{},
+		{Stages: []Stage{{Time: 0}}},
+		{Stages: []Stage{{Time: time.Minute, DelayMode: "fischer"}}},
+		{Stages: []Stage{{Time: time.Minute}, {Moves: 40, Time: time.Minute}}},
+	}
+	for i, tc := range invalid {
+		if err := tc.Validate(); err == nil {
+			t.Fatalf("case %d: expected invalid time control", i)
+		}
+	}
+}
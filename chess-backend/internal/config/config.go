@@ -5,6 +5,7 @@ import (
 	"net/url"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -12,6 +13,8 @@ import (
 type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
+	Engine   EngineConfig
+	Book     BookConfig
 }
 
 type ServerConfig struct {
@@ -27,6 +30,21 @@ type DatabaseConfig struct {
 	SSLMode  string
 }
 
+// EngineConfig selects an external UCI engine for analysis and bots. With no
+// Path the built-in engine is used.
+type EngineConfig struct {
+	Path     string
+	PoolSize int
+	// Options are UCI options such as Threads or Hash, given in ENGINE_OPTIONS
+	// as "Threads=2,Hash=128"
+	Options map[string]string
+}
+
+// BookConfig points at a Polyglot opening book for bots and book lookups.
+type BookConfig struct {
+	Path string
+}
+
 func Load() (*Config, error) {
 	_ = godotenv.Load()
 
@@ -42,11 +60,40 @@ func Load() (*Config, error) {
 			DBName:   GetEnv("DB_NAME", "chess_db").(string),
 			SSLMode:  GetEnv("DB_SSLMODE", "disable").(string),
 		},
+		Engine: EngineConfig{
+			Path:     GetEnv("ENGINE_PATH", "").(string),
+			PoolSize: GetEnv("ENGINE_POOL_SIZE", 2).(int),
+		},
+		Book: BookConfig{
+			Path: GetEnv("BOOK_PATH", "").(string),
+		},
 	}
 
+	options, err := parseEngineOptions(GetEnv("ENGINE_OPTIONS", "").(string))
+	if err != nil {
+		return nil, err
+	}
+	cfg.Engine.Options = options
+
 	return cfg, nil
 }
 
+func parseEngineOptions(s string) (map[string]string, error) {
+	options := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid ENGINE_OPTIONS entry %q, expected name=value", pair)
+		}
+		options[name] = strings.TrimSpace(value)
+	}
+	return options, nil
+}
+
 func (db DatabaseConfig) DSN() string {
 	userInfo := url.User(db.User)
 	if db.Password != "" {
@@ -0,0 +1,138 @@
+// Package eco names chess openings with their Encyclopaedia of Chess Openings
+// code. Openings are keyed by position rather than move order, so a game that
+// transposes into a known line is still recognised.
+package eco
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+
+	"chess-backend/internal/chess"
+)
+
+// eco.tsv lists one opening per line: ECO code, name and the moves from the
+// starting position in SAN. Names follow "Family: Variation, Subvariation".
+//
+//go:embed eco.tsv
+var data string
+
+type Opening struct {
+	ECO       string
+	Name      string // the opening family, e.g. "Sicilian Defense"
+	Variation string // e.g. "Najdorf Variation"; empty for the main line
+}
+
+func (o Opening) String() string {
+	if o.Variation == "" {
+		return o.ECO + " " + o.Name
+	}
+	return o.ECO + " " + o.Name + ": " + o.Variation
+}
+
+// table holds the openings by the zobrist hash of their final position, and
+// how many plies deep each one's line is.
+type table struct {
+	positions map[uint64]Opening
+	plies     map[Opening]int
+}
+
+// openings is the embedded table, built on first use.
+var openings = sync.OnceValue(func() table {
+	t, err := parse(data)
+	if err != nil {
+		panic(fmt.Sprintf("eco: embedded table: %v", err))
+	}
+	return t
+})
+
+func parse(data string) (table, error) {
+	t := table{positions: make(map[uint64]Opening), plies: make(map[Opening]int)}
+	for i, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		if i == 0 {
+			continue // header
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			return table{}, fmt.Errorf("line %d: expected 3 fields, got %d", i+1, len(fields))
+		}
+
+		board := chess.NewBoard()
+		plies := 0
+		for _, san := range strings.Fields(fields[2]) {
+			if strings.HasSuffix(san, ".") {
+				continue // move number
+			}
+			move, err := board.ParseSAN(san)
+			if err != nil {
+				return table{}, fmt.Errorf("line %d: %s: %w", i+1, san, err)
+			}
+			if err := board.MakeMove(move); err != nil {
+				return table{}, fmt.Errorf("line %d: %s: %w", i+1, san, err)
+			}
+			plies++
+		}
+
+		name, variation, _ := strings.Cut(fields[1], ": ")
+		if _, dup := t.positions[board.Hash()]; dup {
+			return table{}, fmt.Errorf("line %d: position already listed", i+1)
+		}
+		o := Opening{ECO: fields[0], Name: name, Variation: variation}
+		t.positions[board.Hash()] = o
+		t.plies[o] = max(t.plies[o], plies)
+	}
+	return t, nil
+}
+
+// Lookup names the board's current position if it is a known opening.
+// Only standard games are classified: not Chess960 games, even from the
+// standard position, nor other variants.
+func Lookup(board *chess.Board) (Opening, bool) {
+	if board.Variant() != chess.Standard {
+		return Opening{}, false
+	}
+	o, ok := openings().positions[board.Hash()]
+	return o, ok
+}
+
+// Plies is how many plies deep o's line runs, the deepest if it is listed
+// more than once, or 0 for an opening not in the table.
+func Plies(o Opening) int {
+	return openings().plies[o]
+}
+
+// Replaces reports whether o, matched later in a game, takes over from the
+// opening recorded so far: it must be at least as deep, so a game that
+// transposes into a shorter line keeps its deeper classification.
+func Replaces(o Opening, current *Opening) bool {
+	return current == nil || Plies(o) >= Plies(*current)
+}
+
+// Classify finds the opening of the game on board: the deepest of the known
+// openings among its positions, going back through the moves made on it, or
+// the latest of them on a tie, as Replaces picks them move by move. The board
+// is not modified.
+func Classify(board *chess.Board) (Opening, bool) {
+	b := board.Clone()
+	var matches []Opening // latest first
+	for {
+		if o, ok := Lookup(b); ok {
+			matches = append(matches, o)
+		}
+		if b.UnmakeMove() != nil {
+			break
+		}
+	}
+
+	var best *Opening
+	for i := len(matches) - 1; i >= 0; i-- {
+		if Replaces(matches[i], best) {
+			best = &matches[i]
+		}
+	}
+	if best == nil {
+		return Opening{}, false
+	}
+	return *best, true
+}
@@ -0,0 +1,84 @@
+package eco
+
+import (
+	"testing"
+
+	"chess-backend/internal/chess"
+)
+
+func TestEmbeddedTableParses(t *testing.T) {
+	table, err := parse(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(table.positions) < 200 {
+		t.Fatalf("expected a few hundred openings, got %d", len(table.positions))
+	}
+}
+
+func playSAN(t *testing.T, b *chess.Board, moves ...string) {
+	t.Helper()
+	for _, san := range moves {
+		m, err := b.ParseSAN(san)
+		if err != nil {
+			t.Fatalf("parse %s: %v", san, err)
+		}
+		if err := b.MakeMove(m); err != nil {
+			t.Fatalf("move %s: %v", san, err)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	b := chess.NewBoard()
+	if _, ok := Lookup(b); ok {
+		t.Fatal("the starting position is not an opening")
+	}
+
+	playSAN(t, b, "e4", "c5", "Nf3", "d6", "d4", "cxd4", "Nxd4", "Nf6", "Nc3", "a6")
+	o, ok := Lookup(b)
+	if !ok || o.ECO != "B90" || o.Name != "Sicilian Defense" || o.Variation != "Najdorf Variation" {
+		t.Fatalf("expected the Najdorf, got %+v", o)
+	}
+	if o.String() != "B90 Sicilian Defense: Najdorf Variation" {
+		t.Fatalf("unexpected String %q", o.String())
+	}
+	if got := Plies(o); got != 10 {
+		t.Fatalf("expected the Najdorf 10 plies deep, got %d", got)
+	}
+}
+
+func TestLookupMatchesTranspositions(t *testing.T) {
+	// the Queen's Gambit Declined reached through the English move order
+	b := chess.NewBoard()
+	playSAN(t, b, "c4", "e6", "Nc3", "d5", "d4", "Nf6")
+	o, ok := Lookup(b)
+	if !ok || o.ECO != "D35" || o.Name != "Queen's Gambit Declined" {
+		t.Fatalf("expected D35 by transposition, got %+v", o)
+	}
+}
+
+func TestClassifyUsesDeepestKnownPosition(t *testing.T) {
+	b := chess.NewBoard()
+	playSAN(t, b, "e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "Ba4", "Nf6", "h3", "b5")
+	fen := b.ToFEN()
+
+	o, ok := Classify(b)
+	if !ok || o.ECO != "C70" || o.Variation != "Morphy Defense" {
+		t.Fatalf("expected C70 Ruy Lopez: Morphy Defense, got %+v", o)
+	}
+	if b.ToFEN() != fen {
+		t.Fatal("Classify modified the board")
+	}
+
+	if _, ok := Classify(chess.NewBoard()); ok {
+		t.Fatal("expected no opening before any move")
+	}
+
+	// a later return to a shorter line does not lower the classification
+	b = chess.NewBoard()
+	playSAN(t, b, "e4", "e5", "Nf3", "Nc6", "Ng1", "Nb8", "d3")
+	if o, ok := Classify(b); !ok || o.ECO != "C44" {
+		t.Fatalf("expected C44 to outrank the later C20, got %+v", o)
+	}
+}
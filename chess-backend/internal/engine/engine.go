@@ -0,0 +1,171 @@
+// Package engine chooses moves for a chess.Board with an iterative-deepening
+// alpha-beta search.
+package engine
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"chess-backend/internal/chess"
+)
+
+var ErrNoLegalMoves = errors.New("no legal moves")
+
+// Score is an evaluation in centipawns from the side to move's point of view.
+// Scores beyond ±mateThreshold encode a forced mate.
+type Score int
+
+const (
+	MateScore Score = 32000
+	infinity  Score = MateScore + 1
+
+	maxPly        = 64
+	mateThreshold = MateScore - maxPly
+)
+
+// Mate reports the number of moves to a forced mate: positive when the side
+// to move mates, negative when it is mated.
+func (s Score) Mate() (int, bool) {
+	switch {
+	case s >= mateThreshold:
+		return int(MateScore-s+1) / 2, true
+	case s <= -mateThreshold:
+		return -int(MateScore+s) / 2, true
+	}
+	return 0, false
+}
+
+// Limits bound a search. Zero fields are unlimited, but a search with no
+// limits and no context deadline runs until it reaches maxPly.
+type Limits struct {
+	Depth    int
+	Nodes    uint64
+	MoveTime time.Duration
+	// MultiPV asks for the best lines of that many different first moves
+	// instead of only the best one. Each extra line costs another search.
+	MultiPV int
+	// Info, when set, is called with the result so far after every completed
+	// iteration, e.g. to report progress to a GUI.
+	Info func(Result)
+}
+
+// Line is one principal variation and its score.
+type Line struct {
+	Score Score
+	PV    []chess.Move
+}
+
+type Result struct {
+	Move    chess.Move
+	Score   Score
+	Depth   int // last fully searched depth
+	Nodes   uint64
+	PV      []chess.Move
+	Lines   []Line // best first; only the best line unless MultiPV was set
+	Elapsed time.Duration
+}
+
+// Engine keeps the transposition table between searches, so consecutive
+// searches of the same game reuse each other's work. It is not safe for
+// concurrent use.
+type Engine struct {
+	tt *transpositionTable
+}
+
+const DefaultHashMB = 16
+
+// New creates an engine with a transposition table of about hashMB megabytes,
+// or DefaultHashMB when hashMB is not positive.
+func New(hashMB int) *Engine {
+	if hashMB <= 0 {
+		hashMB = DefaultHashMB
+	}
+	return &Engine{tt: newTranspositionTable(hashMB)}
+}
+
+// Clear forgets everything learned in earlier searches, e.g. for a new game.
+func (e *Engine) Clear() {
+	e.tt.clear()
+}
+
+// Search looks for the best move in the position. The board is not modified.
+// Cancelling ctx, or reaching a limit, stops the search and returns the
+// result of the last completed iteration, so a move is always returned when
+// one exists. ErrNoLegalMoves is returned for checkmate and stalemate.
+func (e *Engine) Search(ctx context.Context, board *chess.Board, limits Limits) (Result, error) {
+	start := time.Now()
+
+	root := board.Clone()
+	moves := root.LegalMoves()
+	if len(moves) == 0 {
+		return Result{}, ErrNoLegalMoves
+	}
+
+	if limits.MoveTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.MoveTime)
+		defer cancel()
+	}
+
+	maxDepth := limits.Depth
+	if maxDepth <= 0 || maxDepth > maxPly-1 {
+		maxDepth = maxPly - 1
+	}
+
+	multiPV := min(max(limits.MultiPV, 1), len(moves))
+
+	s := &searcher{ctx: ctx, board: root, tt: e.tt, nodeLimit: limits.Nodes, variantRules: !root.Orthodox()}
+	result := Result{Move: moves[0]}
+
+	for depth := 1; depth <= maxDepth; depth++ {
+		lines := s.searchLines(depth, multiPV)
+		if s.stopped || len(lines) == 0 {
+			break
+		}
+
+		result.Lines = lines
+		result.Score = lines[0].Score
+		result.PV = lines[0].PV
+		result.Depth = depth
+		if len(result.PV) > 0 {
+			result.Move = result.PV[0]
+		}
+		if limits.Info != nil {
+			result.Nodes = s.nodes
+			result.Elapsed = time.Since(start)
+			limits.Info(result)
+		}
+
+		// a forced mate found at this depth will not get any shorter
+		if _, mate := result.Score.Mate(); mate && multiPV == 1 {
+			break
+		}
+	}
+
+	result.Nodes = s.nodes
+	result.Elapsed = time.Since(start)
+	return result, nil
+}
+
+// searchLines searches the root once per line, leaving out the first moves of
+// the lines already found.
+func (s *searcher) searchLines(depth, count int) []Line {
+	lines := make([]Line, 0, count)
+	s.rootExclude = s.rootExclude[:0]
+	for len(lines) < count {
+		score := s.negamax(depth, 0, -infinity, infinity)
+		if s.stopped || s.pvLen[0] == 0 {
+			break
+		}
+		pv := append([]chess.Move(nil), s.pv[0][:s.pvLen[0]]...)
+		lines = append(lines, Line{Score: score, PV: pv})
+		s.rootExclude = append(s.rootExclude, pv[0])
+	}
+
+	// a later line can come out ahead when an earlier search was cut short by
+	// the table; the best line is still the first
+	slices.SortStableFunc(lines, func(a, b Line) int { return int(b.Score - a.Score) })
+	return lines
+}
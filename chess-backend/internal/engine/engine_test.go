@@ -0,0 +1,200 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"chess-backend/internal/chess"
+)
+
+func mustLoadFEN(t *testing.T, fen string) *chess.Board {
+	t.Helper()
+	b, err := chess.LoadFEN(fen)
+	if err != nil {
+		t.Fatalf("load fen %q: %v", fen, err)
+	}
+	return b
+}
+
+func TestSearchFindsBestMove(t *testing.T) {
+	cases := []struct {
+		name string
+		fen  string
+		want string
+		mate int
+	}{
+		{"back rank mate", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "a1a8", 1},
+		{"scholar's mate", "r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4", "h5f7", 1},
+		{"king and rook mate in two", "k7/8/2K5/8/8/8/8/7R w - - 0 1", "", 2},
+		{"hanging queen", "4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1", "d2d5", 0},
+		{"black to move", "3rk3/8/8/8/3Q4/8/8/6K1 b - - 0 1", "d8d4", 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := mustLoadFEN(t, tc.fen)
+			result, err := New(1).Search(context.Background(), b, Limits{Depth: 4})
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			if tc.want != "" && result.Move.UCI() != tc.want {
+				t.Fatalf("expected %s, got %s (score %d)", tc.want, result.Move.UCI(), result.Score)
+			}
+			mate, isMate := result.Score.Mate()
+			if tc.mate != 0 && (!isMate || mate != tc.mate) {
+				t.Fatalf("expected mate in %d, got score %d", tc.mate, result.Score)
+			}
+			if len(result.PV) == 0 || result.PV[0] != result.Move {
+				t.Fatalf("expected the principal variation to start with the best move, got %v", result.PV)
+			}
+			if b.ToFEN() != tc.fen {
+				t.Fatalf("search modified the board: %q", b.ToFEN())
+			}
+		})
+	}
+}
+
+func TestSearchMateInTwoPlaysIt(t *testing.T) {
+	b := mustLoadFEN(t, "k7/8/2K5/8/8/8/8/7R w - - 0 1")
+	e := New(1)
+	for ply := 0; ply < 3; ply++ {
+		result, err := e.Search(context.Background(), b, Limits{Depth: 4})
+		if err != nil {
+			t.Fatalf("ply %d: %v", ply, err)
+		}
+		if err := b.MakeMove(result.Move); err != nil {
+			t.Fatalf("ply %d: engine chose illegal move %s: %v", ply, result.Move, err)
+		}
+	}
+	if !b.IsCheckmate(chess.Black) {
+		t.Fatalf("expected checkmate after three plies, got %q", b.ToFEN())
+	}
+}
+
+func TestSearchNoLegalMoves(t *testing.T) {
+	b := mustLoadFEN(t, "R5k1/5ppp/8/8/8/8/8/6K1 b - - 1 1")
+	if _, err := New(1).Search(context.Background(), b, Limits{Depth: 2}); !errors.Is(err, ErrNoLegalMoves) {
+		t.Fatalf("expected ErrNoLegalMoves, got %v", err)
+	}
+}
+
+func TestSearchVariantRules(t *testing.T) {
+	// in King of the Hill Kd4 wins on the spot, in Three-check Rh8+ does
+	cases := []struct {
+		variant chess.Variant
+		fen     string
+		want    string
+	}{
+		{chess.KingOfTheHill, "r3k3/8/8/8/8/4K3/8/8 w - - 0 1", "e3d4"},
+		{chess.ThreeCheck, "4k3/8/8/8/8/8/q7/4K2R w - - 1+3 0 1", "h1h8"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.variant.Name(), func(t *testing.T) {
+			b, err := chess.LoadVariantFEN(tc.variant, tc.fen)
+			if err != nil {
+				t.Fatalf("load fen %q: %v", tc.fen, err)
+			}
+			result, err := New(1).Search(context.Background(), b, Limits{Depth: 3})
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			if result.Move.UCI() != tc.want {
+				t.Fatalf("expected %s, got %s (score %d)", tc.want, result.Move.UCI(), result.Score)
+			}
+			if mate, ok := result.Score.Mate(); !ok || mate != 1 {
+				t.Fatalf("expected a win in one, got score %d", result.Score)
+			}
+		})
+	}
+}
+
+func TestSearchLimits(t *testing.T) {
+	b := chess.NewBoard()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := New(1).Search(ctx, b, Limits{})
+	if err != nil {
+		t.Fatalf("cancelled search: %v", err)
+	}
+	if err := chess.ValidateMove(b, result.Move); err != nil {
+		t.Fatalf("cancelled search should still return a legal move, got %s: %v", result.Move, err)
+	}
+
+	result, err = New(1).Search(context.Background(), b, Limits{Nodes: 5000})
+	if err != nil {
+		t.Fatalf("node limited search: %v", err)
+	}
+	if result.Nodes > 5000 || result.Depth == 0 {
+		t.Fatalf("expected some depth within 5000 nodes, got depth %d after %d nodes", result.Depth, result.Nodes)
+	}
+
+	start := time.Now()
+	if _, err := New(1).Search(context.Background(), b, Limits{MoveTime: 50 * time.Millisecond}); err != nil {
+		t.Fatalf("timed search: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("timed search overran: %v", elapsed)
+	}
+}
+
+func TestEvaluateIsSymmetric(t *testing.T) {
+	if got := Evaluate(chess.NewBoard()); got != 0 {
+		t.Fatalf("expected the starting position to evaluate to 0, got %d", got)
+	}
+
+	// the same position with colours swapped, from the side to move
+	a := mustLoadFEN(t, "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1")
+	b := mustLoadFEN(t, "r2q1rk1/pP1p2pp/Q4n2/bbp1p3/Np6/1B3NBn/pPPP1PPP/R3K2R b KQ - 0 1")
+	if Evaluate(a) != Evaluate(b) {
+		t.Fatalf("mirrored positions differ: %d vs %d", Evaluate(a), Evaluate(b))
+	}
+}
+
+func TestScoreMate(t *testing.T) {
+	cases := []struct {
+		score Score
+		mate  int
+		ok    bool
+	}{
+		{MateScore - 1, 1, true},
+		{MateScore - 3, 2, true},
+		{-MateScore + 2, -1, true},
+		{-MateScore + 4, -2, true},
+		{150, 0, false},
+	}
+	for _, tc := range cases {
+		if mate, ok := tc.score.Mate(); mate != tc.mate || ok != tc.ok {
+			t.Fatalf("score %d: expected (%d, %v), got (%d, %v)", tc.score, tc.mate, tc.ok, mate, ok)
+		}
+	}
+}
+
+func TestSearchMultiPV(t *testing.T) {
+	b := mustLoadFEN(t, "4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1")
+	result, err := New(1).Search(context.Background(), b, Limits{Depth: 3, MultiPV: 3})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(result.Lines) != 3 {
+		t.Fatalf("expected 3 lines, got %d", len(result.Lines))
+	}
+	seen := map[chess.Move]bool{}
+	for i, line := range result.Lines {
+		if len(line.PV) == 0 || seen[line.PV[0]] {
+			t.Fatalf("line %d: expected a new first move, got %v", i, line.PV)
+		}
+		seen[line.PV[0]] = true
+		if i > 0 && line.Score > result.Lines[i-1].Score {
+			t.Fatalf("lines are not sorted by score: %d after %d", line.Score, result.Lines[i-1].Score)
+		}
+	}
+	if result.Move.UCI() != "d2d5" || result.Lines[0].Score != result.Score {
+		t.Fatalf("expected the first line to be the best move, got %s", result.Move.UCI())
+	}
+	if result.Lines[1].Score > result.Score-500 {
+		t.Fatalf("expected every other move to lose the queen capture, got %d vs %d", result.Lines[1].Score, result.Score)
+	}
+}
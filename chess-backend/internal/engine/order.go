@@ -0,0 +1,96 @@
+package engine
+
+import "chess-backend/internal/chess"
+
+// Move ordering decides how quickly alpha-beta finds a cutoff. The hash move
+// goes first, then captures by most valuable victim / least valuable
+// attacker, queen promotions, the two killer moves of this ply, and finally
+// quiet moves by their history score.
+const (
+	orderHashMove  = 1_000_000
+	orderCapture   = 100_000
+	orderPromotion = 90_000
+	orderKiller    = 80_000
+
+	historyLimit = 60_000 // keeps history scores below the killers
+
+	// historyRows are the 64 from squares and a row per piece type for
+	// Crazyhouse drops
+	historyRows = 64 + 6
+)
+
+// orderValue is a rough piece value used only to rank captures.
+var orderValue = [6]int{1, 3, 3, 5, 10, 9}
+
+func isCapture(b *chess.Board, m chess.Move) bool {
+	if p := b.PieceAt(m.To); p != nil {
+		return p.Color != b.Turn() // Chess960 castling lands on an own rook
+	}
+	p := b.PieceAt(m.From)
+	return p != nil && p.Type == chess.Pawn && m.From.File() != m.To.File()
+}
+
+func (s *searcher) scoreMoves(moves []chess.Move, scores []int, hashMove chess.Move, ply int) {
+	b := s.board
+	us := b.Turn()
+	for i, m := range moves {
+		switch {
+		case m == hashMove:
+			scores[i] = orderHashMove
+		case isCapture(b, m):
+			victim := chess.Pawn // en passant
+			if p := b.PieceAt(m.To); p != nil {
+				victim = p.Type
+			}
+			attacker := b.PieceAt(m.From).Type
+			scores[i] = orderCapture + 10*orderValue[victim] - orderValue[attacker]
+		case m.Promotion == chess.Queen:
+			scores[i] = orderPromotion
+		case m == s.killers[ply][0]:
+			scores[i] = orderKiller + 1
+		case m == s.killers[ply][1]:
+			scores[i] = orderKiller
+		default:
+			scores[i] = s.history[us][historyRow(m)][m.To]
+		}
+	}
+}
+
+func historyRow(m chess.Move) int {
+	if m.IsDrop() {
+		return 64 + int(m.Drop)
+	}
+	return int(m.From)
+}
+
+// pickMove swaps the best remaining move into position i. Picking lazily is
+// cheaper than sorting because most nodes cut off after a few moves.
+func pickMove(moves []chess.Move, scores []int, i int) {
+	best := i
+	for j := i + 1; j < len(moves); j++ {
+		if scores[j] > scores[best] {
+			best = j
+		}
+	}
+	moves[i], moves[best] = moves[best], moves[i]
+	scores[i], scores[best] = scores[best], scores[i]
+}
+
+// rememberQuiet records a quiet move that caused a beta cutoff.
+func (s *searcher) rememberQuiet(m chess.Move, depth, ply int) {
+	if s.killers[ply][0] != m {
+		s.killers[ply][1] = s.killers[ply][0]
+		s.killers[ply][0] = m
+	}
+
+	h := &s.history[s.board.Turn()]
+	from := historyRow(m)
+	h[from][m.To] += depth * depth
+	if h[from][m.To] > historyLimit {
+		for from := range h {
+			for to := range h[from] {
+				h[from][to] /= 2
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package engine
+
+import (
+	"context"
+	"sync"
+
+	"chess-backend/internal/chess"
+)
+
+// Searcher finds moves in a position. Engine is the built-in one; external
+// engines can stand in for it.
+type Searcher interface {
+	Search(ctx context.Context, board *chess.Board, limits Limits) (Result, error)
+}
+
+// Pool is a Searcher that is safe for concurrent use: every search borrows an
+// Engine of its own, and idle engines are kept for later searches.
+type Pool struct {
+	engines sync.Pool
+}
+
+func NewPool(hashMB int) *Pool {
+	p := &Pool{}
+	p.engines.New = func() any { return New(hashMB) }
+	return p
+}
+
+func (p *Pool) Search(ctx context.Context, board *chess.Board, limits Limits) (Result, error) {
+	e := p.engines.Get().(*Engine)
+	defer p.engines.Put(e)
+	return e.Search(ctx, board, limits)
+}
@@ -0,0 +1,259 @@
+package engine
+
+import (
+	"context"
+	"slices"
+
+	"chess-backend/internal/chess"
+)
+
+// checkInterval is how many nodes pass between checks of the context, which
+// is too slow to consult at every node.
+const checkInterval = 1024
+
+// searcher holds the state of one Search call.
+type searcher struct {
+	ctx       context.Context
+	board     *chess.Board
+	tt        *transpositionTable
+	nodeLimit uint64
+	// variantRules is set when the board's variant ends games in ways of
+	// its own, which the search then has to ask about at every node
+	variantRules bool
+
+	nodes   uint64
+	stopped bool
+
+	// rootExclude lists root moves to skip, for finding the next best line
+	rootExclude []chess.Move
+
+	killers [maxPly + 1][2]chess.Move
+	history [2][historyRows][64]int
+
+	// triangular principal variation table: pv[ply] is the best line found
+	// from ply onwards, pvLen[ply] the index it ends at
+	pv    [maxPly + 1][maxPly + 1]chess.Move
+	pvLen [maxPly + 1]int
+}
+
+func (s *searcher) shouldStop() bool {
+	if s.stopped {
+		return true
+	}
+	s.nodes++
+	if s.nodeLimit > 0 && s.nodes >= s.nodeLimit {
+		s.stopped = true
+	} else if s.nodes%checkInterval == 0 && s.ctx.Err() != nil {
+		s.stopped = true
+	}
+	return s.stopped
+}
+
+func (s *searcher) isDraw() bool {
+	b := s.board
+	if s.variantRules {
+		return b.CanClaimFiftyMoveDraw() || b.IsRepetition()
+	}
+	return b.CanClaimFiftyMoveDraw() || b.IsRepetition() || b.IsInsufficientMaterial()
+}
+
+// variantEnd scores a position the variant's rules have ended, from the side
+// to move's point of view.
+func (s *searcher) variantEnd(ply int) (Score, bool) {
+	o, over := s.board.Outcome()
+	switch {
+	case !over:
+		return 0, false
+	case o.Draw:
+		return 0, true
+	case o.Winner == s.board.Turn():
+		return MateScore - Score(ply), true
+	default:
+		return -MateScore + Score(ply), true
+	}
+}
+
+func (s *searcher) negamax(depth, ply int, alpha, beta Score) Score {
+	s.pvLen[ply] = ply
+	if s.shouldStop() {
+		return 0
+	}
+
+	b := s.board
+	if ply > 0 && s.isDraw() {
+		return 0
+	}
+	if ply > 0 && s.variantRules {
+		if score, over := s.variantEnd(ply); over {
+			return score
+		}
+	}
+	if ply >= maxPly {
+		return Evaluate(b)
+	}
+
+	inCheck := b.InCheck(b.Turn())
+	if inCheck {
+		depth++ // never stop the search in the middle of a check
+	}
+	if depth <= 0 {
+		return s.quiesce(ply, alpha, beta)
+	}
+
+	var hashMove chess.Move
+	if entry, ok := s.tt.probe(b.Hash()); ok {
+		hashMove = entry.move
+		if ply > 0 && int(entry.depth) >= depth {
+			score := scoreFromTT(entry.score, ply)
+			switch {
+			case entry.bound == boundExact,
+				entry.bound == boundLower && score >= beta,
+				entry.bound == boundUpper && score <= alpha:
+				return score
+			}
+		}
+	}
+
+	moves := b.LegalMoves()
+	if len(moves) == 0 {
+		if inCheck {
+			return -MateScore + Score(ply)
+		}
+		return 0
+	}
+	excluding := ply == 0 && len(s.rootExclude) > 0
+	if excluding {
+		moves = slices.DeleteFunc(moves, func(m chess.Move) bool {
+			return slices.Contains(s.rootExclude, m)
+		})
+		if len(moves) == 0 {
+			return -infinity
+		}
+	}
+
+	scores := make([]int, len(moves))
+	s.scoreMoves(moves, scores, hashMove, ply)
+
+	origAlpha := alpha
+	best := -infinity
+	var bestMove chess.Move
+
+	for i := range moves {
+		pickMove(moves, scores, i)
+		m := moves[i]
+		quiet := !isCapture(b, m) && m.Promotion == 0
+
+		u := b.DoMove(m)
+		var score Score
+		if i == 0 {
+			score = -s.negamax(depth-1, ply+1, -beta, -alpha)
+		} else {
+			// principal variation search: prove the move is no better with
+			// a null window and only search it fully when that fails
+			score = -s.negamax(depth-1, ply+1, -alpha-1, -alpha)
+			if score > alpha && score < beta {
+				score = -s.negamax(depth-1, ply+1, -beta, -alpha)
+			}
+		}
+		b.UndoMove(u)
+
+		if s.stopped {
+			return 0
+		}
+		if score <= best {
+			continue
+		}
+		best, bestMove = score, m
+		if score <= alpha {
+			continue
+		}
+
+		alpha = score
+		s.pv[ply][ply] = m
+		copy(s.pv[ply][ply+1:], s.pv[ply+1][ply+1:s.pvLen[ply+1]])
+		s.pvLen[ply] = s.pvLen[ply+1]
+
+		if alpha >= beta {
+			if quiet {
+				s.rememberQuiet(m, depth, ply)
+			}
+			break
+		}
+	}
+
+	// the best of the remaining root moves is not the position's value
+	if excluding {
+		return best
+	}
+
+	bnd := boundExact
+	switch {
+	case best >= beta:
+		bnd = boundLower
+	case best <= origAlpha:
+		bnd = boundUpper
+	}
+	s.tt.store(b.Hash(), bestMove, best, depth, bnd, ply)
+	return best
+}
+
+// quiesce resolves captures and promotions before evaluating, so the search
+// never stops in the middle of an exchange. In check every evasion is tried.
+func (s *searcher) quiesce(ply int, alpha, beta Score) Score {
+	s.pvLen[ply] = ply
+	if s.shouldStop() {
+		return 0
+	}
+
+	b := s.board
+	if s.variantRules {
+		if score, over := s.variantEnd(ply); over {
+			return score
+		}
+	}
+	if ply >= maxPly {
+		return Evaluate(b)
+	}
+
+	inCheck := b.InCheck(b.Turn())
+	if !inCheck {
+		standPat := Evaluate(b)
+		if standPat >= beta {
+			return standPat
+		}
+		alpha = max(alpha, standPat)
+	}
+
+	moves := b.LegalMoves()
+	if inCheck && len(moves) == 0 {
+		return -MateScore + Score(ply)
+	}
+	if !inCheck {
+		tactical := moves[:0]
+		for _, m := range moves {
+			if isCapture(b, m) || m.Promotion == chess.Queen {
+				tactical = append(tactical, m)
+			}
+		}
+		moves = tactical
+	}
+
+	scores := make([]int, len(moves))
+	s.scoreMoves(moves, scores, chess.Move{}, ply)
+
+	for i := range moves {
+		pickMove(moves, scores, i)
+		u := b.DoMove(moves[i])
+		score := -s.quiesce(ply+1, -beta, -alpha)
+		b.UndoMove(u)
+
+		if s.stopped {
+			return 0
+		}
+		if score >= beta {
+			return score
+		}
+		alpha = max(alpha, score)
+	}
+	return alpha
+}
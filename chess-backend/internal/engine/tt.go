@@ -0,0 +1,83 @@
+package engine
+
+import "chess-backend/internal/chess"
+
+type bound uint8
+
+const (
+	boundExact bound = iota
+	boundLower       // the search failed high; the score is at least this
+	boundUpper       // the search failed low; the score is at most this
+)
+
+type ttEntry struct {
+	key   uint64
+	move  chess.Move
+	score Score
+	depth int8
+	bound bound
+	used  bool
+}
+
+// transpositionTable caches search results by Zobrist key. It is a plain
+// power-of-two array: each key maps to one slot and a new result replaces the
+// old one unless the old one is for the same position at a greater depth.
+type transpositionTable struct {
+	entries []ttEntry
+	mask    uint64
+}
+
+func newTranspositionTable(sizeMB int) *transpositionTable {
+	n := uint64(1)
+	for entrySize := uint64(48); n*2*entrySize <= uint64(sizeMB)<<20; {
+		n *= 2
+	}
+	return &transpositionTable{entries: make([]ttEntry, n), mask: n - 1}
+}
+
+func (t *transpositionTable) probe(key uint64) (ttEntry, bool) {
+	e := t.entries[key&t.mask]
+	return e, e.used && e.key == key
+}
+
+func (t *transpositionTable) store(key uint64, move chess.Move, score Score, depth int, b bound, ply int) {
+	e := &t.entries[key&t.mask]
+	if e.used && e.key == key && int(e.depth) > depth {
+		return
+	}
+	*e = ttEntry{
+		key:   key,
+		move:  move,
+		score: scoreToTT(score, ply),
+		depth: int8(depth),
+		bound: b,
+		used:  true,
+	}
+}
+
+func (t *transpositionTable) clear() {
+	clear(t.entries)
+}
+
+// Mate scores count plies from the root, but a table entry can be reached at
+// any ply, so they are stored relative to the position itself.
+
+func scoreToTT(s Score, ply int) Score {
+	switch {
+	case s >= mateThreshold:
+		return s + Score(ply)
+	case s <= -mateThreshold:
+		return s - Score(ply)
+	}
+	return s
+}
+
+func scoreFromTT(s Score, ply int) Score {
+	switch {
+	case s >= mateThreshold:
+		return s - Score(ply)
+	case s <= -mateThreshold:
+		return s + Score(ply)
+	}
+	return s
+}
@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"chess-backend/internal/chess"
+	"chess-backend/internal/clock"
+	"chess-backend/internal/eco"
 	"github.com/google/uuid"
 )
 
@@ -11,8 +13,10 @@ type Game struct {
 	ID                  string
 	Board               *chess.Board
 	StartFEN            string
+	Variant             string // the chess.Variant's Name, e.g. "chess960"
 	Moves               []string
 	PendingDrawOfferBy  *chess.Color
+	PendingTakebackBy   *chess.Color
 	PlayerWhiteToken    string
 	PlayerBlackToken    string
 	PlayerWhiteJoinedAt *time.Time
@@ -22,6 +26,36 @@ type Game struct {
 	Result              string
 	Winner              string
 	EndedBy             string
+	Clock               *clock.Clock // nil for untimed games
+	Bot                 *Bot         // nil unless the server plays one side
+	Opening             *eco.Opening // deepest known opening reached; nil if none
+	Ply                 int          // number of moves recorded so far
+	// Version is bumped by every successful update; updates made from a stale
+	// copy fail with ErrConflict.
+	Version int64
+}
+
+// Bot is the computer opponent of a play-versus-computer game. Its seat holds
+// a token that is never handed out, so nobody can join or move for it.
+type Bot struct {
+	Color chess.Color
+	Level int
+}
+
+type MoveRecord struct {
+	UCI string
+	SAN string
+	// ClientMove, when set, is stored with the move so a retried submission
+	// can be answered with the original response instead of being replayed.
+	ClientMove *ClientMove
+}
+
+// ClientMove is the dedupe record for a move submitted with a client move ID.
+type ClientMove struct {
+	ID       string
+	Color    chess.Color
+	Ply      int    // filled in by the store
+	Response []byte // encoded response returned for the original submission
 }
 
 func NewGameID() (string, error) {
@@ -7,15 +7,17 @@ import (
 )
 
 type MemoryStore struct {
-	mu    sync.RWMutex
-	games map[string]*Game
-	moves map[string][]string
+	mu          sync.RWMutex
+	games       map[string]*Game
+	moves       map[string][]MoveRecord
+	clientMoves map[string]map[string]ClientMove
 }
 
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
-		games: make(map[string]*Game),
-		moves: make(map[string][]string),
+		games:       make(map[string]*Game),
+		moves:       make(map[string][]MoveRecord),
+		clientMoves: make(map[string]map[string]ClientMove),
 	}
 }
 
@@ -26,8 +28,30 @@ func (s *MemoryStore) CreateGame(_ context.Context, game *Game) error {
 	if _, exists := s.games[game.ID]; exists {
 		return errors.New("game already exists")
 	}
+	game.Ply = len(game.Moves)
 	s.games[game.ID] = cloneGame(game)
-	s.moves[game.ID] = append([]string(nil), game.Moves...)
+	records := make([]MoveRecord, 0, len(game.Moves))
+	for _, uci := range game.Moves {
+		records = append(records, MoveRecord{UCI: uci})
+	}
+	s.moves[game.ID] = records
+	return nil
+}
+
+func (s *MemoryStore) CreateGameWithMoves(_ context.Context, game *Game, moves []MoveRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.games[game.ID]; exists {
+		return errors.New("game already exists")
+	}
+	game.Ply = len(moves)
+	s.games[game.ID] = cloneGame(game)
+	records := make([]MoveRecord, 0, len(moves))
+	for _, m := range moves {
+		records = append(records, MoveRecord{UCI: m.UCI, SAN: m.SAN})
+	}
+	s.moves[game.ID] = records
 	return nil
 }
 
@@ -46,25 +70,65 @@ func (s *MemoryStore) UpdateGame(_ context.Context, game *Game) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, ok := s.games[game.ID]; !ok {
-		return ErrNotFound
+	if err := s.checkVersion(game); err != nil {
+		return err
 	}
+	game.Version++
 	s.games[game.ID] = cloneGame(game)
 	return nil
 }
 
-func (s *MemoryStore) UpdateGameWithMove(_ context.Context, game *Game, move string) error {
+func (s *MemoryStore) UpdateGameWithMove(_ context.Context, game *Game, move MoveRecord) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if _, ok := s.games[game.ID]; !ok {
-		return ErrNotFound
+	if err := s.checkVersion(game); err != nil {
+		return err
 	}
+	if cm := move.ClientMove; cm != nil {
+		if _, exists := s.clientMoves[game.ID][cm.ID]; exists {
+			return ErrConflict
+		}
+		if s.clientMoves[game.ID] == nil {
+			s.clientMoves[game.ID] = make(map[string]ClientMove)
+		}
+		record := *cm
+		record.Ply = game.Ply + 1
+		record.Response = append([]byte(nil), cm.Response...)
+		s.clientMoves[game.ID][cm.ID] = record
+		move.ClientMove = nil
+	}
+	game.Version++
+	game.Ply++
 	s.games[game.ID] = cloneGame(game)
 	s.moves[game.ID] = append(s.moves[game.ID], move)
 	return nil
 }
 
+func (s *MemoryStore) UpdateGameWithTakeback(_ context.Context, game *Game, plies int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.checkVersion(game); err != nil {
+		return err
+	}
+	moves := s.moves[game.ID]
+	if plies > len(moves) {
+		return ErrNoMovesToTakeBack
+	}
+	game.Version++
+	game.Ply -= plies
+	s.games[game.ID] = cloneGame(game)
+	s.moves[game.ID] = moves[:len(moves)-plies]
+	// a retried client move id must not replay a move that was taken back
+	for id, cm := range s.clientMoves[game.ID] {
+		if cm.Ply > game.Ply {
+			delete(s.clientMoves[game.ID], id)
+		}
+	}
+	return nil
+}
+
 func (s *MemoryStore) ListMoves(_ context.Context, id string) ([]string, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -77,10 +141,49 @@ func (s *MemoryStore) ListMoves(_ context.Context, id string) ([]string, error)
 		return []string{}, nil
 	}
 	out := make([]string, len(moves))
-	copy(out, moves)
+	for i, m := range moves {
+		out[i] = m.UCI
+	}
 	return out, nil
 }
 
+func (s *MemoryStore) GetClientMove(_ context.Context, gameID, clientMoveID string) (*ClientMove, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cm, ok := s.clientMoves[gameID][clientMoveID]
+	if !ok {
+		return nil, nil
+	}
+	cm.Response = append([]byte(nil), cm.Response...)
+	return &cm, nil
+}
+
+func (s *MemoryStore) ListActiveTimedGames(_ context.Context) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := []string{}
+	for id, game := range s.games {
+		if game.Clock != nil && normalizeResult(game.Result) == "ongoing" {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// checkVersion must be called with s.mu held.
+func (s *MemoryStore) checkVersion(game *Game) error {
+	stored, ok := s.games[game.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if stored.Version != game.Version {
+		return ErrConflict
+	}
+	return nil
+}
+
 func cloneGame(game *Game) *Game {
 	if game == nil {
 		return nil
@@ -94,6 +197,10 @@ func cloneGame(game *Game) *Game {
 		color := *game.PendingDrawOfferBy
 		clone.PendingDrawOfferBy = &color
 	}
+	if game.PendingTakebackBy != nil {
+		color := *game.PendingTakebackBy
+		clone.PendingTakebackBy = &color
+	}
 	if game.PlayerWhiteJoinedAt != nil {
 		ts := *game.PlayerWhiteJoinedAt
 		clone.PlayerWhiteJoinedAt = &ts
@@ -105,5 +212,14 @@ func cloneGame(game *Game) *Game {
 	if game.Moves != nil {
 		clone.Moves = append([]string(nil), game.Moves...)
 	}
+	clone.Clock = game.Clock.Clone()
+	if game.Bot != nil {
+		b := *game.Bot
+		clone.Bot = &b
+	}
+	if game.Opening != nil {
+		o := *game.Opening
+		clone.Opening = &o
+	}
 	return &clone
 }
@@ -3,12 +3,18 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
 	"chess-backend/internal/chess"
+	"chess-backend/internal/clock"
+	"chess-backend/internal/eco"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -33,14 +39,68 @@ func (s *PostgresStore) Close() {
 }
 
 func (s *PostgresStore) CreateGame(ctx context.Context, game *Game) error {
+	return insertGame(ctx, s.pool, game)
+}
+
+// CreateGameWithMoves inserts the game and its moves in one transaction.
+func (s *PostgresStore) CreateGameWithMoves(ctx context.Context, game *Game, moves []MoveRecord) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := insertGame(ctx, tx, game); err != nil {
+		return err
+	}
+	// the board is after the last move, so count the movers back from it
+	mover := game.Board.Turn()
+	if len(moves)%2 == 1 {
+		mover = mover.Opposite()
+	}
+	for i, m := range moves {
+		ply := i + 1
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO moves (game_id, ply, move_number, color, uci, san, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, game.ID, ply, (ply+1)/2, moveColor(mover), m.UCI, nullIfEmpty(m.SAN), game.UpdatedAt); err != nil {
+			return err
+		}
+		mover = mover.Opposite()
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	game.Ply = len(moves)
+	return nil
+}
+
+// execer is what insertGame needs from a pool or a transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+func insertGame(ctx context.Context, db execer, game *Game) error {
 	query := `
 		INSERT INTO games (
 			id, start_fen, current_fen, result, winner, ended_by,
 			pending_draw_offer_by, player_white_token, player_black_token,
-			player_white_joined_at, player_black_joined_at, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			player_white_joined_at, player_black_joined_at, created_at, updated_at, clock,
+			pending_takeback_by, version, bot_color, bot_level,
+			opening_eco, opening_name, opening_variation, variant
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 	`
-	_, err := s.pool.Exec(
+	clockJSON, err := clockToNullableJSON(game.Clock)
+	if err != nil {
+		return err
+	}
+	var botColor, botLevel interface{}
+	if game.Bot != nil {
+		botColor = game.Bot.Color.String()
+		botLevel = game.Bot.Level
+	}
+	openingECO, openingName, openingVariation := openingColumns(game.Opening)
+	_, err = db.Exec(
 		ctx,
 		query,
 		game.ID,
@@ -56,6 +116,15 @@ func (s *PostgresStore) CreateGame(ctx context.Context, game *Game) error {
 		nullIfNilTime(game.PlayerBlackJoinedAt),
 		game.CreatedAt,
 		game.UpdatedAt,
+		clockJSON,
+		colorToNullableString(game.PendingTakebackBy),
+		game.Version,
+		botColor,
+		botLevel,
+		openingECO,
+		openingName,
+		openingVariation,
+		variantOrStandard(game.Variant),
 	)
 	return err
 }
@@ -64,7 +133,9 @@ func (s *PostgresStore) GetGame(ctx context.Context, id string) (*Game, error) {
 	query := `
 		SELECT id, start_fen, current_fen, result, winner, ended_by,
 		       pending_draw_offer_by, player_white_token, player_black_token,
-		       player_white_joined_at, player_black_joined_at, created_at, updated_at
+		       player_white_joined_at, player_black_joined_at, created_at, updated_at, clock,
+		       pending_takeback_by, version, bot_color, bot_level,
+		       opening_eco, opening_name, opening_variation, variant
 		FROM games
 		WHERE id = $1
 	`
@@ -82,6 +153,15 @@ func (s *PostgresStore) GetGame(ctx context.Context, id string) (*Game, error) {
 		blackJoined sql.NullTime
 		created     time.Time
 		updated     time.Time
+		clockJSON   []byte
+		takeback    sql.NullString
+		version     int64
+		botColor    sql.NullString
+		botLevel    sql.NullInt32
+		openingECO  sql.NullString
+		openingName sql.NullString
+		openingVar  sql.NullString
+		variant     string
 	)
 
 	err := s.pool.QueryRow(ctx, query, id).Scan(
@@ -98,6 +178,15 @@ func (s *PostgresStore) GetGame(ctx context.Context, id string) (*Game, error) {
 		&blackJoined,
 		&created,
 		&updated,
+		&clockJSON,
+		&takeback,
+		&version,
+		&botColor,
+		&botLevel,
+		&openingECO,
+		&openingName,
+		&openingVar,
+		&variant,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -106,18 +195,25 @@ func (s *PostgresStore) GetGame(ctx context.Context, id string) (*Game, error) {
 		return nil, err
 	}
 
-	board, err := chess.LoadFEN(fen)
+	moves, err := s.listMoveUCIs(ctx, gameID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid FEN in store: %w", err)
+		return nil, err
+	}
+	board, err := rebuildBoard(gameID, variant, startFEN, fen, moves)
+	if err != nil {
+		return nil, err
 	}
 
 	game := &Game{
 		ID:        gameID,
 		Board:     board,
 		StartFEN:  startFEN,
+		Variant:   variant,
 		Result:    result,
 		CreatedAt: created,
 		UpdatedAt: updated,
+		Version:   version,
+		Ply:       len(moves),
 	}
 	if whiteToken.Valid {
 		game.PlayerWhiteToken = whiteToken.String
@@ -145,6 +241,28 @@ func (s *PostgresStore) GetGame(ctx context.Context, id string) (*Game, error) {
 			game.PendingDrawOfferBy = &color
 		}
 	}
+	if takeback.Valid {
+		color, err := parseColor(takeback.String)
+		if err == nil {
+			game.PendingTakebackBy = &color
+		}
+	}
+	if botColor.Valid {
+		color, err := parseColor(botColor.String)
+		if err == nil {
+			game.Bot = &Bot{Color: color, Level: int(botLevel.Int32)}
+		}
+	}
+	if openingECO.Valid {
+		game.Opening = &eco.Opening{ECO: openingECO.String, Name: openingName.String, Variation: openingVar.String}
+	}
+	if clockJSON != nil {
+		var c clock.Clock
+		if err := json.Unmarshal(clockJSON, &c); err != nil {
+			return nil, fmt.Errorf("invalid clock in store: %w", err)
+		}
+		game.Clock = &c
+	}
 
 	return game, nil
 }
@@ -161,9 +279,16 @@ func (s *PostgresStore) UpdateGame(ctx context.Context, game *Game) error {
 		    player_black_token = $8,
 		    player_white_joined_at = $9,
 		    player_black_joined_at = $10,
-		    updated_at = $11
-		WHERE id = $1
+		    updated_at = $11,
+		    clock = $12,
+		    pending_takeback_by = $13,
+		    version = version + 1
+		WHERE id = $1 AND version = $14
 	`
+	clockJSON, err := clockToNullableJSON(game.Clock)
+	if err != nil {
+		return err
+	}
 	ct, err := s.pool.Exec(
 		ctx,
 		query,
@@ -178,17 +303,21 @@ func (s *PostgresStore) UpdateGame(ctx context.Context, game *Game) error {
 		nullIfNilTime(game.PlayerWhiteJoinedAt),
 		nullIfNilTime(game.PlayerBlackJoinedAt),
 		game.UpdatedAt,
+		clockJSON,
+		colorToNullableString(game.PendingTakebackBy),
+		game.Version,
 	)
 	if err != nil {
 		return err
 	}
 	if ct.RowsAffected() == 0 {
-		return ErrNotFound
+		return s.conflictOrNotFound(ctx, game.ID)
 	}
+	game.Version++
 	return nil
 }
 
-func (s *PostgresStore) UpdateGameWithMove(ctx context.Context, game *Game, move string) error {
+func (s *PostgresStore) UpdateGameWithMove(ctx context.Context, game *Game, move MoveRecord) error {
 	query := `
 		WITH updated AS (
 			UPDATE games
@@ -201,8 +330,14 @@ func (s *PostgresStore) UpdateGameWithMove(ctx context.Context, game *Game, move
 			    player_black_token = $8,
 			    player_white_joined_at = $9,
 			    player_black_joined_at = $10,
-			    updated_at = $11
-			WHERE id = $1
+			    updated_at = $11,
+			    clock = $14,
+			    pending_takeback_by = $15,
+			    opening_eco = $20,
+			    opening_name = $21,
+			    opening_variation = $22,
+			    version = version + 1
+			WHERE id = $1 AND version = $16
 			RETURNING id
 		),
 		next_ply AS (
@@ -211,20 +346,38 @@ func (s *PostgresStore) UpdateGameWithMove(ctx context.Context, game *Game, move
 			WHERE game_id = $1
 		),
 		inserted AS (
-			INSERT INTO moves (game_id, ply, move_number, color, uci, created_at)
+			INSERT INTO moves (game_id, ply, move_number, color, uci, san, created_at)
 			SELECT
 				$1,
 				next_ply.ply,
 				(next_ply.ply + 1) / 2,
-				CASE WHEN next_ply.ply % 2 = 1 THEN 'w' ELSE 'b' END,
+				$23::text,
 				$12,
+				$13,
 				$11
-			FROM next_ply
+			FROM next_ply, updated
 			RETURNING 1
+		),
+		client_move AS (
+			INSERT INTO client_moves (game_id, client_move_id, color, ply, response, created_at)
+			SELECT $1, $17, $18, next_ply.ply, $19, $11
+			FROM next_ply, updated
+			WHERE $17::text IS NOT NULL
 		)
 		SELECT 1 FROM updated, inserted
 	`
-	err := s.pool.QueryRow(
+	var clientMoveID, clientMoveColor, clientMoveResponse interface{}
+	if cm := move.ClientMove; cm != nil {
+		clientMoveID = cm.ID
+		clientMoveColor = cm.Color.String()
+		clientMoveResponse = cm.Response
+	}
+	clockJSON, err := clockToNullableJSON(game.Clock)
+	if err != nil {
+		return err
+	}
+	openingECO, openingName, openingVariation := openingColumns(game.Opening)
+	err = s.pool.QueryRow(
 		ctx,
 		query,
 		game.ID,
@@ -238,18 +391,203 @@ func (s *PostgresStore) UpdateGameWithMove(ctx context.Context, game *Game, move
 		nullIfNilTime(game.PlayerWhiteJoinedAt),
 		nullIfNilTime(game.PlayerBlackJoinedAt),
 		game.UpdatedAt,
-		move,
+		move.UCI,
+		nullIfEmpty(move.SAN),
+		clockJSON,
+		colorToNullableString(game.PendingTakebackBy),
+		game.Version,
+		clientMoveID,
+		clientMoveColor,
+		clientMoveResponse,
+		openingECO,
+		openingName,
+		openingVariation,
+		// the board is after the move, so the mover is the side not to move
+		moveColor(game.Board.Turn().Opposite()),
 	).Scan(new(int))
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return ErrNotFound
+			return s.conflictOrNotFound(ctx, game.ID)
+		}
+		if isUniqueViolation(err) {
+			return ErrConflict
 		}
 		return err
 	}
+	game.Version++
+	game.Ply++
+	return nil
+}
+
+// UpdateGameWithTakeback saves the game and removes its last plies moves in a
+// single transaction.
+func (s *PostgresStore) UpdateGameWithTakeback(ctx context.Context, game *Game, plies int) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	ct, err := tx.Exec(ctx, `
+		UPDATE games
+		SET version = version + 1
+		WHERE id = $1 AND version = $2
+	`, game.ID, game.Version)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return s.conflictOrNotFound(ctx, game.ID)
+	}
+
+	ct, err = tx.Exec(ctx, `
+		DELETE FROM moves
+		WHERE game_id = $1
+		  AND ply > (SELECT COALESCE(MAX(ply), 0) FROM moves WHERE game_id = $1) - $2
+	`, game.ID, plies)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() != int64(plies) {
+		return ErrNoMovesToTakeBack
+	}
+
+	// a retried client move id must not replay a move that was taken back
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM client_moves
+		WHERE game_id = $1
+		  AND ply > (SELECT COALESCE(MAX(ply), 0) FROM moves WHERE game_id = $1)
+	`, game.ID); err != nil {
+		return err
+	}
+
+	clockJSON, err := clockToNullableJSON(game.Clock)
+	if err != nil {
+		return err
+	}
+	openingECO, openingName, openingVariation := openingColumns(game.Opening)
+	ct, err = tx.Exec(ctx, `
+		UPDATE games
+		SET current_fen = $2,
+		    result = $3,
+		    winner = $4,
+		    ended_by = $5,
+		    pending_draw_offer_by = $6,
+		    pending_takeback_by = $7,
+		    updated_at = $8,
+		    clock = $9,
+		    opening_eco = $10,
+		    opening_name = $11,
+		    opening_variation = $12
+		WHERE id = $1
+	`,
+		game.ID,
+		game.Board.ToFEN(),
+		normalizeResult(game.Result),
+		nullIfEmpty(game.Winner),
+		nullIfEmpty(game.EndedBy),
+		colorToNullableString(game.PendingDrawOfferBy),
+		colorToNullableString(game.PendingTakebackBy),
+		game.UpdatedAt,
+		clockJSON,
+		openingECO,
+		openingName,
+		openingVariation,
+	)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	game.Version++
+	game.Ply -= plies
 	return nil
 }
 
+// conflictOrNotFound explains why a versioned update matched no rows.
+func (s *PostgresStore) conflictOrNotFound(ctx context.Context, id string) error {
+	var exists bool
+	err := s.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM games WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrConflict
+}
+
 func (s *PostgresStore) ListMoves(ctx context.Context, id string) ([]string, error) {
+	moves, err := s.listMoveUCIs(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(moves) == 0 {
+		_, err := s.GetGame(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return moves, nil
+}
+
+func (s *PostgresStore) GetClientMove(ctx context.Context, gameID, clientMoveID string) (*ClientMove, error) {
+	query := `
+		SELECT color, ply, response
+		FROM client_moves
+		WHERE game_id = $1 AND client_move_id = $2
+	`
+	var (
+		color string
+		cm    = ClientMove{ID: clientMoveID}
+	)
+	err := s.pool.QueryRow(ctx, query, gameID, clientMoveID).Scan(&color, &cm.Ply, &cm.Response)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	cm.Color, err = parseColor(color)
+	if err != nil {
+		return nil, err
+	}
+	return &cm, nil
+}
+
+func (s *PostgresStore) ListActiveTimedGames(ctx context.Context) ([]string, error) {
+	query := `
+		SELECT id
+		FROM games
+		WHERE result = 'ongoing' AND clock IS NOT NULL
+	`
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return ids, nil
+}
+
+func (s *PostgresStore) listMoveUCIs(ctx context.Context, id string) ([]string, error) {
 	query := `
 		SELECT uci
 		FROM moves
@@ -273,15 +611,65 @@ func (s *PostgresStore) ListMoves(ctx context.Context, id string) ([]string, err
 	if rows.Err() != nil {
 		return nil, rows.Err()
 	}
+	return moves, nil
+}
 
-	if len(moves) == 0 {
-		_, err := s.GetGame(ctx, id)
+// rebuildBoard replays the stored moves from the start position so the board
+// carries its position history for repetition checks and the undo stack for
+// takebacks. The moves were validated when they were made, so the replay
+// skips validation. current_fen stays the source of truth: if the replay
+// disagrees with it, the mismatch is logged and the plain FEN board wins.
+func rebuildBoard(id, variant, startFEN, currentFEN string, moves []string) (*chess.Board, error) {
+	v, ok := chess.VariantByName(variant)
+	if !ok {
+		return nil, fmt.Errorf("unknown variant in store: %q", variant)
+	}
+
+	current, err := chess.LoadVariantFEN(v, currentFEN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FEN in store: %w", err)
+	}
+
+	board, err := replayMoves(v, startFEN, moves)
+	if err == nil && board.ToFEN() != current.ToFEN() {
+		err = fmt.Errorf("replay reaches %q, stored position is %q", board.ToFEN(), currentFEN)
+	}
+	if err != nil {
+		log.Printf("store: game %s: %v; using the stored position without history", id, err)
+		return current, nil
+	}
+	return board, nil
+}
+
+func replayMoves(v chess.Variant, startFEN string, moves []string) (*chess.Board, error) {
+	board, err := chess.LoadVariantFEN(v, startFEN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start FEN: %w", err)
+	}
+	for i, uci := range moves {
+		move, err := chess.ParseUCI(uci)
+		if err == nil {
+			err = board.ReplayMove(move)
+		}
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("ply %d (%s): %w", i+1, uci, err)
 		}
 	}
+	return board, nil
+}
 
-	return moves, nil
+func openingColumns(o *eco.Opening) (ecoCode, name, variation interface{}) {
+	if o == nil {
+		return nil, nil, nil
+	}
+	return o.ECO, o.Name, nullIfEmpty(o.Variation)
+}
+
+func variantOrStandard(variant string) string {
+	if variant == "" {
+		return chess.Standard.Name()
+	}
+	return variant
 }
 
 func nullIfEmpty(value string) interface{} {
@@ -305,6 +693,30 @@ func normalizeResult(result string) string {
 	return result
 }
 
+func clockToNullableJSON(c *clock.Clock) (interface{}, error) {
+	if c == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// moveColor is a mover's colour as the moves table writes it.
+func moveColor(c chess.Color) string {
+	if c == chess.White {
+		return "w"
+	}
+	return "b"
+}
+
 func colorToNullableString(color *chess.Color) interface{} {
 	if color == nil {
 		return nil
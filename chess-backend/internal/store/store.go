@@ -5,12 +5,24 @@ import (
 	"errors"
 )
 
-var ErrNotFound = errors.New("game not found")
+var (
+	ErrNotFound          = errors.New("game not found")
+	ErrNoMovesToTakeBack = errors.New("not enough moves to take back")
+	// ErrConflict means the game changed since it was read; reload and retry.
+	ErrConflict = errors.New("game was modified concurrently")
+)
 
 type GameStore interface {
 	CreateGame(ctx context.Context, game *Game) error
+	// CreateGameWithMoves creates game with moves already played from its
+	// start position, all or nothing, e.g. for an imported PGN.
+	CreateGameWithMoves(ctx context.Context, game *Game, moves []MoveRecord) error
 	GetGame(ctx context.Context, id string) (*Game, error)
 	UpdateGame(ctx context.Context, game *Game) error
-	UpdateGameWithMove(ctx context.Context, game *Game, move string) error
+	UpdateGameWithMove(ctx context.Context, game *Game, move MoveRecord) error
+	UpdateGameWithTakeback(ctx context.Context, game *Game, plies int) error
 	ListMoves(ctx context.Context, id string) ([]string, error)
+	// GetClientMove returns nil, nil if no move was stored under clientMoveID.
+	GetClientMove(ctx context.Context, gameID, clientMoveID string) (*ClientMove, error)
+	ListActiveTimedGames(ctx context.Context) ([]string, error)
 }
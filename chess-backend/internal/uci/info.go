@@ -0,0 +1,162 @@
+package uci
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"chess-backend/internal/chess"
+	"chess-backend/internal/engine"
+)
+
+// info is the part of an "info" line a search result is built from.
+type info struct {
+	depth   int
+	multiPV int
+	score   engine.Score
+	nodes   uint64
+	pv      []chess.Move
+	// hasScore is false for lines without a score and for bounds, which are
+	// only provisional
+	hasScore bool
+}
+
+// parseInfo reads the fields of an "info" line that matter here. Unknown
+// fields are skipped; "string" ends the line as the spec requires.
+func parseInfo(fields []string) (info, error) {
+	in := info{multiPV: 1}
+	number := func(i int) (int, error) {
+		if i >= len(fields) {
+			return 0, fmt.Errorf("%w: missing value for %s", ErrProtocol, fields[i-1])
+		}
+		return strconv.Atoi(fields[i])
+	}
+
+	for i := 0; i < len(fields); i++ {
+		var err error
+		switch fields[i] {
+		case "depth":
+			i++
+			in.depth, err = number(i)
+		case "multipv":
+			i++
+			in.multiPV, err = number(i)
+		case "nodes":
+			i++
+			var n int
+			n, err = number(i)
+			in.nodes = uint64(max(n, 0))
+		case "score":
+			if i+2 >= len(fields) {
+				return info{}, fmt.Errorf("%w: incomplete score", ErrProtocol)
+			}
+			var n int
+			if n, err = strconv.Atoi(fields[i+2]); err != nil {
+				break
+			}
+			switch fields[i+1] {
+			case "cp":
+				in.score, in.hasScore = engine.Score(n), true
+			case "mate":
+				in.score, in.hasScore = mateScore(n), true
+			default:
+				return info{}, fmt.Errorf("%w: unknown score kind %q", ErrProtocol, fields[i+1])
+			}
+			i += 2
+			if i+1 < len(fields) && (fields[i+1] == "lowerbound" || fields[i+1] == "upperbound") {
+				in.hasScore = false
+				i++
+			}
+		case "pv":
+			for _, f := range fields[i+1:] {
+				m, err := chess.ParseUCI(f)
+				if err != nil {
+					return info{}, fmt.Errorf("%w: invalid pv move %q", ErrProtocol, f)
+				}
+				in.pv = append(in.pv, m)
+			}
+			return in, nil
+		case "string":
+			return in, nil
+		}
+		if err != nil {
+			return info{}, fmt.Errorf("%w: %v", ErrProtocol, err)
+		}
+	}
+	return in, nil
+}
+
+// mateScore converts "mate n", in moves, to the engine's scores, which count
+// plies from the root: mating in n moves takes 2n-1 plies, being mated in n
+// takes 2n.
+func mateScore(n int) engine.Score {
+	if n > 0 {
+		return engine.MateScore - engine.Score(2*n-1)
+	}
+	return -engine.MateScore + engine.Score(-2*n)
+}
+
+// collector gathers the output of one search.
+type collector struct {
+	board   *chess.Board
+	multiPV int    // lines asked for; higher multipv indexes are ignored
+	lines   []info // latest scored line for each multipv, first is index 0
+	nodes   uint64
+}
+
+// add handles one output line. It reports done with the best move once the
+// engine sends bestmove.
+func (c *collector) add(line string) (best chess.Move, done bool, err error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return chess.Move{}, false, nil
+	}
+
+	switch fields[0] {
+	case "info":
+		in, err := parseInfo(fields[1:])
+		if err != nil {
+			return chess.Move{}, false, err
+		}
+		c.nodes = max(c.nodes, in.nodes)
+		if !in.hasScore || len(in.pv) == 0 || in.multiPV < 1 || in.multiPV > c.multiPV {
+			return chess.Move{}, false, nil
+		}
+		for len(c.lines) < in.multiPV {
+			c.lines = append(c.lines, info{})
+		}
+		c.lines[in.multiPV-1] = in
+	case "bestmove":
+		if len(fields) < 2 {
+			return chess.Move{}, false, fmt.Errorf("%w: bestmove without a move", ErrProtocol)
+		}
+		best, err := chess.ParseUCI(fields[1])
+		if err != nil {
+			return chess.Move{}, false, fmt.Errorf("%w: invalid bestmove %q", ErrProtocol, fields[1])
+		}
+		if err := chess.ValidateMove(c.board, best); err != nil {
+			return chess.Move{}, false, fmt.Errorf("%w: illegal bestmove %s", ErrProtocol, fields[1])
+		}
+		return best, true, nil
+	}
+	return chess.Move{}, false, nil
+}
+
+// result builds the search result around the engine's best move.
+func (c *collector) result(best chess.Move) engine.Result {
+	result := engine.Result{Move: best, Nodes: c.nodes, PV: []chess.Move{best}}
+	for _, in := range c.lines {
+		if len(in.pv) == 0 {
+			continue // a multipv index the engine skipped
+		}
+		if len(result.Lines) == 0 {
+			result.Depth = in.depth
+			result.Score = in.score
+			if in.pv[0] == best {
+				result.PV = in.pv
+			}
+		}
+		result.Lines = append(result.Lines, engine.Line{Score: in.score, PV: in.pv})
+	}
+	return result
+}
@@ -0,0 +1,122 @@
+package uci
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"chess-backend/internal/chess"
+	"chess-backend/internal/engine"
+)
+
+var ErrPoolClosed = errors.New("engine pool closed")
+
+// Pool runs searches on up to size engine processes at once. Processes are
+// started on demand, kept between searches and replaced when one dies or
+// misbehaves. Searches beyond size wait for a free process.
+type Pool struct {
+	cfg   Config
+	slots chan struct{} // one token per process in use or being started
+	idle  chan *Process
+
+	mu     sync.Mutex
+	closed bool
+	name   string
+}
+
+// NewPool starts one engine right away, so a wrong path or an engine that
+// does not speak UCI is reported at startup rather than on the first search.
+func NewPool(ctx context.Context, cfg Config, size int) (*Pool, error) {
+	size = max(size, 1)
+	proc, err := Start(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Pool{
+		cfg:   cfg,
+		slots: make(chan struct{}, size),
+		idle:  make(chan *Process, size),
+		name:  proc.Name(),
+	}
+	p.idle <- proc
+	return p, nil
+}
+
+// Name is the engine's name as reported during the handshake.
+func (p *Pool) Name() string {
+	return p.name
+}
+
+func (p *Pool) Search(ctx context.Context, board *chess.Board, limits engine.Limits) (engine.Result, error) {
+	proc, err := p.acquire(ctx)
+	if err != nil {
+		return engine.Result{}, err
+	}
+	result, err := proc.Search(ctx, board, limits)
+	p.release(proc)
+	return result, err
+}
+
+func (p *Pool) acquire(ctx context.Context) (*Process, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		<-p.slots
+		return nil, ErrPoolClosed
+	}
+
+	select {
+	case proc := <-p.idle:
+		return proc, nil
+	default:
+	}
+	proc, err := Start(ctx, p.cfg)
+	if err != nil {
+		<-p.slots
+		return nil, err
+	}
+	return proc, nil
+}
+
+func (p *Pool) release(proc *Process) {
+	p.mu.Lock()
+	keep := !p.closed && !proc.broken
+	if keep {
+		p.idle <- proc
+	}
+	p.mu.Unlock()
+
+	if !keep {
+		_ = proc.Close()
+	}
+	<-p.slots
+}
+
+// Close stops the idle engines; engines still searching are stopped when
+// their search ends.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+
+	var errs []error
+	for {
+		select {
+		case proc := <-p.idle:
+			errs = append(errs, proc.Close())
+		default:
+			return errors.Join(errs...)
+		}
+	}
+}
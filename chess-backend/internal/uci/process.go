@@ -0,0 +1,319 @@
+// Package uci drives external engines that speak the Universal Chess
+// Interface, such as Stockfish, as subprocesses. A Pool of them satisfies
+// engine.Searcher, so they can stand in for the built-in engine.
+package uci
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"chess-backend/internal/chess"
+	"chess-backend/internal/engine"
+)
+
+const (
+	// DefaultInitTimeout bounds the uci and isready handshake
+	DefaultInitTimeout = 10 * time.Second
+	// stopTimeout is how long an engine gets to answer stop with bestmove
+	// before it is considered hung
+	stopTimeout = 2 * time.Second
+	// quitTimeout is how long an engine gets to exit after quit
+	quitTimeout = time.Second
+)
+
+var (
+	ErrNoPath     = errors.New("no engine path configured")
+	ErrEngineDied = errors.New("engine process exited")
+	ErrProtocol   = errors.New("unexpected engine output")
+)
+
+// Config describes how to start an engine.
+type Config struct {
+	Path string
+	Args []string
+	// Options are sent with setoption after the handshake, e.g. Threads or
+	// Hash. MultiPV is managed per search and should not be set here.
+	Options     map[string]string
+	InitTimeout time.Duration
+}
+
+// Process is one running engine. It searches one position at a time and is
+// not safe for concurrent use; Pool hands processes out one search at a time.
+type Process struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan string // closed when the engine's stdout ends
+
+	name     string
+	multiPV  int
+	chess960 bool
+	variant  string // UCI_Variant last sent; "" before the first
+	broken   bool
+}
+
+// uciVariants maps variant names to their UCI_Variant values, as
+// Fairy-Stockfish and the multi-variant Stockfish fork name them.
+var uciVariants = map[string]string{
+	chess.KingOfTheHill.Name(): "kingofthehill",
+	chess.ThreeCheck.Name():    "3check",
+	chess.RacingKings.Name():   "racingkings",
+	chess.Crazyhouse.Name():    "crazyhouse",
+	chess.Atomic.Name():        "atomic",
+	chess.Antichess.Name():     "antichess",
+	chess.Horde.Name():         "horde",
+}
+
+// Start launches the engine and completes the handshake, applying the
+// configured options. ctx only bounds the startup.
+func Start(ctx context.Context, cfg Config) (*Process, error) {
+	if cfg.Path == "" {
+		return nil, ErrNoPath
+	}
+
+	cmd := exec.Command(cfg.Path, cfg.Args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start engine: %w", err)
+	}
+
+	p := &Process{
+		cmd:     cmd,
+		stdin:   stdin,
+		lines:   make(chan string, 64),
+		multiPV: 1,
+	}
+	go p.readLines(stdout)
+
+	timeout := cfg.InitTimeout
+	if timeout <= 0 {
+		timeout = DefaultInitTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := p.handshake(ctx, cfg.Options); err != nil {
+		p.kill()
+		_ = p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *Process) readLines(stdout io.Reader) {
+	defer close(p.lines)
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		p.lines <- scanner.Text()
+	}
+}
+
+func (p *Process) handshake(ctx context.Context, options map[string]string) error {
+	if err := p.send("uci"); err != nil {
+		return err
+	}
+	err := p.readUntil(ctx, func(line string) bool {
+		if name, ok := strings.CutPrefix(line, "id name "); ok {
+			p.name = name
+		}
+		return line == "uciok"
+	})
+	if err != nil {
+		return fmt.Errorf("uci handshake: %w", err)
+	}
+
+	for name, value := range options {
+		if err := p.send("setoption name %s value %s", name, value); err != nil {
+			return err
+		}
+	}
+	return p.ready(ctx)
+}
+
+// ready waits until the engine has processed everything sent so far.
+func (p *Process) ready(ctx context.Context) error {
+	if err := p.send("isready"); err != nil {
+		return err
+	}
+	if err := p.readUntil(ctx, func(line string) bool { return line == "readyok" }); err != nil {
+		return fmt.Errorf("isready: %w", err)
+	}
+	return nil
+}
+
+// Name is the engine's name as reported during the handshake.
+func (p *Process) Name() string {
+	return p.name
+}
+
+func (p *Process) send(format string, args ...any) error {
+	if _, err := fmt.Fprintf(p.stdin, format+"\n", args...); err != nil {
+		p.broken = true
+		return fmt.Errorf("%w: %v", ErrEngineDied, err)
+	}
+	return nil
+}
+
+// readUntil consumes output lines until done returns true.
+func (p *Process) readUntil(ctx context.Context, done func(line string) bool) error {
+	for {
+		select {
+		case line, ok := <-p.lines:
+			if !ok {
+				p.broken = true
+				return ErrEngineDied
+			}
+			if done(strings.TrimSpace(line)) {
+				return nil
+			}
+		case <-ctx.Done():
+			p.broken = true
+			return ctx.Err()
+		}
+	}
+}
+
+// Search runs "go" on the position with the given limits and collects the
+// engine's info lines into a Result like the built-in engine's. Limits.Info
+// is not called. When ctx is cancelled the engine is told to stop and its
+// best move so far is returned.
+//
+// Only the position itself is sent, not the moves leading to it, so the
+// engine cannot see repetitions of earlier positions. UCI_Chess960 is turned
+// on for Chess960 boards, whose castling moves are written king takes rook,
+// and UCI_Variant is set for other variants.
+func (p *Process) Search(ctx context.Context, board *chess.Board, limits engine.Limits) (engine.Result, error) {
+	if p.broken {
+		return engine.Result{}, ErrEngineDied
+	}
+	if len(board.LegalMoves()) == 0 {
+		return engine.Result{}, engine.ErrNoLegalMoves
+	}
+
+	multiPV := max(limits.MultiPV, 1)
+	if multiPV != p.multiPV {
+		if err := p.send("setoption name MultiPV value %d", multiPV); err != nil {
+			return engine.Result{}, err
+		}
+		p.multiPV = multiPV
+	}
+	if board.Chess960() != p.chess960 {
+		if err := p.send("setoption name UCI_Chess960 value %t", board.Chess960()); err != nil {
+			return engine.Result{}, err
+		}
+		p.chess960 = board.Chess960()
+	}
+	variant, ok := uciVariants[board.Variant().Name()]
+	if !ok {
+		variant = "chess"
+	}
+	// engines without the option only ever see standard games
+	if variant != p.variant && (p.variant != "" || variant != "chess") {
+		if err := p.send("setoption name UCI_Variant value %s", variant); err != nil {
+			return engine.Result{}, err
+		}
+		p.variant = variant
+	}
+	if err := p.send("position fen %s", board.ToFEN()); err != nil {
+		return engine.Result{}, err
+	}
+
+	start := time.Now()
+	if err := p.send("%s", goCommand(limits)); err != nil {
+		return engine.Result{}, err
+	}
+
+	c := collector{board: board, multiPV: multiPV}
+	var stopDeadline <-chan time.Time
+	for {
+		select {
+		case line, ok := <-p.lines:
+			if !ok {
+				p.broken = true
+				return engine.Result{}, ErrEngineDied
+			}
+			best, done, err := c.add(line)
+			if err != nil {
+				// the engine's state is unknown, so it is not reused
+				p.broken = true
+				return engine.Result{}, err
+			}
+			if done {
+				result := c.result(best)
+				result.Elapsed = time.Since(start)
+				return result, nil
+			}
+		case <-ctx.Done():
+			if err := p.send("stop"); err != nil {
+				return engine.Result{}, err
+			}
+			stopDeadline = time.After(stopTimeout)
+			ctx = context.Background()
+		case <-stopDeadline:
+			p.broken = true
+			return engine.Result{}, fmt.Errorf("%w: no bestmove after stop", ErrEngineDied)
+		}
+	}
+}
+
+// goCommand turns limits into a go command. Without any limit the engine
+// searches until it is stopped.
+func goCommand(limits engine.Limits) string {
+	parts := []string{"go"}
+	if limits.Depth > 0 {
+		parts = append(parts, "depth", strconv.Itoa(limits.Depth))
+	}
+	if limits.Nodes > 0 {
+		parts = append(parts, "nodes", strconv.FormatUint(limits.Nodes, 10))
+	}
+	if limits.MoveTime > 0 {
+		parts = append(parts, "movetime", strconv.FormatInt(max(limits.MoveTime.Milliseconds(), 1), 10))
+	}
+	if len(parts) == 1 {
+		parts = append(parts, "infinite")
+	}
+	return strings.Join(parts, " ")
+}
+
+// Close asks the engine to quit and kills it if it does not. Output still
+// pending is discarded.
+func (p *Process) Close() error {
+	if !p.broken {
+		_ = p.send("quit")
+	}
+	_ = p.stdin.Close()
+
+	// stdout must be read to the end before Wait closes it
+	timeout := time.After(quitTimeout)
+	for {
+		select {
+		case _, ok := <-p.lines:
+			if !ok {
+				return p.cmd.Wait()
+			}
+		case <-timeout:
+			p.kill()
+			timeout = nil
+		}
+	}
+}
+
+func (p *Process) kill() {
+	p.broken = true
+	if p.cmd.Process != nil {
+		_ = p.cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,50 @@
+#!/bin/sh
+# A stand-in UCI engine for tests. It always recommends the same moves, mates
+# with the rook when the position has one on a1, and with "go infinite" waits
+# for stop before answering.
+multipv=1
+position=""
+searching=""
+
+answer() {
+	case "$position" in
+	*R5K1*)
+		echo "info depth 1 multipv 1 score mate 1 nodes 12 pv a1a8"
+		echo "bestmove a1a8"
+		;;
+	*)
+		echo "info depth 1 multipv 1 score cp 10 nodes 20 pv e2e4"
+		echo "info depth 2 seldepth 3 multipv 1 score cp 30 lowerbound nodes 60 pv d2d4"
+		echo "info depth 2 seldepth 3 multipv 1 score cp 25 nodes 100 nps 1000 pv e2e4 e7e5"
+		if [ "$multipv" -ge 2 ]; then
+			echo "info depth 2 seldepth 3 multipv 2 score cp -15 nodes 120 pv d2d4 d7d5"
+		fi
+		echo "info string searched $position"
+		echo "bestmove e2e4 ponder e7e5"
+		;;
+	esac
+}
+
+while read -r line; do
+	case "$line" in
+	uci)
+		echo "id name Fake Engine 1.0"
+		echo "id author go-chess tests"
+		echo "option name MultiPV type spin default 1 min 1 max 500"
+		echo "uciok"
+		;;
+	isready) echo "readyok" ;;
+	"setoption name MultiPV value "*) multipv=${line##* } ;;
+	"setoption name Crash value true") exit 1 ;;
+	"position fen "*) position=${line#position fen } ;;
+	"go infinite"*) searching=1 ;;
+	go*) answer ;;
+	stop)
+		if [ -n "$searching" ]; then
+			searching=""
+			answer
+		fi
+		;;
+	quit) exit 0 ;;
+	esac
+done
@@ -0,0 +1,218 @@
+package uci
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"chess-backend/internal/chess"
+	"chess-backend/internal/engine"
+)
+
+func fakeConfig() Config {
+	return Config{Path: "/bin/sh", Args: []string{"testdata/fake_engine.sh"}}
+}
+
+func newTestPool(t *testing.T, size int) *Pool {
+	t.Helper()
+	pool, err := NewPool(context.Background(), fakeConfig(), size)
+	if err != nil {
+		t.Fatalf("new pool: %v", err)
+	}
+	t.Cleanup(func() { pool.Close() })
+	return pool
+}
+
+func mustLoadFEN(t *testing.T, fen string) *chess.Board {
+	t.Helper()
+	b, err := chess.LoadFEN(fen)
+	if err != nil {
+		t.Fatalf("load fen: %v", err)
+	}
+	return b
+}
+
+func pvString(pv []chess.Move) string {
+	moves := make([]string, len(pv))
+	for i, m := range pv {
+		moves[i] = m.UCI()
+	}
+	return strings.Join(moves, " ")
+}
+
+func TestSearchCollectsInfo(t *testing.T) {
+	pool := newTestPool(t, 1)
+	if pool.Name() != "Fake Engine 1.0" {
+		t.Fatalf("expected engine name from handshake, got %q", pool.Name())
+	}
+
+	result, err := pool.Search(context.Background(), chess.NewBoard(), engine.Limits{Depth: 2})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if result.Move.UCI() != "e2e4" || result.Score != 25 || result.Depth != 2 || result.Nodes != 100 {
+		t.Fatalf("unexpected result: move %s score %d depth %d nodes %d",
+			result.Move.UCI(), result.Score, result.Depth, result.Nodes)
+	}
+	if got := pvString(result.PV); got != "e2e4 e7e5" {
+		t.Fatalf("expected pv e2e4 e7e5, got %q", got)
+	}
+	if len(result.Lines) != 1 {
+		t.Fatalf("expected one line, got %d", len(result.Lines))
+	}
+
+	result, err = pool.Search(context.Background(), chess.NewBoard(), engine.Limits{Depth: 2, MultiPV: 2})
+	if err != nil {
+		t.Fatalf("multipv search: %v", err)
+	}
+	if len(result.Lines) != 2 || result.Lines[1].Score != -15 || pvString(result.Lines[1].PV) != "d2d4 d7d5" {
+		t.Fatalf("unexpected multipv lines: %+v", result.Lines)
+	}
+}
+
+func TestSearchMateScore(t *testing.T) {
+	pool := newTestPool(t, 1)
+	b := mustLoadFEN(t, "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1")
+
+	result, err := pool.Search(context.Background(), b, engine.Limits{Depth: 3})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if mate, ok := result.Score.Mate(); !ok || mate != 1 {
+		t.Fatalf("expected mate in 1, got score %d", result.Score)
+	}
+	if result.Move.UCI() != "a1a8" {
+		t.Fatalf("expected a1a8, got %s", result.Move.UCI())
+	}
+}
+
+func TestSearchStopsWhenCancelled(t *testing.T) {
+	pool := newTestPool(t, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	result, err := pool.Search(ctx, chess.NewBoard(), engine.Limits{})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if result.Move.UCI() != "e2e4" {
+		t.Fatalf("expected e2e4 after stop, got %s", result.Move.UCI())
+	}
+
+	// the process is still usable afterwards
+	if _, err := pool.Search(context.Background(), chess.NewBoard(), engine.Limits{Depth: 1}); err != nil {
+		t.Fatalf("search after stop: %v", err)
+	}
+}
+
+func TestSearchWithoutLegalMoves(t *testing.T) {
+	pool := newTestPool(t, 1)
+	b := mustLoadFEN(t, "7k/5Q2/6K1/8/8/8/8/8 b - - 0 1")
+
+	if _, err := pool.Search(context.Background(), b, engine.Limits{Depth: 1}); !errors.Is(err, engine.ErrNoLegalMoves) {
+		t.Fatalf("expected ErrNoLegalMoves, got %v", err)
+	}
+}
+
+func TestPoolConcurrentSearches(t *testing.T) {
+	pool := newTestPool(t, 2)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := pool.Search(context.Background(), chess.NewBoard(), engine.Limits{Depth: 2})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("concurrent search: %v", err)
+		}
+	}
+	if n := len(pool.idle); n > 2 {
+		t.Fatalf("pool kept %d processes, limit is 2", n)
+	}
+}
+
+func TestStartFailures(t *testing.T) {
+	if _, err := Start(context.Background(), Config{}); !errors.Is(err, ErrNoPath) {
+		t.Fatalf("expected ErrNoPath, got %v", err)
+	}
+	if _, err := Start(context.Background(), Config{Path: "testdata/no-such-engine"}); err == nil {
+		t.Fatal("expected an error for a missing binary")
+	}
+
+	cfg := fakeConfig()
+	cfg.Options = map[string]string{"Crash": "true"}
+	if _, err := Start(context.Background(), cfg); !errors.Is(err, ErrEngineDied) {
+		t.Fatalf("expected ErrEngineDied, got %v", err)
+	}
+}
+
+func TestClosedPool(t *testing.T) {
+	pool := newTestPool(t, 1)
+	if err := pool.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if _, err := pool.Search(context.Background(), chess.NewBoard(), engine.Limits{Depth: 1}); !errors.Is(err, ErrPoolClosed) {
+		t.Fatalf("expected ErrPoolClosed, got %v", err)
+	}
+}
+
+func TestParseInfo(t *testing.T) {
+	tests := []struct {
+		line     string
+		depth    int
+		multiPV  int
+		score    engine.Score
+		hasScore bool
+		pv       string
+	}{
+		{"depth 12 seldepth 18 multipv 3 score cp -42 nodes 9000 nps 1 time 9 pv e2e4 c7c5", 12, 3, -42, true, "e2e4 c7c5"},
+		{"depth 5 score mate 2 pv d1h5", 5, 1, engine.MateScore - 3, true, "d1h5"},
+		{"depth 5 score mate -1 pv g8h8", 5, 1, -engine.MateScore + 2, true, "g8h8"},
+		{"depth 7 score cp 80 upperbound pv a2a4", 7, 1, 80, false, "a2a4"},
+		{"currmove e2e4 currmovenumber 1", 0, 1, 0, false, ""},
+		{"string depth 99 score cp 1", 0, 1, 0, false, ""},
+	}
+	for _, tt := range tests {
+		in, err := parseInfo(strings.Fields(tt.line))
+		if err != nil {
+			t.Fatalf("%q: %v", tt.line, err)
+		}
+		if in.depth != tt.depth || in.multiPV != tt.multiPV || in.hasScore != tt.hasScore ||
+			(tt.hasScore && in.score != tt.score) || pvString(in.pv) != tt.pv {
+			t.Fatalf("%q: unexpected %+v", tt.line, in)
+		}
+	}
+
+	for _, line := range []string{"depth", "score cp", "score wdl 1 2 3", "pv e2e9"} {
+		if _, err := parseInfo(strings.Fields(line)); !errors.Is(err, ErrProtocol) {
+			t.Fatalf("%q: expected ErrProtocol, got %v", line, err)
+		}
+	}
+}
+
+func TestGoCommand(t *testing.T) {
+	tests := []struct {
+		limits engine.Limits
+		want   string
+	}{
+		{engine.Limits{}, "go infinite"},
+		{engine.Limits{Depth: 8}, "go depth 8"},
+		{engine.Limits{Nodes: 5000, MoveTime: 1500 * time.Millisecond}, "go nodes 5000 movetime 1500"},
+	}
+	for _, tt := range tests {
+		if got := goCommand(tt.limits); got != tt.want {
+			t.Fatalf("goCommand(%+v) = %q, want %q", tt.limits, got, tt.want)
+		}
+	}
+}
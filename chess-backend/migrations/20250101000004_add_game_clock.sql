@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE games
+    ADD COLUMN clock JSONB;
+
+-- +goose Down
+ALTER TABLE games
+    DROP COLUMN clock;
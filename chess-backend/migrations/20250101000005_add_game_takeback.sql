@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE games
+    ADD COLUMN pending_takeback_by TEXT;
+
+-- +goose Down
+ALTER TABLE games
+    DROP COLUMN pending_takeback_by;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE client_moves (
+    game_id TEXT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    client_move_id TEXT NOT NULL,
+    color TEXT NOT NULL,
+    ply INTEGER NOT NULL,
+    response JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (game_id, client_move_id)
+);
+
+-- +goose Down
+DROP TABLE client_moves;
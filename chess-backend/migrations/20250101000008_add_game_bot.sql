@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE games
+    ADD COLUMN bot_color TEXT,
+    ADD COLUMN bot_level INTEGER;
+
+-- +goose Down
+ALTER TABLE games
+    DROP COLUMN bot_color,
+    DROP COLUMN bot_level;
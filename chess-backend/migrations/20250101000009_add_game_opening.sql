@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE games
+    ADD COLUMN opening_eco TEXT,
+    ADD COLUMN opening_name TEXT,
+    ADD COLUMN opening_variation TEXT;
+
+-- +goose Down
+ALTER TABLE games
+    DROP COLUMN opening_eco,
+    DROP COLUMN opening_name,
+    DROP COLUMN opening_variation;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE games
+    ADD COLUMN variant TEXT NOT NULL DEFAULT 'standard';
+
+-- +goose Down
+ALTER TABLE games
+    DROP COLUMN variant;